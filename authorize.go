@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/web"
+)
+
+// authorizeCommand is the subcommand used for authorizing the exporter on the terminal.
+const authorizeCommand = "authorize"
+
+// authorize asks the user to open the NetAtmo authorization page and to paste the URL they are redirected to.
+// The token received for the code contained in that URL is retrieved using tokenFunc and written to tokenFile.
+func authorize(ctx context.Context, client web.OAuthClient, tokenFunc func() (*oauth2.Token, error), externalURL string, scopes []string, tokenFile string, in io.Reader, out io.Writer) error {
+	if tokenFile == "" {
+		return errors.New("a token file is needed for saving the token")
+	}
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		return fmt.Errorf("error creating state: %w", err)
+	}
+	wantState := hex.EncodeToString(stateBytes)
+
+	authURL, err := web.AuthCodeURL(client, externalURL, wantState, scopes)
+	if err != nil {
+		return fmt.Errorf("error creating authorization URL: %w", err)
+	}
+
+	fmt.Fprintf(out, "Open the following URL in a browser and allow the access:\n\n%s\n\n", authURL)
+	fmt.Fprintln(out, "Afterwards the browser is redirected to the exporter. The page does not need to load.")
+	fmt.Fprint(out, "Paste the URL from the address bar of the browser or only the code contained in it: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+
+	code, state, err := web.ParseCallback(line)
+	if err != nil {
+		return err
+	}
+
+	if state != "" && state != wantState {
+		return errors.New("state does not match, the URL belongs to a different authorization")
+	}
+
+	if err := client.Exchange(ctx, code, wantState); err != nil {
+		return fmt.Errorf("error exchanging code: %w", err)
+	}
+
+	if err := saveToken(tokenFunc, tokenFile); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Token saved to %s.\n", tokenFile)
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+type testAuthClient struct {
+	exchangedCode string
+	token         *oauth2.Token
+}
+
+func (c *testAuthClient) AuthCodeURL(redirectURL, state string) string {
+	return "https://api.netatmo.com/oauth2/authorize?" + url.Values{
+		"redirect_uri": {redirectURL},
+		"state":        {state},
+	}.Encode()
+}
+
+func (c *testAuthClient) Exchange(_ context.Context, code, _ string) error {
+	c.exchangedCode = code
+	c.token = &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+	return nil
+}
+
+func (c *testAuthClient) InitWithToken(_ context.Context, token *oauth2.Token) {
+	c.token = token
+}
+
+func (c *testAuthClient) CurrentToken() (*oauth2.Token, error) {
+	return c.token, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	tt := []struct {
+		desc      string
+		input     string
+		wantCode  string
+		wantToken bool
+		wantErr   bool
+	}{
+		{
+			desc:      "code",
+			input:     "code\n",
+			wantCode:  "code",
+			wantToken: true,
+		},
+		{
+			desc:      "callback URL without newline",
+			input:     "http://127.0.0.1:9210/auth/callback?code=code",
+			wantCode:  "code",
+			wantToken: true,
+		},
+		{
+			desc:    "state mismatch",
+			input:   "http://127.0.0.1:9210/auth/callback?state=other&code=code\n",
+			wantErr: true,
+		},
+		{
+			desc:    "not accepted",
+			input:   "http://127.0.0.1:9210/auth/callback?error=access_denied\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tokenFile := filepath.Join(t.TempDir(), "token.json")
+			client := &testAuthClient{}
+			out := &bytes.Buffer{}
+
+			err := authorize(context.Background(), client, client.CurrentToken, "http://127.0.0.1:9210", []string{"read_station"}, tokenFile, strings.NewReader(tc.input), out)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+
+			if client.exchangedCode != tc.wantCode {
+				t.Errorf("got exchanged code %q, want %q", client.exchangedCode, tc.wantCode)
+			}
+
+			data, err := os.ReadFile(tokenFile)
+			if !tc.wantToken {
+				if err == nil {
+					t.Error("token file written after failed authorization")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error reading token file: %s", err)
+			}
+
+			var token oauth2.Token
+			if err := json.Unmarshal(data, &token); err != nil {
+				t.Fatalf("error decoding token file: %s", err)
+			}
+
+			if token.RefreshToken != "refresh" {
+				t.Errorf("got refresh token %q, want %q", token.RefreshToken, "refresh")
+			}
+		})
+	}
+}
+
+func TestAuthorizeNoTokenFile(t *testing.T) {
+	client := &testAuthClient{}
+
+	err := authorize(context.Background(), client, client.CurrentToken, "http://127.0.0.1:9210", nil, "", strings.NewReader("code\n"), &bytes.Buffer{})
+	if err == nil {
+		t.Error("got no error")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+)
+
+// dryRun reads the data from the NetAtmo API once and prints a summary of the discovered stations and modules.
+// Healthy Home Coach devices are only read when homeCoachFunc is not nil.
+func dryRun(out io.Writer, readFunc func() (*netatmo.DeviceCollection, error), homeCoachFunc collector.HomeCoachReadFunction) error {
+	devices, err := readFunc()
+	if err != nil {
+		return fmt.Errorf("error reading data: %w", err)
+	}
+
+	stations := devices.Devices()
+	fmt.Fprintf(out, "Found %d stations.\n", len(stations))
+	for _, station := range stations {
+		fmt.Fprintf(out, "Station %q in home %q (%s, %s) with %d modules:\n", station.StationName, station.HomeName, station.ID, station.Type, len(station.LinkedModules)) //nolint: staticcheck
+		for _, module := range station.LinkedModules {
+			fmt.Fprintf(out, "  - %q (%s, %s)\n", module.ModuleName, module.ID, module.Type)
+		}
+	}
+
+	if homeCoachFunc == nil {
+		return nil
+	}
+
+	homeCoaches, err := homeCoachFunc()
+	if err != nil {
+		return fmt.Errorf("error reading Healthy Home Coach data: %w", err)
+	}
+
+	fmt.Fprintf(out, "Found %d Healthy Home Coaches.\n", len(homeCoaches))
+	for _, homeCoach := range homeCoaches {
+		fmt.Fprintf(out, "  - %q (%s, %s)\n", homeCoach.ModuleName, homeCoach.ID, homeCoach.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+)
+
+func TestDryRun(t *testing.T) {
+	readStations := func() (*netatmo.DeviceCollection, error) {
+		devices := &netatmo.DeviceCollection{}
+		devices.Body.Devices = []*netatmo.Device{
+			{
+				ID:          "70:ee:50:00:00:01",
+				StationName: "Home", //nolint: staticcheck
+				HomeName:    "House",
+				Type:        "NAMain",
+				LinkedModules: []*netatmo.Device{
+					{
+						ID:         "02:00:00:00:00:01",
+						ModuleName: "Outdoor",
+						Type:       "NAModule1",
+					},
+				},
+			},
+		}
+		return devices, nil
+	}
+	readHomeCoaches := func() ([]*api.HomeCoach, error) {
+		return []*api.HomeCoach{
+			{
+				Device: netatmo.Device{
+					ID:         "70:ee:50:00:00:02",
+					ModuleName: "Bedroom",
+					Type:       "NHC",
+				},
+			},
+		}, nil
+	}
+	testErr := errors.New("test error")
+
+	tt := []struct {
+		desc          string
+		readFunc      func() (*netatmo.DeviceCollection, error)
+		homeCoachFunc collector.HomeCoachReadFunction
+		wantOutput    string
+		wantErr       error
+	}{
+		{
+			desc:     "stations",
+			readFunc: readStations,
+			wantOutput: `Found 1 stations.
+Station "Home" in home "House" (70:ee:50:00:00:01, NAMain) with 1 modules:
+  - "Outdoor" (02:00:00:00:00:01, NAModule1)
+`,
+		},
+		{
+			desc:          "stations and home coaches",
+			readFunc:      readStations,
+			homeCoachFunc: readHomeCoaches,
+			wantOutput: `Found 1 stations.
+Station "Home" in home "House" (70:ee:50:00:00:01, NAMain) with 1 modules:
+  - "Outdoor" (02:00:00:00:00:01, NAModule1)
+Found 1 Healthy Home Coaches.
+  - "Bedroom" (70:ee:50:00:00:02, NHC)
+`,
+		},
+		{
+			desc: "read error",
+			readFunc: func() (*netatmo.DeviceCollection, error) {
+				return nil, testErr
+			},
+			wantErr: testErr,
+		},
+		{
+			desc:     "home coach error",
+			readFunc: readStations,
+			homeCoachFunc: func() ([]*api.HomeCoach, error) {
+				return nil, testErr
+			},
+			wantOutput: `Found 1 stations.
+Station "Home" in home "House" (70:ee:50:00:00:01, NAMain) with 1 modules:
+  - "Outdoor" (02:00:00:00:00:01, NAModule1)
+`,
+			wantErr: testErr,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			err := dryRun(&out, tc.readFunc, tc.homeCoachFunc)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("got error %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(out.String(), tc.wantOutput); diff != "" {
+				t.Errorf("output differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/transport"
+)
+
+const (
+	// ScopeReadStation is needed for reading weather station data.
+	ScopeReadStation = "read_station"
+	// ScopeReadHomeCoach is needed for reading Healthy Home Coach data.
+	ScopeReadHomeCoach = "read_homecoach"
+	// ScopeReadThermostat is needed for reading data of NetAtmo Energy devices.
+	ScopeReadThermostat = "read_thermostat"
+
+	// errorCodeUsageReached is the error code returned by the NetAtmo API when the rate limit of the user is reached.
+	errorCodeUsageReached = 26
+)
+
+// ErrRateLimited is returned when the NetAtmo API rejected a request because the rate limit has been reached.
+var ErrRateLimited = errors.New("rate limit of the NetAtmo API reached")
+
+// TokenFunc returns the token used for authenticating requests.
+type TokenFunc func() (*oauth2.Token, error)
+
+// Token implements oauth2.TokenSource.
+func (f TokenFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+// Client provides access to parts of the NetAtmo API which are not covered by the netatmo-api-go library.
+// It uses the token of the library client for authentication.
+type Client struct {
+	httpClient *http.Client
+}
+
+// New creates a new Client which uses tokenFunc for retrieving a token and the provided base transport
+// for making the requests. Requests taking longer than timeout are aborted, a zero timeout disables this.
+func New(tokenFunc TokenFunc, base http.RoundTripper, timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &oauth2.Transport{
+				Source: tokenFunc,
+				Base:   base,
+			},
+		},
+	}
+}
+
+func (c *Client) get(path string, query url.Values, result any) error {
+	req, err := http.NewRequest(http.MethodGet, transport.DefaultAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return ErrRateLimited
+		}
+
+		buf := &bytes.Buffer{}
+		if _, err := io.Copy(buf, resp.Body); err != nil {
+			return fmt.Errorf("error reading body for status code %d: %w", resp.StatusCode, err)
+		}
+
+		var errResp netatmo.ErrorResponse
+		if err := json.Unmarshal(buf.Bytes(), &errResp); err != nil {
+			return fmt.Errorf("can not parse error message for status %d: %s - parse error: %w", resp.StatusCode, buf.String(), err)
+		}
+
+		if errResp.Error.Code == errorCodeUsageReached {
+			return fmt.Errorf("%w: %s", ErrRateLimited, errResp.Error.Message)
+		}
+
+		if errResp.Error.Message != "" {
+			return fmt.Errorf("got error %d: %s (HTTP status %d)", errResp.Error.Code, errResp.Error.Message, resp.StatusCode)
+		}
+
+		return fmt.Errorf("got non-ok HTTP status %d: %s", resp.StatusCode, buf.String())
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
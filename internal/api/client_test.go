@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestClientRateLimited(t *testing.T) {
+	tt := []struct {
+		desc       string
+		statusCode int
+		body       string
+		wantLimit  bool
+	}{
+		{
+			desc:       "too many requests",
+			statusCode: http.StatusTooManyRequests,
+			body:       "",
+			wantLimit:  true,
+		},
+		{
+			desc:       "usage reached",
+			statusCode: http.StatusForbidden,
+			body:       `{"error": {"code": 26, "message": "User usage reached"}}`,
+			wantLimit:  true,
+		},
+		{
+			desc:       "other error",
+			statusCode: http.StatusForbidden,
+			body:       `{"error": {"code": 3, "message": "Access token expired"}}`,
+			wantLimit:  false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := New(func() (*oauth2.Token, error) {
+				return &oauth2.Token{AccessToken: "token"}, nil
+			}, roundTripFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tc.statusCode,
+					Body:       io.NopCloser(strings.NewReader(tc.body)),
+				}, nil
+			}), 0)
+
+			err := client.get(stationsPath, url.Values{}, nil)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+
+			if limited := errors.Is(err, ErrRateLimited); limited != tc.wantLimit {
+				t.Errorf("got rate limited %v, want %v (error: %s)", limited, tc.wantLimit, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	homesDataPath  = "api/homesdata"
+	homeStatusPath = "api/homestatus"
+)
+
+// Home contains the configuration and current state of a home with NetAtmo Energy devices.
+type Home struct {
+	ID   string
+	Name string
+
+	// ScheduleID and ScheduleName identify the currently selected heating schedule of the home.
+	ScheduleID   string
+	ScheduleName string
+
+	Rooms []*Room
+}
+
+// Room contains the configuration and current state of a room managed by NetAtmo Energy devices.
+type Room struct {
+	ID   string
+	Name string
+
+	// MeasuredTemperature is the temperature measured in the room in celsius.
+	MeasuredTemperature *float64
+	// SetpointTemperature is the target temperature of the room in celsius.
+	SetpointTemperature *float64
+	// HeatingPowerRequest is the heating power requested by the room in percent. For rooms with valves this
+	// corresponds to how far the valves are opened.
+	HeatingPowerRequest *int32
+	// BoilerOn is the boiler status reported by the thermostat in the room. It is nil, if there is no thermostat
+	// controlling a boiler in the room.
+	BoilerOn *bool
+}
+
+// HasThermostat returns true if the room reports data of a thermostat or valve.
+func (r *Room) HasThermostat() bool {
+	return r.MeasuredTemperature != nil || r.SetpointTemperature != nil
+}
+
+type roomStatus struct {
+	ID                  string   `json:"id"`
+	MeasuredTemperature *float64 `json:"therm_measured_temperature"`
+	SetpointTemperature *float64 `json:"therm_setpoint_temperature"`
+	HeatingPowerRequest *int32   `json:"heating_power_request"`
+}
+
+type moduleStatus struct {
+	ID           string `json:"id"`
+	BoilerStatus *bool  `json:"boiler_status"`
+}
+
+// ReadHomes returns all homes of the user, which contain rooms, together with their current state. This needs one
+// request for the list of homes and one additional request per home.
+func (c *Client) ReadHomes() ([]*Home, error) {
+	var homesData struct {
+		Body struct {
+			Homes []struct {
+				ID    string `json:"id"`
+				Name  string `json:"name"`
+				Rooms []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"rooms"`
+				Modules []struct {
+					ID     string `json:"id"`
+					RoomID string `json:"room_id"`
+				} `json:"modules"`
+				Schedules []struct {
+					ID       string `json:"id"`
+					Name     string `json:"name"`
+					Selected bool   `json:"selected"`
+				} `json:"schedules"`
+			} `json:"homes"`
+		} `json:"body"`
+	}
+	if err := c.get(homesDataPath, url.Values{}, &homesData); err != nil {
+		return nil, err
+	}
+
+	var result []*Home
+	for _, homeData := range homesData.Body.Homes {
+		if len(homeData.Rooms) == 0 {
+			continue
+		}
+
+		var homeStatus struct {
+			Body struct {
+				Home struct {
+					Rooms   []roomStatus   `json:"rooms"`
+					Modules []moduleStatus `json:"modules"`
+				} `json:"home"`
+			} `json:"body"`
+		}
+		if err := c.get(homeStatusPath, url.Values{"home_id": {homeData.ID}}, &homeStatus); err != nil {
+			return nil, fmt.Errorf("error reading status of home %q: %w", homeData.Name, err)
+		}
+
+		home := &Home{
+			ID:   homeData.ID,
+			Name: homeData.Name,
+		}
+		for _, schedule := range homeData.Schedules {
+			if schedule.Selected {
+				home.ScheduleID = schedule.ID
+				home.ScheduleName = schedule.Name
+			}
+		}
+
+		rooms := make(map[string]roomStatus, len(homeStatus.Body.Home.Rooms))
+		for _, room := range homeStatus.Body.Home.Rooms {
+			rooms[room.ID] = room
+		}
+
+		boilerStatus := make(map[string]*bool)
+		for _, module := range homeStatus.Body.Home.Modules {
+			if module.BoilerStatus != nil {
+				boilerStatus[module.ID] = module.BoilerStatus
+			}
+		}
+
+		roomBoiler := make(map[string]*bool)
+		for _, module := range homeData.Modules {
+			if status, ok := boilerStatus[module.ID]; ok && module.RoomID != "" {
+				roomBoiler[module.RoomID] = status
+			}
+		}
+
+		for _, roomData := range homeData.Rooms {
+			status := rooms[roomData.ID]
+			home.Rooms = append(home.Rooms, &Room{
+				ID:                  roomData.ID,
+				Name:                roomData.Name,
+				MeasuredTemperature: status.MeasuredTemperature,
+				SetpointTemperature: status.SetpointTemperature,
+				HeatingPowerRequest: status.HeatingPowerRequest,
+				BoilerOn:            roomBoiler[roomData.ID],
+			})
+		}
+
+		result = append(result, home)
+	}
+
+	return result, nil
+}
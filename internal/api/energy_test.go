@@ -0,0 +1,116 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/oauth2"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestReadHomes(t *testing.T) {
+	responses := map[string]string{
+		"/api/homesdata": `{
+  "body": {
+    "homes": [
+      {
+        "id": "home1",
+        "name": "Home",
+        "rooms": [
+          {"id": "1", "name": "Living Room"},
+          {"id": "2", "name": "Bedroom"}
+        ],
+        "modules": [
+          {"id": "04:00:00:00:00:01", "type": "NATherm1", "room_id": "1"}
+        ],
+        "schedules": [
+          {"id": "s1", "name": "Default", "selected": false},
+          {"id": "s2", "name": "Winter", "selected": true}
+        ]
+      },
+      {
+        "id": "home2",
+        "name": "Weather only"
+      }
+    ]
+  }
+}`,
+		"/api/homestatus?home_id=home1": `{
+  "body": {
+    "home": {
+      "id": "home1",
+      "rooms": [
+        {
+          "id": "1",
+          "therm_measured_temperature": 20.5,
+          "therm_setpoint_temperature": 21,
+          "heating_power_request": 40
+        }
+      ],
+      "modules": [
+        {"id": "04:00:00:00:00:01", "type": "NATherm1", "boiler_status": true}
+      ]
+    }
+  }
+}`,
+	}
+
+	client := New(func() (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "token"}, nil
+	}, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request: %s", req.URL)
+			return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}), 0)
+
+	homes, err := client.ReadHomes()
+	if err != nil {
+		t.Fatalf("error reading homes: %s", err)
+	}
+
+	measured := 20.5
+	setpoint := 21.0
+	heatingPower := int32(40)
+	boilerOn := true
+	wantHomes := []*Home{
+		{
+			ID:           "home1",
+			Name:         "Home",
+			ScheduleID:   "s2",
+			ScheduleName: "Winter",
+			Rooms: []*Room{
+				{
+					ID:                  "1",
+					Name:                "Living Room",
+					MeasuredTemperature: &measured,
+					SetpointTemperature: &setpoint,
+					HeatingPowerRequest: &heatingPower,
+					BoilerOn:            &boilerOn,
+				},
+				{
+					ID:   "2",
+					Name: "Bedroom",
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(homes, wantHomes); diff != "" {
+		t.Errorf("homes differ: -got+want\n%s", diff)
+	}
+}
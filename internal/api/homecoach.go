@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/exzz/netatmo-api-go"
+)
+
+const homeCoachPath = "api/gethomecoachsdata"
+
+// HomeCoach contains the data of a Healthy Home Coach device.
+type HomeCoach struct {
+	netatmo.Device
+
+	// HealthIndex contains the "health index" computed by the device, 0 being healthy and 4 being unhealthy.
+	HealthIndex *int32
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *HomeCoach) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &h.Device); err != nil {
+		return err
+	}
+
+	var extra struct {
+		DashboardData struct {
+			HealthIndex *int32 `json:"health_idx"`
+		} `json:"dashboard_data"`
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	h.HealthIndex = extra.DashboardData.HealthIndex
+
+	return nil
+}
+
+// ReadHomeCoaches returns the Healthy Home Coach devices available to the user.
+func (c *Client) ReadHomeCoaches() ([]*HomeCoach, error) {
+	var result struct {
+		Body struct {
+			Devices []*HomeCoach `json:"devices"`
+		} `json:"body"`
+	}
+	if err := c.get(homeCoachPath, url.Values{}, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Body.Devices, nil
+}
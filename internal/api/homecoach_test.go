@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHomeCoachUnmarshal(t *testing.T) {
+	tt := []struct {
+		desc          string
+		input         string
+		wantHomeCoach *HomeCoach
+	}{
+		{
+			desc: "with health index",
+			input: `{
+  "_id": "70:ee:50:00:00:01",
+  "type": "NHC",
+  "module_name": "Bedroom",
+  "station_name": "Bedroom",
+  "wifi_status": 50,
+  "dashboard_data": {
+    "time_utc": 3600,
+    "Temperature": 21.5,
+    "CO2": 800,
+    "Humidity": 50,
+    "Noise": 35,
+    "Pressure": 1012.5,
+    "AbsolutePressure": 1001.2,
+    "health_idx": 1
+  }
+}`,
+			wantHomeCoach: &HomeCoach{
+				Device: netatmo.Device{
+					ID:          "70:ee:50:00:00:01",
+					Type:        "NHC",
+					ModuleName:  "Bedroom",
+					StationName: "Bedroom",
+					WifiStatus:  int32Ptr(50),
+					DashboardData: netatmo.DashboardData{
+						Temperature:      float32Ptr(21.5),
+						Humidity:         int32Ptr(50),
+						CO2:              int32Ptr(800),
+						Noise:            int32Ptr(35),
+						Pressure:         float32Ptr(1012.5),
+						AbsolutePressure: float32Ptr(1001.2),
+						LastMeasure:      int64Ptr(3600),
+					},
+				},
+				HealthIndex: int32Ptr(1),
+			},
+		},
+		{
+			desc: "no dashboard data",
+			input: `{
+  "_id": "70:ee:50:00:00:02",
+  "type": "NHC",
+  "module_name": "Office"
+}`,
+			wantHomeCoach: &HomeCoach{
+				Device: netatmo.Device{
+					ID:         "70:ee:50:00:00:02",
+					Type:       "NHC",
+					ModuleName: "Office",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var homeCoach HomeCoach
+			if err := json.Unmarshal([]byte(tc.input), &homeCoach); err != nil {
+				t.Fatalf("error unmarshalling: %s", err)
+			}
+
+			if diff := cmp.Diff(&homeCoach, tc.wantHomeCoach); diff != "" {
+				t.Errorf("home coach differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func float32Ptr(f float32) *float32 {
+	return &f
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const measurePath = "api/getmeasure"
+
+// Measurement contains the values of the requested measurement types at one point in time.
+// Values can be nil if the module did not provide a value for a type.
+type Measurement struct {
+	Time   time.Time  `json:"time"`
+	Values []*float64 `json:"values"`
+}
+
+// MeasureTypes returns the measurement types supported by a module type.
+func MeasureTypes(moduleType string) []string {
+	switch moduleType {
+	case "NAMain", "NHC":
+		return []string{"Temperature", "CO2", "Humidity", "Noise", "Pressure"}
+	case "NAModule1":
+		return []string{"Temperature", "Humidity"}
+	case "NAModule2":
+		return []string{"WindStrength", "WindAngle", "GustStrength", "GustAngle"}
+	case "NAModule3":
+		return []string{"Rain"}
+	case "NAModule4":
+		return []string{"Temperature", "CO2", "Humidity"}
+	default:
+		return nil
+	}
+}
+
+// ReadMeasurements returns the measurements of a module between begin and end, using the highest resolution available.
+// The moduleID needs to be empty to read the measurements of the station itself.
+func (c *Client) ReadMeasurements(deviceID, moduleID string, types []string, begin, end time.Time) ([]Measurement, error) {
+	query := url.Values{
+		"device_id":  {deviceID},
+		"scale":      {"max"},
+		"type":       {strings.Join(types, ",")},
+		"date_begin": {strconv.FormatInt(begin.Unix(), 10)},
+		"date_end":   {strconv.FormatInt(end.Unix(), 10)},
+		"optimize":   {"false"},
+	}
+	if moduleID != "" {
+		query.Set("module_id", moduleID)
+	}
+
+	var result struct {
+		Body map[string][]*float64 `json:"body"`
+	}
+	if err := c.get(measurePath, query, &result); err != nil {
+		return nil, err
+	}
+
+	measurements := make([]Measurement, 0, len(result.Body))
+	for rawTime, values := range result.Body {
+		timestamp, err := strconv.ParseInt(rawTime, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse measurement time %q: %w", rawTime, err)
+		}
+
+		measurements = append(measurements, Measurement{
+			Time:   time.Unix(timestamp, 0).UTC(),
+			Values: values,
+		})
+	}
+
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].Time.Before(measurements[j].Time)
+	})
+
+	return measurements, nil
+}
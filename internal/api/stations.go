@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/exzz/netatmo-api-go"
+)
+
+const stationsPath = "api/getstationsdata"
+
+// DeviceDetails contains the values of a station or module, which are not decoded by the netatmo-api-go library.
+type DeviceDetails struct {
+	// BatteryVoltage contains the battery voltage of a module in millivolts. It is nil for stations.
+	BatteryVoltage *int32 `json:"battery_vp,omitempty"`
+
+	// Place contains the location of a station. It is nil for modules.
+	Place *Place `json:"place,omitempty"`
+
+	// Reachable is false for modules, which have not sent data to the station recently.
+	Reachable *bool `json:"reachable,omitempty"`
+}
+
+// StationsFunction reads the weather stations and returns them together with the details of the stations and
+// modules by their ID.
+type StationsFunction func() (*netatmo.DeviceCollection, map[string]DeviceDetails, error)
+
+// StationsFile is the format used for writing the station data to a file, for example by the /debug/data endpoint.
+// The details are stored next to the devices, because netatmo.DeviceCollection does not contain them.
+type StationsFile struct {
+	netatmo.DeviceCollection
+	Details map[string]DeviceDetails `json:"details,omitempty"`
+}
+
+// Place contains the location of a station. The city is empty for some stations.
+type Place struct {
+	Country  string `json:"country"`
+	City     string `json:"city"`
+	Timezone string `json:"timezone"`
+}
+
+// stationDevice contains the data of a station or module together with its details.
+type stationDevice struct {
+	netatmo.Device
+
+	Details DeviceDetails
+	Modules []*stationDevice
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *stationDevice) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &d.Device); err != nil {
+		return err
+	}
+
+	var extra struct {
+		BatteryVoltage *int32           `json:"battery_vp"`
+		Place          *Place           `json:"place"`
+		Reachable      *bool            `json:"reachable"`
+		Modules        []*stationDevice `json:"modules"`
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	d.Details.BatteryVoltage = extra.BatteryVoltage
+	d.Details.Place = extra.Place
+	d.Details.Reachable = extra.Reachable
+	d.Modules = extra.Modules
+
+	return nil
+}
+
+// ReadStations returns the weather stations with the provided IDs and their modules. Each station is requested
+// separately, so only the data of the requested stations is transferred. All stations of the user are returned, when
+// no IDs are provided. The details of the stations and modules are returned by their ID.
+func (c *Client) ReadStations(ids []string) (*netatmo.DeviceCollection, map[string]DeviceDetails, error) {
+	result := &netatmo.DeviceCollection{}
+	details := make(map[string]DeviceDetails)
+	if len(ids) == 0 {
+		devices, err := c.readStations(url.Values{"app_type": {"app_station"}})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		addStations(result, details, devices)
+		return result, details, nil
+	}
+
+	for _, id := range ids {
+		devices, err := c.readStations(url.Values{"device_id": {id}})
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading station %q: %w", id, err)
+		}
+
+		addStations(result, details, devices)
+	}
+
+	return result, details, nil
+}
+
+// ReadStationsFunction returns a StationsFunction reading the stations with the provided IDs using ReadStations.
+func (c *Client) ReadStationsFunction(ids []string) StationsFunction {
+	return func() (*netatmo.DeviceCollection, map[string]DeviceDetails, error) {
+		return c.ReadStations(ids)
+	}
+}
+
+func (c *Client) readStations(query url.Values) ([]*stationDevice, error) {
+	var response struct {
+		Body struct {
+			Devices []*stationDevice `json:"devices"`
+		} `json:"body"`
+	}
+	if err := c.get(stationsPath, query, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Body.Devices, nil
+}
+
+// addStations adds the devices to result and their details and the details of their modules to details.
+func addStations(result *netatmo.DeviceCollection, details map[string]DeviceDetails, devices []*stationDevice) {
+	for _, device := range devices {
+		if device == nil {
+			result.Body.Devices = append(result.Body.Devices, nil)
+			continue
+		}
+
+		result.Body.Devices = append(result.Body.Devices, &device.Device)
+		details[device.ID] = device.Details
+		for _, module := range device.Modules {
+			if module != nil {
+				details[module.ID] = module.Details
+			}
+		}
+	}
+}
+
+// StationReader reads the weather stations using a StationsFunction. It keeps the details of the stations and modules
+// returned by the last successful read, so that they can be looked up while collecting the metrics.
+type StationReader struct {
+	read StationsFunction
+
+	lock    sync.RWMutex
+	details map[string]DeviceDetails
+}
+
+// NewStationReader creates a StationReader, which reads the stations using read.
+func NewStationReader(read StationsFunction) *StationReader {
+	return &StationReader{
+		read: read,
+	}
+}
+
+// Read reads the stations and updates the details.
+func (r *StationReader) Read() (*netatmo.DeviceCollection, error) {
+	devices, details, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.details = details
+
+	return devices, nil
+}
+
+// Details returns the details of the station or module with the ID from the last successful read.
+func (r *StationReader) Details(id string) (DeviceDetails, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	details, ok := r.details[id]
+	return details, ok
+}
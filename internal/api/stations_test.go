@@ -0,0 +1,114 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/oauth2"
+)
+
+func testClient(t *testing.T, responses map[string]string) *Client {
+	t.Helper()
+
+	return New(func() (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "token"}, nil
+	}, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request: %s", req.URL)
+			return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}), 0)
+}
+
+func TestReadStations(t *testing.T) {
+	client := testClient(t, map[string]string{
+		"/api/getstationsdata?device_id=70%3Aee%3A50%3A00%3A00%3A01": `{"body": {"devices": [{"_id": "70:ee:50:00:00:01", "station_name": "Home"}]}}`,
+		"/api/getstationsdata?device_id=70%3Aee%3A50%3A00%3A00%3A02": `{"body": {"devices": [{"_id": "70:ee:50:00:00:02", "station_name": "Office"}]}}`,
+	})
+
+	stations, _, err := client.ReadStations([]string{"70:ee:50:00:00:01", "70:ee:50:00:00:02"})
+	if err != nil {
+		t.Fatalf("error reading stations: %s", err)
+	}
+
+	var ids []string
+	for _, station := range stations.Devices() {
+		ids = append(ids, station.ID)
+	}
+
+	wantIDs := []string{"70:ee:50:00:00:01", "70:ee:50:00:00:02"}
+	if diff := cmp.Diff(ids, wantIDs); diff != "" {
+		t.Errorf("station IDs differ: -got+want\n%s", diff)
+	}
+}
+
+func TestStationReader(t *testing.T) {
+	client := testClient(t, map[string]string{
+		"/api/getstationsdata?app_type=app_station": `{"body": {"devices": [{
+			"_id": "70:ee:50:00:00:01",
+			"station_name": "Home",
+			"dashboard_data": {"time_utc": 1700000000, "Temperature": 21.5},
+			"place": {"altitude": 35, "country": "DE", "timezone": "Europe/Berlin", "location": [13.4, 52.5]},
+			"modules": [{"_id": "02:00:00:00:00:01", "type": "NAModule1", "battery_vp": 5120, "battery_percent": 64, "reachable": false}]
+		}]}}`,
+	})
+	reader := NewStationReader(client.ReadStationsFunction(nil))
+
+	if _, ok := reader.Details("02:00:00:00:00:01"); ok {
+		t.Error("got details before reading")
+	}
+
+	stations, err := reader.Read()
+	if err != nil {
+		t.Fatalf("error reading stations: %s", err)
+	}
+
+	if len(stations.Devices()) != 1 || len(stations.Devices()[0].LinkedModules) != 1 {
+		t.Fatalf("got %d stations, want one station with one module", len(stations.Devices()))
+	}
+
+	station := stations.Devices()[0]
+	if station.DashboardData.Temperature == nil || *station.DashboardData.Temperature != 21.5 {
+		t.Errorf("got temperature %v, want 21.5", station.DashboardData.Temperature)
+	}
+
+	moduleDetails, ok := reader.Details("02:00:00:00:00:01")
+	if !ok {
+		t.Fatal("got no details for module")
+	}
+
+	if moduleDetails.BatteryVoltage == nil || *moduleDetails.BatteryVoltage != 5120 {
+		t.Errorf("got battery voltage %v, want 5120", moduleDetails.BatteryVoltage)
+	}
+
+	stationDetails, ok := reader.Details("70:ee:50:00:00:01")
+	if !ok {
+		t.Fatal("got no details for station")
+	}
+
+	if stationDetails.BatteryVoltage != nil {
+		t.Errorf("got battery voltage %d for station, want none", *stationDetails.BatteryVoltage)
+	}
+
+	wantPlace := &Place{Country: "DE", Timezone: "Europe/Berlin"}
+	if diff := cmp.Diff(stationDetails.Place, wantPlace); diff != "" {
+		t.Errorf("place differs: -got+want\n%s", diff)
+	}
+
+	if moduleDetails.Reachable == nil || *moduleDetails.Reachable {
+		t.Errorf("got reachable %v, want false", moduleDetails.Reachable)
+	}
+
+	if moduleDetails.Place != nil {
+		t.Errorf("got place %v for module, want none", moduleDetails.Place)
+	}
+}
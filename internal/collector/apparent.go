@@ -0,0 +1,51 @@
+package collector
+
+import "math"
+
+const (
+	outdoorModuleType = "NAModule1"
+
+	// The heat index is only used at or above this temperature in celsius (80 °F).
+	heatIndexMinTemperature = 26.7
+	// The wind chill is only used at or below this temperature in celsius and above the wind speed in km/h.
+	windChillMaxTemperature = 10
+	windChillMinWindSpeed   = 4.8
+)
+
+// apparentTemperature calculates the temperature in celsius as perceived by humans. In warm conditions this is the
+// heat index calculated using the regression of Rothfusz, in cold and windy conditions this is the wind chill
+// as defined by Environment Canada. Otherwise, the measured temperature is returned. The wind speed is in km/h and
+// is optional.
+func apparentTemperature(temperature, humidity float64, windSpeed *float64) float64 {
+	switch {
+	case temperature >= heatIndexMinTemperature:
+		return heatIndex(temperature, humidity)
+	case temperature <= windChillMaxTemperature && windSpeed != nil && *windSpeed > windChillMinWindSpeed:
+		return windChill(temperature, *windSpeed)
+	default:
+		return temperature
+	}
+}
+
+// heatIndex uses the regression of Rothfusz, which is defined in fahrenheit.
+func heatIndex(temperature, humidity float64) float64 {
+	t := temperature*9/5 + 32
+	rh := humidity
+
+	hi := -42.379 +
+		2.04901523*t +
+		10.14333127*rh -
+		0.22475541*t*rh -
+		0.00683783*t*t -
+		0.05481717*rh*rh +
+		0.00122874*t*t*rh +
+		0.00085282*t*rh*rh -
+		0.00000199*t*t*rh*rh
+
+	return (hi - 32) * 5 / 9
+}
+
+func windChill(temperature, windSpeed float64) float64 {
+	v := math.Pow(windSpeed, 0.16)
+	return 13.12 + 0.6215*temperature - 11.37*v + 0.3965*temperature*v
+}
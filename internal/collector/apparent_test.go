@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApparentTemperature(t *testing.T) {
+	tt := []struct {
+		desc        string
+		temperature float64
+		humidity    float64
+		windSpeed   *float64
+		want        float64
+	}{
+		{
+			desc:        "mild",
+			temperature: 20,
+			humidity:    50,
+			windSpeed:   float64Ptr(30),
+			want:        20,
+		},
+		{
+			desc:        "heat index",
+			temperature: 32,
+			humidity:    70,
+			want:        40.4,
+		},
+		{
+			desc:        "wind chill",
+			temperature: -10,
+			humidity:    80,
+			windSpeed:   float64Ptr(30),
+			want:        -19.5,
+		},
+		{
+			desc:        "cold without wind",
+			temperature: -10,
+			humidity:    80,
+			windSpeed:   float64Ptr(2),
+			want:        -10,
+		},
+		{
+			desc:        "cold without wind gauge",
+			temperature: -10,
+			humidity:    80,
+			want:        -10,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := apparentTemperature(tc.temperature, tc.humidity, tc.windSpeed)
+			if math.Abs(got-tc.want) > 0.05 {
+				t.Errorf("got %.2f, want %.1f", got, tc.want)
+			}
+		})
+	}
+}
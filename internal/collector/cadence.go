@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+// defaultReportInterval is used until the interval of a module has been observed. Per the NetAtmo documentation the
+// data is updated every ten minutes.
+const defaultReportInterval = 10 * time.Minute
+
+// reportIntervalWindow is the number of recent intervals between measurements used for the reporting interval of a
+// module. Older intervals are dropped, so the estimate follows a module which starts reporting less often.
+const reportIntervalWindow = 6
+
+// reportCadence contains the time of the last measurement of a module and the recently observed intervals between
+// two measurements.
+type reportCadence struct {
+	lastMeasure int64
+	intervals   []time.Duration
+}
+
+// interval returns the shortest of the recent intervals or zero, if no interval has been observed yet.
+func (r *reportCadence) interval() time.Duration {
+	var shortest time.Duration
+	for _, interval := range r.intervals {
+		if shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+	}
+
+	return shortest
+}
+
+// observeReports records the time of the last measurement of all devices and modules. When the time changed since the
+// previous refresh, the difference is recorded as an interval of the module. The shortest of the recent intervals is
+// used as the reporting interval, because refreshes can miss measurements.
+func (c *NetatmoCollector) observeReports(devices *netatmo.DeviceCollection, homeCoaches []*api.HomeCoach) {
+	var all []*netatmo.Device
+	if devices != nil {
+		for _, station := range devices.Devices() {
+			if station == nil {
+				continue
+			}
+
+			all = append(all, station)
+			all = append(all, station.LinkedModules...)
+		}
+	}
+	for _, homeCoach := range homeCoaches {
+		all = append(all, &homeCoach.Device)
+	}
+
+	for _, device := range all {
+		if device == nil || device.DashboardData.LastMeasure == nil {
+			continue
+		}
+		lastMeasure := *device.DashboardData.LastMeasure
+
+		cadence, ok := c.reportCadences[device.ID]
+		if !ok {
+			c.reportCadences[device.ID] = &reportCadence{lastMeasure: lastMeasure}
+			continue
+		}
+
+		if lastMeasure <= cadence.lastMeasure {
+			continue
+		}
+
+		interval := time.Duration(lastMeasure-cadence.lastMeasure) * time.Second
+		if len(cadence.intervals) == reportIntervalWindow {
+			cadence.intervals = cadence.intervals[1:]
+		}
+		cadence.intervals = append(cadence.intervals, interval)
+		cadence.lastMeasure = lastMeasure
+	}
+}
+
+// expectedNextReport returns the time when the next measurement of the device is expected.
+func (c *NetatmoCollector) expectedNextReport(device *netatmo.Device, lastMeasure time.Time) time.Time {
+	interval := defaultReportInterval
+	if cadence, ok := c.reportCadences[device.ID]; ok && cadence.interval() > 0 {
+		interval = cadence.interval()
+	}
+
+	return lastMeasure.Add(interval)
+}
+
+// adaptiveRefreshMargin is added to the expected time of the next measurement, because the NetAtmo API only provides
+// the data after the station has uploaded it.
+const adaptiveRefreshMargin = time.Minute
+
+// adaptRefreshInterval sets the time until the next refresh to shortly after the earliest expected measurement of
+// all modules with a known reporting interval, limited to AdaptiveRefreshMin and AdaptiveRefreshMax. The configured
+// refresh interval is used, if no reporting interval is known or all modules are overdue.
+func (c *NetatmoCollector) adaptRefreshInterval(now time.Time) {
+	if !c.AdaptiveRefresh {
+		return
+	}
+
+	var next time.Time
+	for _, cadence := range c.reportCadences {
+		interval := cadence.interval()
+		if interval == 0 {
+			continue
+		}
+
+		expected := time.Unix(cadence.lastMeasure, 0).Add(interval + adaptiveRefreshMargin)
+		if !expected.After(now) {
+			continue
+		}
+
+		if next.IsZero() || expected.Before(next) {
+			next = expected
+		}
+	}
+
+	if next.IsZero() {
+		c.adaptiveInterval.Store(0)
+		return
+	}
+
+	interval := min(max(next.Sub(now), c.AdaptiveRefreshMin), c.AdaptiveRefreshMax)
+	c.adaptiveInterval.Store(int64(interval))
+}
+
+// refreshInterval returns the time between two refreshes. In adaptive mode this depends on the reporting interval of
+// the modules.
+func (c *NetatmoCollector) refreshInterval() time.Duration {
+	if interval := c.adaptiveInterval.Load(); c.AdaptiveRefresh && interval > 0 {
+		return time.Duration(interval)
+	}
+
+	return c.RefreshInterval
+}
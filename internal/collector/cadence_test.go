@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExpectedNextReport(t *testing.T) {
+	lastMeasure := int64(1000)
+	read := func() (*netatmo.DeviceCollection, error) {
+		dc := &netatmo.DeviceCollection{}
+		dc.Body.Devices = []*netatmo.Device{
+			{
+				ID: "station",
+				DashboardData: netatmo.DashboardData{
+					LastMeasure: int64Ptr(lastMeasure),
+				},
+			},
+		}
+		return dc, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	device := &netatmo.Device{ID: "station"}
+
+	tt := []struct {
+		lastMeasure int64
+		want        int64
+	}{
+		{lastMeasure: 1000, want: 1600},
+		{lastMeasure: 1000, want: 1600},
+		{lastMeasure: 1600, want: 2200},
+		{lastMeasure: 1900, want: 2200},
+		{lastMeasure: 2800, want: 3100},
+		{lastMeasure: 3700, want: 4000},
+		{lastMeasure: 4600, want: 4900},
+		{lastMeasure: 5500, want: 5800},
+		{lastMeasure: 6400, want: 6700},
+		{lastMeasure: 7300, want: 8200},
+	}
+
+	for _, tc := range tt {
+		lastMeasure = tc.lastMeasure
+		c.RefreshData(time.Unix(tc.lastMeasure, 0))
+
+		got := c.expectedNextReport(device, time.Unix(tc.lastMeasure, 0))
+		if got.Unix() != tc.want {
+			t.Errorf("after measurement at %d got %d, want %d", tc.lastMeasure, got.Unix(), tc.want)
+		}
+	}
+}
+
+func TestAdaptiveRefreshInterval(t *testing.T) {
+	lastMeasure := int64(1000)
+	read := func() (*netatmo.DeviceCollection, error) {
+		dc := &netatmo.DeviceCollection{}
+		dc.Body.Devices = []*netatmo.Device{
+			{
+				ID: "station",
+				DashboardData: netatmo.DashboardData{
+					LastMeasure: int64Ptr(lastMeasure),
+				},
+			},
+		}
+		return dc, nil
+	}
+
+	c := New(logrus.New(), read, 8*time.Minute, time.Hour)
+	c.AdaptiveRefresh = true
+	c.AdaptiveRefreshMin = 2 * time.Minute
+	c.AdaptiveRefreshMax = 10 * time.Minute
+
+	tt := []struct {
+		desc        string
+		now         int64
+		lastMeasure int64
+		want        time.Duration
+	}{
+		{desc: "unknown cadence", now: 1000, lastMeasure: 1000, want: 8 * time.Minute},
+		{desc: "next measurement", now: 1700, lastMeasure: 1600, want: 560 * time.Second},
+		{desc: "overdue", now: 2300, lastMeasure: 1600, want: 8 * time.Minute},
+		{desc: "maximum", now: 2250, lastMeasure: 2200, want: 10 * time.Minute},
+		{desc: "minimum", now: 2850, lastMeasure: 2200, want: 2 * time.Minute},
+	}
+
+	for _, tc := range tt {
+		lastMeasure = tc.lastMeasure
+		c.RefreshData(time.Unix(tc.now, 0))
+
+		got := c.refreshInterval()
+		if got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.desc, got, tc.want)
+		}
+
+		wantNext := time.Unix(tc.now, 0).Add(tc.want)
+		if next := c.nextRefresh(time.Unix(tc.now, 0)); !next.Equal(wantNext) {
+			t.Errorf("%s: got next refresh %s, want %s", tc.desc, next, wantNext)
+		}
+	}
+}
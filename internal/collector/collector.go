@@ -1,12 +1,41 @@
 package collector
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	netatmo "github.com/exzz/netatmo-api-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+const (
+	// The first refresh is retried a few times, so that temporary problems during startup are recovered quickly.
+	initialRefreshRetries    = 3
+	initialRefreshRetryDelay = 5 * time.Second
+
+	// A warning is logged for measurements which are further in the future.
+	maxClockSkew = time.Minute
+
+	// Thresholds for the raw signal strength values reported by the API. Lower values mean a better signal.
+	wifiStrengthBad   = 86
+	wifiStrengthGood  = 56
+	rfStrengthLowest  = 90
+	rfStrengthHighest = 60
 )
 
 var (
@@ -14,6 +43,39 @@ var (
 	netatmoUpDesc = prometheus.NewDesc(prefix+"up",
 		"Zero if there was an error during the last refresh try.",
 		nil, nil)
+	warmingUpDesc = prometheus.NewDesc(prefix+"warming_up",
+		"One during the startup grace period, while netatmo_up is not reported because no data has been read yet. Zero otherwise.",
+		nil, nil)
+	stationUpDesc = prometheus.NewDesc(prefix+"station_up",
+		"One if the station and all its modules provided fresh data during the last refresh, zero otherwise.",
+		[]string{"station", "home"}, nil)
+	stationModuleCountDesc = prometheus.NewDesc(prefix+"station_module_count",
+		"Number of modules linked to the station in the data of the last successful refresh.",
+		[]string{"station", "home"}, nil)
+	stationInfoDesc = prometheus.NewDesc(prefix+"station_info",
+		"Contains the location of the station. The city is empty if NetAtmo does not know it. Value is always 1.",
+		[]string{"station", "home", "country", "city", "timezone"}, nil)
+	stationTimezoneOffsetDesc = prometheus.NewDesc(prefix+"station_timezone_offset_seconds",
+		"Offset of the current local time at the station from UTC in seconds, including daylight saving time.",
+		[]string{"station", "home"}, nil)
+	stationMeanTemperatureDesc = newComputedDesc(prefix+"station_mean_temperature_celsius",
+		"Average temperature of all modules of the station with fresh data in celsius.",
+		[]string{"station", "home"})
+	stationTemperatureDeltaDesc = newComputedDesc(prefix+"station_indoor_outdoor_temperature_delta_celsius",
+		"Difference between the temperature of the main module and the outdoor module of the station in celsius. Only available if both modules have fresh data.",
+		[]string{"station", "home"})
+	stationCompletenessDesc = newComputedDesc(prefix+"station_data_completeness_ratio",
+		"Fraction of the modules of the station which provided fresh data during the last refresh.",
+		[]string{"station", "home"})
+	completenessDesc = newComputedDesc(prefix+"data_completeness_ratio",
+		"Fraction of all modules which provided fresh data during the last refresh. One if there are no modules.",
+		nil)
+	consecutiveFailuresDesc = prometheus.NewDesc(prefix+"consecutive_refresh_failures",
+		"Number of refreshes which failed in a row. Reset to zero by a successful refresh.",
+		nil, nil)
+	lastErrorDesc = prometheus.NewDesc(prefix+"last_error_info",
+		"One if there was an error during the last refresh try, zero otherwise. The reason label contains the category of the error.",
+		[]string{"reason"}, nil)
 
 	refreshIntervalDesc = prometheus.NewDesc(
 		prefix+"refresh_interval_seconds",
@@ -28,11 +90,68 @@ var (
 		refreshPrefix+"duration_seconds",
 		"Contains the time it took for the last refresh to complete, even if it was unsuccessful.",
 		nil, nil)
+	nextRefreshDesc = prometheus.NewDesc(
+		prefix+"next_refresh_time",
+		"Contains the time after which the next scrape will refresh the cached data. Contains the current time while a refresh is running.",
+		nil, nil)
+
+	scrapesDesc = prometheus.NewDesc(
+		prefix+"scrapes_total",
+		"Total number of scrapes of the exporter.",
+		nil, nil)
+	lastScrapeDurationDesc = prometheus.NewDesc(
+		prefix+"last_scrape_duration_seconds",
+		"Contains the time it took to complete the previous scrape.",
+		nil, nil)
+	cacheServedDesc = prometheus.NewDesc(
+		prefix+"cache_served_total",
+		"Total number of scrapes which were served from the cache without triggering a refresh.",
+		nil, nil)
+	refreshTriggeredDesc = prometheus.NewDesc(
+		prefix+"refresh_triggered_total",
+		"Total number of scrapes which triggered a refresh of the cached data.",
+		nil, nil)
 
 	cacheTimestampDesc = prometheus.NewDesc(
 		prefix+"cache_updated_time",
-		"Contains the time of the cached data.",
+		"Contains the time the cached station data was read.",
+		nil, nil)
+	cacheAgeDesc = prometheus.NewDesc(
+		prefix+"cache_age_seconds",
+		"Contains the age of the cached data in seconds. Only present once data has been cached.",
 		nil, nil)
+	servingStaleCacheDesc = newComputedDesc(
+		prefix+"serving_stale_cache",
+		"One if the cached data is older than the time between refreshes including the jitter, for example because a refresh is running or failed. Only present once data has been cached.",
+		nil)
+
+	staleModulesDesc = newComputedDesc(
+		prefix+"stale_modules_total",
+		"Number of modules with data older than the stale threshold. Modules without any data are not counted.",
+		nil)
+
+	devicesDesc = prometheus.NewDesc(
+		prefix+"devices_total",
+		"Number of stations returned by the last successful refresh. Zero means the account does not contain any stations.",
+		nil, nil)
+
+	filteredReadingsOpts = prometheus.CounterOpts{
+		Name: prefix + "filtered_readings_total",
+		Help: "Total number of readings which were not exported, because they were outside of the configured limits.",
+	}
+
+	metricErrorsOpts = prometheus.CounterOpts{
+		Name: prefix + "metric_errors_total",
+		Help: "Total number of metrics which were not exported, because they could not be created.",
+	}
+
+	collectPanicsOpts = prometheus.CounterOpts{
+		Name: prefix + "collect_panics_total",
+		Help: "Total number of devices whose metrics were not completely exported, because collecting them caused a panic.",
+	}
+
+	// The name of a metric is not accessible on prometheus.Desc, so it is taken from its string representation.
+	fqNameRegexp = regexp.MustCompile(`fqName: "([^"]*)"`)
 
 	varLabels = []string{
 		"module",
@@ -40,11 +159,39 @@ var (
 		"home",
 	}
 
+	moduleInfoDesc = prometheus.NewDesc(
+		prefix+"module_info",
+		"Contains information about all known modules, even if they do not have fresh data. Value is always 1.",
+		append(varLabels, "type"),
+		nil)
+
+	moduleIsMainDesc = prometheus.NewDesc(
+		prefix+"module_is_main",
+		"One if the module is the main device of the station, zero for modules linked to the station.",
+		varLabels,
+		nil)
+
+	moduleLastSeenDesc = prometheus.NewDesc(
+		prefix+"module_last_seen_time",
+		"Contains the time of the most recent measurement of a module, even if the data is stale.",
+		varLabels,
+		nil)
+
 	sensorPrefix = prefix + "sensor_"
 
+	clockSkewDesc = newComputedDesc(
+		sensorPrefix+"clock_skew_seconds",
+		"Difference between the time of the most recent measurement and the time of the exporter in seconds. Positive values mean the measurement is in the future.",
+		varLabels)
+
+	expectedNextReportDesc = newComputedDesc(
+		sensorPrefix+"expected_next_report_time",
+		"Time when the next measurement of the module is expected, based on the shortest observed interval between measurements.",
+		varLabels)
+
 	updatedDesc = prometheus.NewDesc(
 		sensorPrefix+"updated",
-		"Timestamp of last update",
+		"Timestamp of last update. Deprecated, use netatmo_module_last_seen_time instead, which is also available for stale data.",
 		varLabels,
 		nil)
 
@@ -72,9 +219,30 @@ var (
 		varLabels,
 		nil)
 
+	cotwoMaxDesc = newComputedDesc(
+		sensorPrefix+"co2_max_ppm",
+		"Highest carbondioxide measurement within the peak window in parts per million. Reset on restart.",
+		varLabels)
+
+	noiseMaxDesc = newComputedDesc(
+		sensorPrefix+"noise_max_db",
+		"Highest noise measurement within the peak window in decibels. Reset on restart.",
+		varLabels)
+
+	gustMaxDesc = newComputedDesc(
+		sensorPrefix+"gust_max_kph",
+		"Highest wind gust strength measured since the exporter was started in kilometers per hour. Reset on restart.",
+		varLabels)
+
 	pressureDesc = prometheus.NewDesc(
 		sensorPrefix+"pressure_mb",
-		"Atmospheric pressure measurement in millibar",
+		"Atmospheric pressure measurement in millibar, reduced to sea level by NetAtmo using the altitude of the station",
+		varLabels,
+		nil)
+
+	absolutePressureDesc = prometheus.NewDesc(
+		sensorPrefix+"absolute_pressure_mb",
+		"Atmospheric pressure measurement in millibar at the altitude of the station",
 		varLabels,
 		nil)
 
@@ -90,17 +258,46 @@ var (
 		varLabels,
 		nil)
 
+	cotwoAlertDesc = newComputedDesc(
+		sensorPrefix+"co2_alert",
+		"One if the carbondioxide measurement is above the alert threshold, zero otherwise.",
+		varLabels)
+
+	humidityComfortDesc = newComputedDesc(
+		sensorPrefix+"humidity_comfort",
+		"Humidity compared to the comfortable range. -1 means too dry, 0 comfortable and 1 too humid. Not available for outdoor modules.",
+		varLabels)
+
+	apparentTemperatureDesc = newComputedDesc(
+		sensorPrefix+"apparent_temperature_celsius",
+		"Apparent temperature in celsius. Heat index (Rothfusz) at or above 26.7°C, wind chill (Environment Canada) at or below 10°C with wind above 4.8 km/h, otherwise the measured temperature.",
+		varLabels)
+
 	rainDesc = prometheus.NewDesc(
 		sensorPrefix+"rain_amount_mm",
 		"Rain amount in millimeters",
 		varLabels,
 		nil)
 
+	rainRateDesc = newComputedDesc(
+		sensorPrefix+"rain_rate_mm_per_hour",
+		"Rain rate in millimeters per hour, calculated from the change of the rain amount of the last 24 hours between two measurements.",
+		varLabels)
+
 	batteryDesc = prometheus.NewDesc(
 		sensorPrefix+"battery_percent",
 		"Battery remaining life (10: low)",
 		varLabels,
 		nil)
+	batteryVoltageDesc = prometheus.NewDesc(
+		sensorPrefix+"battery_millivolts",
+		"Battery voltage of the module in millivolts",
+		varLabels,
+		nil)
+	batteryStatusDesc = newComputedDesc(
+		sensorPrefix+"battery_status",
+		"Battery level derived from the battery voltage using the thresholds of the module type (0: very low, 1: low, 2: medium, 3: high, 4: full).",
+		varLabels)
 	wifiDesc = prometheus.NewDesc(
 		sensorPrefix+"wifi_signal_strength",
 		"Wifi signal strength (86: bad, 71: avg, 56: good)",
@@ -111,153 +308,1327 @@ var (
 		"RF signal strength (90: lowest, 60: highest)",
 		varLabels,
 		nil)
+
+	wifiQualityDesc = newComputedDesc(
+		sensorPrefix+"wifi_quality_percent",
+		"Wifi signal quality in percent (0: bad, 100: good), derived from the wifi signal strength.",
+		varLabels)
+	rfQualityDesc = newComputedDesc(
+		sensorPrefix+"rf_quality_percent",
+		"RF signal quality in percent (0: lowest, 100: highest), derived from the RF signal strength.",
+		varLabels)
+
+	wifiCategoryDesc = newComputedDesc(
+		sensorPrefix+"wifi_quality",
+		"Wifi signal quality using the configured thresholds (0: bad, 1: average, 2: good).",
+		varLabels)
+	rfCategoryDesc = newComputedDesc(
+		sensorPrefix+"rf_quality",
+		"RF signal quality using the configured thresholds (0: bad, 1: average, 2: good).",
+		varLabels)
+
+	healthIndexDesc = prometheus.NewDesc(
+		sensorPrefix+"health_index",
+		"Health index computed by the Healthy Home Coach (0: healthy, 1: fine, 2: fair, 3: poor, 4: unhealthy)",
+		varLabels,
+		nil)
+
+	roomLabels = []string{
+		"room",
+		"home",
+	}
+
+	roomTemperatureDesc = prometheus.NewDesc(
+		prefix+"room_temperature_celsius",
+		"Temperature measured in a room managed by NetAtmo Energy devices in celsius",
+		roomLabels,
+		nil)
+
+	roomSetpointDesc = prometheus.NewDesc(
+		prefix+"room_setpoint_celsius",
+		"Target temperature of a room managed by NetAtmo Energy devices in celsius",
+		roomLabels,
+		nil)
+
+	valveOpenDesc = prometheus.NewDesc(
+		prefix+"valve_open_percent",
+		"Heating power requested by a room in percent, which corresponds to the opening of its valves",
+		roomLabels,
+		nil)
+
+	heatingPowerRequestDesc = prometheus.NewDesc(
+		prefix+"room_heating_power_request",
+		"Heating power requested by a room in percent",
+		roomLabels,
+		nil)
+
+	boilerOnDesc = prometheus.NewDesc(
+		prefix+"thermostat_boiler_on",
+		"One if the thermostat in a room has turned on the boiler, zero otherwise",
+		roomLabels,
+		nil)
+
+	scheduleInfoDesc = prometheus.NewDesc(
+		prefix+"home_schedule_info",
+		"Contains the currently selected heating schedule of a home. Value is always 1.",
+		[]string{"home", "schedule_id", "schedule"},
+		nil)
 )
 
+// Units contains the unit of every metric of the collector which has one, by metric name.
+var Units = map[string]string{
+	prefix + "cache_age_seconds":                                "seconds",
+	prefix + "cache_updated_time":                               "unix timestamp",
+	prefix + "data_completeness_ratio":                          "ratio",
+	refreshPrefix + "duration_seconds":                          "seconds",
+	refreshPrefix + "time":                                      "unix timestamp",
+	prefix + "last_scrape_duration_seconds":                     "seconds",
+	prefix + "module_last_seen_time":                            "unix timestamp",
+	prefix + "next_refresh_time":                                "unix timestamp",
+	prefix + "refresh_interval_seconds":                         "seconds",
+	prefix + "room_heating_power_request":                       "percent",
+	prefix + "room_setpoint_celsius":                            "celsius",
+	prefix + "room_temperature_celsius":                         "celsius",
+	prefix + "station_data_completeness_ratio":                  "ratio",
+	prefix + "station_indoor_outdoor_temperature_delta_celsius": "celsius",
+	prefix + "station_mean_temperature_celsius":                 "celsius",
+	prefix + "station_timezone_offset_seconds":                  "seconds",
+	prefix + "valve_open_percent":                               "percent",
+	sensorPrefix + "absolute_pressure_mb":                       "millibar",
+	sensorPrefix + "apparent_temperature_celsius":               "celsius",
+	sensorPrefix + "battery_millivolts":                         "millivolts",
+	sensorPrefix + "battery_percent":                            "percent",
+	sensorPrefix + "clock_skew_seconds":                         "seconds",
+	sensorPrefix + "co2_max_ppm":                                "ppm",
+	sensorPrefix + "co2_ppm":                                    "ppm",
+	sensorPrefix + "expected_next_report_time":                  "unix timestamp",
+	sensorPrefix + "gust_max_kph":                               "kilometers per hour",
+	sensorPrefix + "humidity_percent":                           "percent",
+	sensorPrefix + "noise_db":                                   "decibel",
+	sensorPrefix + "noise_max_db":                               "decibel",
+	sensorPrefix + "pressure_mb":                                "millibar",
+	sensorPrefix + "rain_amount_mm":                             "millimeter",
+	sensorPrefix + "rain_rate_mm_per_hour":                      "millimeters per hour",
+	sensorPrefix + "rf_quality_percent":                         "percent",
+	sensorPrefix + "temperature_celsius":                        "celsius",
+	sensorPrefix + "updated":                                    "unix timestamp",
+	sensorPrefix + "wifi_quality_percent":                       "percent",
+	sensorPrefix + "wind_direction_degrees":                     "degrees",
+	sensorPrefix + "wind_strength_kph":                          "kilometers per hour",
+}
+
 // ReadFunction defines the interface for reading from the Netatmo API.
 type ReadFunction func() (*netatmo.DeviceCollection, error)
 
+// HomeCoachReadFunction defines the interface for reading Healthy Home Coach devices from the Netatmo API.
+type HomeCoachReadFunction func() ([]*api.HomeCoach, error)
+
+// HomesReadFunction defines the interface for reading the homes with NetAtmo Energy devices.
+type HomesReadFunction func() ([]*api.Home, error)
+
+// DetailsFunction defines the interface for looking up the values of a station or module, which are not part of the
+// data returned by the ReadFunction.
+type DetailsFunction func(id string) (api.DeviceDetails, bool)
+
+// batteryThresholds contains the battery voltages in millivolts from which the battery of a module type is considered
+// full, high, medium and low, as documented by NetAtmo. Lower voltages are very low.
+var batteryThresholds = map[string][4]int32{
+	outdoorModuleType: {5500, 5000, 4500, 4000},
+	"NAModule2":       {5590, 5180, 4770, 4360},
+	"NAModule3":       {5500, 5000, 4500, 4000},
+	"NAModule4":       {5640, 5280, 4920, 4560},
+}
+
+// batteryStatus returns the battery level of a module from 0 (very low) to 4 (full). It returns false for module types
+// without known thresholds.
+func batteryStatus(moduleType string, voltage int32) (float64, bool) {
+	thresholds, ok := batteryThresholds[moduleType]
+	if !ok {
+		return 0, false
+	}
+
+	for i, threshold := range thresholds {
+		if voltage >= threshold {
+			return float64(len(thresholds) - i), true
+		}
+	}
+
+	return 0, true
+}
+
+// Limits contains the range of plausible values of a measurement. Readings outside of the range are not exported.
+type Limits struct {
+	Min float64
+	Max float64
+}
+
+// NoLimits does not filter any readings.
+var NoLimits = Limits{Min: math.Inf(-1), Max: math.Inf(1)}
+
+func (l Limits) contains(value float64) bool {
+	return value >= l.Min && value <= l.Max
+}
+
+// Precision is the number of decimals a measurement is rounded to before it is exported.
+type Precision int
+
+// NoRounding exports the measurements as returned by the NetAtmo API.
+const NoRounding Precision = -1
+
+func (p Precision) round(value float64) float64 {
+	if p < 0 {
+		return value
+	}
+
+	factor := math.Pow(10, float64(p))
+	return math.Round(value*factor) / factor
+}
+
+// DefaultCO2AlertThreshold is the CO2 concentration in ppm above which the air should be refreshed.
+const DefaultCO2AlertThreshold = 1000
+
+// DefaultHumidityComfort is the range of humidity in percent, which is generally considered comfortable indoors.
+var DefaultHumidityComfort = Limits{Min: 40, Max: 60}
+
+// comfort returns -1 if the value is below the range, 1 if it is above and 0 otherwise.
+func (l Limits) comfort(value float64) float64 {
+	switch {
+	case value < l.Min:
+		return -1
+	case value > l.Max:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SignalThresholds contains the raw signal strength values at which a signal is considered bad or good. Lower values
+// mean a better signal.
+type SignalThresholds struct {
+	Bad  int32
+	Good int32
+}
+
+var (
+	// DefaultWifiThresholds uses the values from the NetAtmo documentation for the wifi signal.
+	DefaultWifiThresholds = SignalThresholds{Bad: wifiStrengthBad, Good: wifiStrengthGood}
+	// DefaultRFThresholds uses the values from the NetAtmo documentation for the RF signal.
+	DefaultRFThresholds = SignalThresholds{Bad: rfStrengthLowest, Good: rfStrengthHighest}
+)
+
+// quality converts a raw signal strength into a percentage.
+func (t SignalThresholds) quality(value int32) float64 {
+	return signalQuality(value, t.Bad, t.Good)
+}
+
+// category returns 0 for a bad, 1 for an average and 2 for a good signal.
+func (t SignalThresholds) category(value int32) float64 {
+	switch {
+	case value >= t.Bad:
+		return 0
+	case value <= t.Good:
+		return 2
+	default:
+		return 1
+	}
+}
+
 // NetatmoCollector is a Prometheus collector for Netatmo sensor values.
 type NetatmoCollector struct {
-	Log             logrus.FieldLogger
-	RefreshInterval time.Duration
-	StaleThreshold  time.Duration
-	ReadFunction    ReadFunction
-	clock           func() time.Time
+	Log                   logrus.FieldLogger
+	RefreshInterval       time.Duration
+	RefreshJitter         time.Duration
+	AdaptiveRefresh       bool
+	AdaptiveRefreshMin    time.Duration
+	AdaptiveRefreshMax    time.Duration
+	StaleThreshold        time.Duration
+	ReadFunction          ReadFunction
+	ReadHomeCoachFunction HomeCoachReadFunction
+	ReadHomesFunction     HomesReadFunction
+	DetailsFunction       DetailsFunction
+	IncludeStations       []string
+	ExcludeStations       []string
+	TemperatureLimits     Limits
+	HumidityLimits        Limits
+	TemperaturePrecision  Precision
+	PressurePrecision     Precision
+	CO2AlertThreshold     int32
+	HumidityComfort       Limits
+	WifiThresholds        SignalThresholds
+	RFThresholds          SignalThresholds
+	FailOnError           bool
+	SkipUnreachable       bool
+	PeakWindow            time.Duration
+	ReadRetries           int
+	ReadRetryDelay        time.Duration
+	StationLabelTemplate  *template.Template
+	StartupGrace          time.Duration
+	startTime             time.Time
+	clock                 func() time.Time
+	sleep                 func(ctx context.Context, d time.Duration) error
+	randomDuration        func(max time.Duration) time.Duration
+	initialRetryDelay     time.Duration
 
+	refreshLock         sync.Mutex
+	refreshing          atomic.Bool
 	lastRefresh         time.Time
+	nextJitter          time.Duration
+	adaptiveInterval    atomic.Int64
 	lastRefreshError    error
+	consecutiveFailures int
 	lastRefreshDuration time.Duration
 	cacheLock           sync.RWMutex
-	cacheTimestamp      time.Time
+	stationsTimestamp   time.Time
+	homeCoachTimestamp  time.Time
 	cachedData          *netatmo.DeviceCollection
+	deviceCount         int
+	moduleCounts        map[string]int
+	cachedHomeCoaches   []*api.HomeCoach
+	cachedHomes         []*api.Home
+	reportCadences      map[string]*reportCadence
+	peaks               map[peakKey][]peakSample
+	rainRates           map[string]*rainRate
+	gustMax             map[string]float64
+	timezones           sync.Map
+	homesTimestamp      time.Time
+	scrapes             atomic.Uint64
+	lastScrapeDuration  atomic.Int64
+	cacheServed         atomic.Uint64
+	refreshTriggered    atomic.Uint64
+	filteredReadings    *prometheus.CounterVec
+	metricErrors        *prometheus.CounterVec
+	collectPanics       prometheus.Counter
 }
 
 func New(log *logrus.Logger, readFunction ReadFunction, refreshInterval, staleDuration time.Duration) *NetatmoCollector {
 	return &NetatmoCollector{
-		Log:             log,
-		RefreshInterval: refreshInterval,
-		StaleThreshold:  staleDuration,
-		ReadFunction:    readFunction,
-		clock:           time.Now,
+		Log:                  log,
+		RefreshInterval:      refreshInterval,
+		StaleThreshold:       staleDuration,
+		ReadFunction:         readFunction,
+		clock:                time.Now,
+		sleep:                sleepContext,
+		startTime:            time.Now(),
+		randomDuration:       randomDuration,
+		initialRetryDelay:    initialRefreshRetryDelay,
+		TemperatureLimits:    NoLimits,
+		HumidityLimits:       NoLimits,
+		TemperaturePrecision: NoRounding,
+		PressurePrecision:    NoRounding,
+		CO2AlertThreshold:    DefaultCO2AlertThreshold,
+		HumidityComfort:      DefaultHumidityComfort,
+		WifiThresholds:       DefaultWifiThresholds,
+		RFThresholds:         DefaultRFThresholds,
+		filteredReadings:     prometheus.NewCounterVec(filteredReadingsOpts, []string{"metric"}),
+		metricErrors:         prometheus.NewCounterVec(metricErrorsOpts, []string{"metric"}),
+		collectPanics:        prometheus.NewCounter(collectPanicsOpts),
+		reportCadences:       make(map[string]*reportCadence),
+		peaks:                make(map[peakKey][]peakSample),
+		rainRates:            make(map[string]*rainRate),
+		gustMax:              make(map[string]float64),
 	}
 }
 
+// sleepContext waits until d has passed or ctx is done, whichever happens first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func randomDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return rand.N(max)
+}
+
 // Describe implements prometheus.Collector
 func (c *NetatmoCollector) Describe(dChan chan<- *prometheus.Desc) {
 	dChan <- netatmoUpDesc
+	dChan <- warmingUpDesc
+	dChan <- stationUpDesc
+	dChan <- stationModuleCountDesc
+	dChan <- stationInfoDesc
+	dChan <- stationTimezoneOffsetDesc
+	dChan <- stationMeanTemperatureDesc
+	dChan <- stationTemperatureDeltaDesc
+	dChan <- stationCompletenessDesc
+	dChan <- completenessDesc
+	dChan <- lastErrorDesc
+	dChan <- consecutiveFailuresDesc
 	dChan <- refreshIntervalDesc
 	dChan <- refreshTimestampDesc
 	dChan <- refreshDurationDesc
+	dChan <- nextRefreshDesc
+	dChan <- scrapesDesc
+	dChan <- lastScrapeDurationDesc
+	dChan <- cacheServedDesc
+	dChan <- refreshTriggeredDesc
 	dChan <- cacheTimestampDesc
+	dChan <- cacheAgeDesc
+	dChan <- servingStaleCacheDesc
+	dChan <- devicesDesc
+	dChan <- staleModulesDesc
+	c.filteredReadings.Describe(dChan)
+	c.metricErrors.Describe(dChan)
+	c.collectPanics.Describe(dChan)
+	dChan <- moduleInfoDesc
+	dChan <- moduleIsMainDesc
+	dChan <- moduleLastSeenDesc
+	dChan <- clockSkewDesc
 	dChan <- updatedDesc
+	dChan <- expectedNextReportDesc
 	dChan <- tempDesc
 	dChan <- humidityDesc
+	dChan <- humidityComfortDesc
 	dChan <- cotwoDesc
+	dChan <- cotwoAlertDesc
 	dChan <- noiseDesc
+	dChan <- cotwoMaxDesc
+	dChan <- noiseMaxDesc
 	dChan <- pressureDesc
+	dChan <- absolutePressureDesc
 	dChan <- windStrengthDesc
 	dChan <- windDirectionDesc
+	dChan <- gustMaxDesc
+	dChan <- apparentTemperatureDesc
 	dChan <- rainDesc
+	dChan <- rainRateDesc
 	dChan <- batteryDesc
+	dChan <- batteryVoltageDesc
+	dChan <- batteryStatusDesc
 	dChan <- wifiDesc
 	dChan <- rfDesc
+	dChan <- wifiQualityDesc
+	dChan <- rfQualityDesc
+	dChan <- wifiCategoryDesc
+	dChan <- rfCategoryDesc
+	dChan <- healthIndexDesc
+	dChan <- roomTemperatureDesc
+	dChan <- roomSetpointDesc
+	dChan <- valveOpenDesc
+	dChan <- heatingPowerRequestDesc
+	dChan <- boilerOnDesc
+	dChan <- scheduleInfoDesc
 }
 
 // Collect implements prometheus.Collector
 func (c *NetatmoCollector) Collect(mChan chan<- prometheus.Metric) {
 	now := c.clock()
-	if now.Sub(c.lastRefresh) >= c.RefreshInterval {
-		go c.RefreshData(now)
+	defer func() {
+		c.lastScrapeDuration.Store(int64(c.clock().Sub(now)))
+	}()
+
+	scrapes := c.scrapes.Add(1)
+	c.sendMetric(mChan, scrapesDesc, prometheus.CounterValue, float64(scrapes))
+	c.sendMetric(mChan, lastScrapeDurationDesc, prometheus.GaugeValue, time.Duration(c.lastScrapeDuration.Load()).Seconds())
+
+	lastRefresh, triggered := c.triggerRefresh(now)
+	if triggered {
+		c.refreshTriggered.Add(1)
+	} else {
+		c.cacheServed.Add(1)
 	}
+	c.sendMetric(mChan, cacheServedDesc, prometheus.CounterValue, float64(c.cacheServed.Load()))
+	c.sendMetric(mChan, refreshTriggeredDesc, prometheus.CounterValue, float64(c.refreshTriggered.Load()))
+	c.filteredReadings.Collect(mChan)
+	// Collected last, so that the errors and panics of this scrape are included.
+	defer c.metricErrors.Collect(mChan)
+	defer c.collectPanics.Collect(mChan)
 
+	refreshDuration, refreshErr := c.refreshStatus()
 	upValue := 1.0
-	if c.lastRefresh.IsZero() || c.lastRefreshError != nil {
+	if lastRefresh.IsZero() || refreshErr != nil {
 		upValue = 0
 	}
-	c.sendMetric(mChan, netatmoUpDesc, prometheus.GaugeValue, upValue)
+	warmingUp := c.warmingUp(now)
+	warmingUpValue := 0.0
+	if warmingUp {
+		warmingUpValue = 1
+	}
+	c.sendMetric(mChan, warmingUpDesc, prometheus.GaugeValue, warmingUpValue)
+	switch {
+	case warmingUp:
+		c.Log.Debug("Not reporting netatmo_up during startup grace period.")
+	case c.FailOnError && refreshErr != nil:
+		mChan <- prometheus.NewInvalidMetric(netatmoUpDesc, fmt.Errorf("last refresh failed: %w", refreshErr))
+	default:
+		c.sendMetric(mChan, netatmoUpDesc, prometheus.GaugeValue, upValue)
+	}
+	if refreshErr != nil {
+		c.sendMetric(mChan, lastErrorDesc, prometheus.GaugeValue, 1, errorReason(refreshErr))
+	} else {
+		c.sendMetric(mChan, lastErrorDesc, prometheus.GaugeValue, 0, "")
+	}
+	c.sendMetric(mChan, consecutiveFailuresDesc, prometheus.GaugeValue, float64(c.consecutiveRefreshFailures()))
 	c.sendMetric(mChan, refreshIntervalDesc, prometheus.GaugeValue, c.RefreshInterval.Seconds())
-	c.sendMetric(mChan, refreshTimestampDesc, prometheus.GaugeValue, convertTime(c.lastRefresh))
-	c.sendMetric(mChan, refreshDurationDesc, prometheus.GaugeValue, c.lastRefreshDuration.Seconds())
+	c.sendMetric(mChan, refreshTimestampDesc, prometheus.GaugeValue, convertTimeMillis(lastRefresh))
+	c.sendMetric(mChan, refreshDurationDesc, prometheus.GaugeValue, refreshDuration.Seconds())
+	c.sendMetric(mChan, nextRefreshDesc, prometheus.GaugeValue, convertTimeMillis(c.nextRefresh(now)))
+	refreshDelay := c.refreshDelay()
 
 	c.cacheLock.RLock()
 	defer c.cacheLock.RUnlock()
 
-	c.sendMetric(mChan, cacheTimestampDesc, prometheus.GaugeValue, convertTime(c.cacheTimestamp))
+	c.sendMetric(mChan, cacheTimestampDesc, prometheus.GaugeValue, convertTimeMillis(c.stationsTimestamp))
+	if !c.stationsTimestamp.IsZero() {
+		cacheAge := now.Sub(c.stationsTimestamp)
+		c.sendMetric(mChan, cacheAgeDesc, prometheus.GaugeValue, cacheAge.Seconds())
+
+		servingStale := 0.0
+		if cacheAge > refreshDelay {
+			servingStale = 1
+		}
+		c.sendMetric(mChan, servingStaleCacheDesc, prometheus.GaugeValue, servingStale)
+	}
+	staleModules := 0
+	var completeness freshRatio
 	if c.cachedData != nil {
+		c.sendMetric(mChan, devicesDesc, prometheus.GaugeValue, float64(c.deviceCount))
+
+		// Modules can be linked to more than one station. Only the first occurrence is collected to avoid duplicate metrics.
+		seen := make(map[string]bool)
 		for _, dev := range c.cachedData.Devices() {
-			homeName := dev.HomeName
-			stationName := dev.StationName //nolint: staticcheck
-			c.collectData(mChan, dev, stationName, homeName)
+			if dev == nil || !c.stationIncluded(dev) {
+				continue
+			}
+
+			c.collectSafely(dev.ID, func() {
+				homeName := dev.HomeName
+				stationName := c.stationLabel(dev)
+				seen[dev.ID] = true
+				c.sendMetric(mChan, moduleIsMainDesc, prometheus.GaugeValue, 1, moduleName(dev), stationName, homeName)
+				stationUp := c.collectData(mChan, dev, stationName, homeName, nil)
+				var stationCompleteness freshRatio
+				stationCompleteness.add(stationUp)
+				var temperatures meanValue
+				var indoor, outdoor *float32
+				if stationUp {
+					temperatures.add(dev.DashboardData.Temperature)
+					indoor = dev.DashboardData.Temperature
+				}
+				if c.isStale(dev, now) {
+					staleModules++
+				}
+				windSpeed := c.windSpeed(dev)
+
+				if moduleCount, ok := c.moduleCounts[dev.ID]; ok {
+					c.sendMetric(mChan, stationModuleCountDesc, prometheus.GaugeValue, float64(moduleCount), stationName, homeName)
+				}
+				if place := c.details(dev).Place; place != nil {
+					c.sendMetric(mChan, stationInfoDesc, prometheus.GaugeValue, 1, stationName, homeName, place.Country, place.City, place.Timezone)
+					if offset, ok := c.timezoneOffset(place.Timezone, now); ok {
+						c.sendMetric(mChan, stationTimezoneOffsetDesc, prometheus.GaugeValue, offset, stationName, homeName)
+					} else {
+						c.Log.WithFields(logrus.Fields{
+							"station":  stationName,
+							"timezone": place.Timezone,
+						}).Debug("Unknown timezone, not exporting the timezone offset.")
+					}
+				}
+
+				for _, module := range dev.LinkedModules {
+					if module == nil {
+						continue
+					}
+
+					if seen[module.ID] {
+						c.Log.Debugf("Module %s already collected, skipping it for station %s.", module.ID, stationName)
+						continue
+					}
+					seen[module.ID] = true
+					c.sendMetric(mChan, moduleIsMainDesc, prometheus.GaugeValue, 0, moduleName(module), stationName, homeName)
+
+					// The wind gauge is a separate module, so its data is combined with the data of the outdoor module.
+					var moduleWindSpeed *float64
+					if module.Type == outdoorModuleType {
+						moduleWindSpeed = windSpeed
+					}
+
+					fresh := c.collectData(mChan, module, stationName, homeName, moduleWindSpeed)
+					stationUp = stationUp && fresh
+					stationCompleteness.add(fresh)
+					if fresh {
+						temperatures.add(module.DashboardData.Temperature)
+						if module.Type == outdoorModuleType {
+							outdoor = module.DashboardData.Temperature
+						}
+					}
+					if c.isStale(module, now) {
+						staleModules++
+					}
+				}
+
+				if mean, ok := temperatures.mean(); ok {
+					c.sendMetric(mChan, stationMeanTemperatureDesc, prometheus.GaugeValue, c.TemperaturePrecision.round(mean), stationName, homeName)
+				}
+				if indoor != nil && outdoor != nil {
+					c.sendMetric(mChan, stationTemperatureDeltaDesc, prometheus.GaugeValue, c.TemperaturePrecision.round(float64(*indoor-*outdoor)), stationName, homeName)
+				}
+				c.sendStationUp(mChan, stationUp && refreshErr == nil, stationName, homeName)
+				c.sendMetric(mChan, stationCompletenessDesc, prometheus.GaugeValue, stationCompleteness.ratio(), stationName, homeName)
+				completeness.merge(stationCompleteness)
+			})
+		}
+	}
+
+	for _, homeCoach := range c.cachedHomeCoaches {
+		if !c.stationIncluded(&homeCoach.Device) {
+			continue
+		}
+
+		c.collectSafely(homeCoach.ID, func() {
+			stationName := c.stationLabel(&homeCoach.Device)
+			stationUp := c.collectHomeCoach(mChan, homeCoach, stationName)
+			if c.isStale(&homeCoach.Device, now) {
+				staleModules++
+			}
+			c.sendStationUp(mChan, stationUp && refreshErr == nil, stationName, homeCoach.HomeName)
+
+			var stationCompleteness freshRatio
+			stationCompleteness.add(stationUp)
+			c.sendMetric(mChan, stationCompletenessDesc, prometheus.GaugeValue, stationCompleteness.ratio(), stationName, homeCoach.HomeName)
+			completeness.merge(stationCompleteness)
+		})
+	}
+
+	c.sendMetric(mChan, staleModulesDesc, prometheus.GaugeValue, float64(staleModules))
+	if completeness.total == 0 {
+		c.Log.Debug("No modules available, reporting data completeness of one.")
+	}
+	c.sendMetric(mChan, completenessDesc, prometheus.GaugeValue, completeness.ratio())
+
+	// The Energy API does not provide the time of the measurements, so the time of the last successful read is used.
+	if now.Sub(c.homesTimestamp) <= c.StaleThreshold {
+		for _, home := range c.cachedHomes {
+			c.collectSafely(home.ID, func() {
+				c.collectHome(mChan, home)
+			})
+		}
+	}
+}
+
+func (c *NetatmoCollector) sendStationUp(ch chan<- prometheus.Metric, up bool, stationName, homeName string) {
+	upValue := 0.0
+	if up {
+		upValue = 1.0
+	}
+
+	c.sendMetric(ch, stationUpDesc, prometheus.GaugeValue, upValue, stationName, homeName)
+}
+
+// refreshStatus returns the duration and error of the last refresh.
+func (c *NetatmoCollector) refreshStatus() (time.Duration, error) {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	return c.lastRefreshDuration, c.lastRefreshError
+}
+
+// consecutiveRefreshFailures returns the number of refreshes which failed since the last successful one.
+func (c *NetatmoCollector) consecutiveRefreshFailures() int {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	return c.consecutiveFailures
+}
+
+// nextRefresh returns the time after which the cached data will be refreshed. If a refresh is currently running,
+// now is returned.
+func (c *NetatmoCollector) nextRefresh(now time.Time) time.Time {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	if c.refreshing.Load() {
+		return now
+	}
+
+	return c.lastRefresh.Add(c.refreshInterval() + c.nextJitter)
+}
+
+// refreshDelay returns the time between the last refresh and the scheduled next one, including its jitter.
+func (c *NetatmoCollector) refreshDelay() time.Duration {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	return c.refreshInterval() + c.nextJitter
+}
+
+// Start starts the first refresh in the background, so that the exporter becomes ready without being scraped.
+func (c *NetatmoCollector) Start() {
+	c.triggerRefresh(c.clock())
+}
+
+// Ready returns true once data has been successfully read from the NetAtmo API. It only reports the cached state and
+// does not start a refresh, so that readiness probes do not cause requests to the NetAtmo API.
+func (c *NetatmoCollector) Ready() bool {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	return c.hasCachedData()
+}
+
+// hasCachedData returns true if stations or Healthy Home Coaches have been read successfully. The cacheLock needs to
+// be held by the caller.
+func (c *NetatmoCollector) hasCachedData() bool {
+	return !c.stationsTimestamp.IsZero() || !c.homeCoachTimestamp.IsZero()
+}
+
+// CachedDevice returns the cached data of the station, module or home coach with the ID. It does not trigger a refresh.
+// The cached devices are replaced but never modified during a refresh, so the result can be used without the lock.
+func (c *NetatmoCollector) CachedDevice(id string) (*netatmo.Device, bool) {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	if c.cachedData != nil {
+		for _, dev := range c.cachedData.Devices() {
+			if dev == nil {
+				continue
+			}
+
+			if dev.ID == id {
+				return dev, true
+			}
 
 			for _, module := range dev.LinkedModules {
-				c.collectData(mChan, module, stationName, homeName)
+				if module != nil && module.ID == id {
+					return module, true
+				}
 			}
 		}
 	}
+
+	for _, homeCoach := range c.cachedHomeCoaches {
+		if homeCoach.ID == id {
+			return &homeCoach.Device, true
+		}
+	}
+
+	return nil, false
+}
+
+// warmingUp returns true during the startup grace period, as long as no data has been read successfully.
+func (c *NetatmoCollector) warmingUp(now time.Time) bool {
+	if now.Sub(c.startTime) >= c.StartupGrace {
+		return false
+	}
+
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	return !c.hasCachedData()
+}
+
+// triggerRefresh starts a refresh in the background if one is due. It returns the time of the previous refresh and
+// whether a refresh has been started.
+func (c *NetatmoCollector) triggerRefresh(now time.Time) (time.Time, bool) {
+	lastRefresh, refreshDue := c.claimRefresh(now)
+	if refreshDue {
+		go func() {
+			defer c.refreshing.Store(false)
+			if lastRefresh.IsZero() {
+				c.initialRefresh(now)
+				return
+			}
+
+			c.refresh(now)
+		}()
+	}
+
+	return lastRefresh, refreshDue
+}
+
+// claimRefresh checks if the cached data needs to be refreshed and returns the time of the previous refresh.
+// If a refresh is due, the time of the last refresh is updated immediately, so that concurrent or aborted scrapes
+// do not start additional refreshes in the background. No refresh is due while another refresh is still running.
+// The time until the following refresh is extended by a random duration up to RefreshJitter.
+func (c *NetatmoCollector) claimRefresh(now time.Time) (time.Time, bool) {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	lastRefresh := c.lastRefresh
+	if now.Sub(lastRefresh) < c.refreshInterval()+c.nextJitter {
+		return lastRefresh, false
+	}
+
+	if !c.refreshing.CompareAndSwap(false, true) {
+		c.Log.Debug("Previous refresh still running, not starting another one.")
+		return lastRefresh, false
+	}
+
+	c.lastRefresh = now
+	c.nextJitter = c.randomDuration(c.RefreshJitter)
+	return lastRefresh, true
 }
 
 // RefreshData causes the collector to try to refresh the cached data.
 func (c *NetatmoCollector) RefreshData(now time.Time) {
-	c.Log.Debugf("Refreshing data. Time since last refresh: %s", now.Sub(c.lastRefresh))
+	c.refreshLock.Lock()
+	c.lastRefresh = now
+	c.refreshLock.Unlock()
+
+	c.refresh(now)
+}
+
+// ErrRefreshRunning is returned by ForceRefresh, when another refresh is still running.
+var ErrRefreshRunning = errors.New("another refresh is still running")
+
+// ForceRefresh refreshes the cached data immediately instead of waiting for the refresh interval. It returns the
+// duration and error of the refresh. The next regular refresh is scheduled relative to this one.
+func (c *NetatmoCollector) ForceRefresh() (time.Duration, error) {
+	now := c.clock()
+
+	c.refreshLock.Lock()
+	if !c.refreshing.CompareAndSwap(false, true) {
+		c.refreshLock.Unlock()
+		return 0, ErrRefreshRunning
+	}
 	c.lastRefresh = now
+	c.nextJitter = c.randomDuration(c.RefreshJitter)
+	c.refreshLock.Unlock()
+	defer c.refreshing.Store(false)
 
-	defer func(start time.Time) {
-		c.lastRefreshDuration = c.clock().Sub(start)
-	}(c.clock())
+	c.refresh(now)
+	return c.refreshStatus()
+}
+
+// initialRefresh does the first refresh and retries it a few times if it fails.
+func (c *NetatmoCollector) initialRefresh(now time.Time) {
+	for attempt := 1; ; attempt++ {
+		c.refresh(now)
+
+		if _, err := c.refreshStatus(); err == nil || attempt > initialRefreshRetries {
+			return
+		}
+
+		c.Log.Infof("Initial refresh failed, retrying in %s (attempt %d of %d).", c.initialRetryDelay, attempt, initialRefreshRetries)
+		_ = c.sleep(context.Background(), c.initialRetryDelay)
+		now = c.clock()
+	}
+}
 
-	devices, err := c.ReadFunction()
-	c.lastRefreshError = err
+func (c *NetatmoCollector) refresh(now time.Time) {
+	c.Log.Debug("Refreshing data.")
+
+	start := c.clock()
+	defer func() {
+		duration := c.clock().Sub(start)
+
+		c.refreshLock.Lock()
+		defer c.refreshLock.Unlock()
+		c.lastRefreshDuration = duration
+	}()
+
+	// The configured refresh interval is used after failed refreshes, until the reporting interval is known again.
+	c.adaptiveInterval.Store(0)
+
+	// Reads which take longer than the refresh interval are abandoned, so that they do not block further refreshes.
+	ctx, cancel := c.refreshContext()
+	defer cancel()
+
+	devices, err := c.readStations(ctx)
+	refreshErr := err
 	if err != nil {
-		c.Log.Errorf("Error during refresh: %s", err)
+		c.logRefreshError(err, "stations")
+	}
+
+	var homeCoaches []*api.HomeCoach
+	var homeCoachErr error
+	if c.ReadHomeCoachFunction != nil {
+		homeCoaches, homeCoachErr = readWithContext(ctx, c.ReadHomeCoachFunction)
+		if homeCoachErr != nil {
+			c.logRefreshError(homeCoachErr, "homecoach")
+			if refreshErr == nil {
+				refreshErr = homeCoachErr
+			}
+		}
+	}
+
+	var homes []*api.Home
+	var homesErr error
+	if c.ReadHomesFunction != nil {
+		homes, homesErr = readWithContext(ctx, c.ReadHomesFunction)
+		if homesErr != nil {
+			c.logRefreshError(homesErr, "energy")
+			if refreshErr == nil {
+				refreshErr = homesErr
+			}
+		}
+	}
+
+	c.refreshLock.Lock()
+	c.lastRefreshError = refreshErr
+	if refreshErr != nil {
+		c.consecutiveFailures++
+	} else {
+		c.consecutiveFailures = 0
+	}
+	c.refreshLock.Unlock()
+
+	if err == nil {
+		c.filterReadings(devices)
+	}
+	for _, homeCoach := range homeCoaches {
+		c.filterDevice(&homeCoach.Device)
+	}
+
+	homeCoachOK := c.ReadHomeCoachFunction != nil && homeCoachErr == nil
+	homesOK := c.ReadHomesFunction != nil && homesErr == nil
+	if err != nil && !homeCoachOK && !homesOK {
 		return
 	}
 
+	// Parts of the data which could not be refreshed are kept in the cache. They will eventually be considered stale.
 	c.cacheLock.Lock()
 	defer c.cacheLock.Unlock()
-	c.cacheTimestamp = now
-	c.cachedData = devices
+	if !c.hasCachedData() {
+		c.logFilteredStations(devices, homeCoaches)
+	}
+	if err == nil {
+		c.stationsTimestamp = now
+		c.deviceCount = countStations(devices)
+		c.moduleCounts = countModules(devices)
+		if c.deviceCount == 0 {
+			c.Log.Warn("No stations found. Check if the stations are still assigned to the account.")
+		}
+
+		c.cachedData = mergeDevices(c.cachedData, devices, now.Add(-c.StaleThreshold))
+	}
+	if homeCoachOK {
+		c.cachedHomeCoaches = homeCoaches
+		c.homeCoachTimestamp = now
+	}
+	c.observeReports(c.cachedData, c.cachedHomeCoaches)
+	c.adaptRefreshInterval(now)
+	c.observePeaks(c.cachedData, c.cachedHomeCoaches, now)
+	c.observeRain(c.cachedData)
+	c.observeGusts(c.cachedData)
+	if homesOK {
+		c.cachedHomes = homes
+		c.homesTimestamp = now
+	}
+	c.logRefreshSummary(now, c.clock().Sub(start), refreshErr)
+}
+
+// logRefreshSummary logs the number of stations and modules in the cache after a refresh. Modules include the main
+// module of each station and the Healthy Home Coaches. The summary is logged as a warning if a part of the data could
+// not be refreshed.
+func (c *NetatmoCollector) logRefreshSummary(now time.Time, duration time.Duration, refreshErr error) {
+	stations, modules, stale := 0, 0, 0
+	count := func(device *netatmo.Device) {
+		modules++
+		if c.isStale(device, now) {
+			stale++
+		}
+	}
+
+	if c.cachedData != nil {
+		for _, station := range c.cachedData.Devices() {
+			if station == nil {
+				continue
+			}
+
+			stations++
+			count(station)
+			for _, module := range station.LinkedModules {
+				if module != nil {
+					count(module)
+				}
+			}
+		}
+	}
+	for _, homeCoach := range c.cachedHomeCoaches {
+		stations++
+		count(&homeCoach.Device)
+	}
+
+	entry := c.Log.WithFields(logrus.Fields{
+		"stations": stations,
+		"modules":  modules,
+		"stale":    stale,
+		"duration": duration,
+	})
+	if refreshErr != nil {
+		entry.WithError(refreshErr).Warn("Refresh partially failed.")
+		return
+	}
+
+	entry.Info("Refresh completed.")
+}
+
+// refreshContext returns the context used for a single refresh. Its deadline is the refresh interval.
+func (c *NetatmoCollector) refreshContext() (context.Context, context.CancelFunc) {
+	if c.RefreshInterval <= 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), c.RefreshInterval)
+}
+
+// readWithContext returns the result of read, unless ctx is done earlier. The read functions can not be cancelled, so
+// an abandoned read continues in the background and its result is discarded.
+func readWithContext[T any](ctx context.Context, read func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		value, err := read()
+		resultCh <- result{value, err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// readStations calls the ReadFunction and retries it up to ReadRetries times. No more retries are done, when the next
+// one would start after the refresh interval has passed. Waiting for a retry stops when ctx is done, so the retries
+// never outlast the deadline of the refresh.
+func (c *NetatmoCollector) readStations(ctx context.Context) (*netatmo.DeviceCollection, error) {
+	start := c.clock()
+	for attempt := 1; ; attempt++ {
+		devices, err := readWithContext(ctx, c.ReadFunction)
+		if err == nil || attempt > c.ReadRetries || ctx.Err() != nil {
+			return devices, err
+		}
+
+		if c.clock().Add(c.ReadRetryDelay).Sub(start) >= c.RefreshInterval {
+			return devices, err
+		}
+
+		c.Log.WithError(err).Infof("Reading stations failed, retrying in %s (attempt %d of %d).", c.ReadRetryDelay, attempt, c.ReadRetries)
+		if c.sleep(ctx, c.ReadRetryDelay) != nil {
+			return devices, err
+		}
+	}
+}
+
+// logRefreshError logs an error which happened while reading the data of source. The error is classified
+// using errorReason, so that log-based alerting can match on the fields instead of the message.
+func (c *NetatmoCollector) logRefreshError(err error, source string) {
+	c.Log.WithFields(logrus.Fields{
+		"source": source,
+		"reason": errorReason(err),
+	}).WithError(err).Error("Error during refresh.")
+}
+
+// countStations returns the number of stations contained in devices.
+func countStations(devices *netatmo.DeviceCollection) int {
+	if devices == nil {
+		return 0
+	}
+
+	count := 0
+	for _, station := range devices.Devices() {
+		if station != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// countModules returns the number of modules of every station contained in devices by the ID of the station. It is
+// used instead of the merged cache, so that modules which are no longer returned by the NetAtmo API are not counted.
+func countModules(devices *netatmo.DeviceCollection) map[string]int {
+	counts := make(map[string]int)
+	if devices == nil {
+		return counts
+	}
+
+	for _, station := range devices.Devices() {
+		if station == nil {
+			continue
+		}
+
+		count := 0
+		for _, module := range station.LinkedModules {
+			if module != nil {
+				count++
+			}
+		}
+		counts[station.ID] = count
+	}
+
+	return counts
+}
+
+// filterReadings removes the readings outside of the configured limits from all stations and their modules.
+func (c *NetatmoCollector) filterReadings(devices *netatmo.DeviceCollection) {
+	if devices == nil {
+		return
+	}
+
+	for _, station := range devices.Devices() {
+		if station == nil {
+			continue
+		}
+
+		c.filterDevice(station)
+		for _, module := range station.LinkedModules {
+			if module != nil {
+				c.filterDevice(module)
+			}
+		}
+	}
+}
+
+// filterDevice removes the readings outside of the configured limits from a single device. Removed readings are
+// counted and logged.
+func (c *NetatmoCollector) filterDevice(device *netatmo.Device) {
+	data := &device.DashboardData
+	if data.Temperature != nil && !c.plausible(device, "temperature", c.TemperatureLimits, float64(*data.Temperature)) {
+		data.Temperature = nil
+	}
+
+	if data.Humidity != nil && !c.plausible(device, "humidity", c.HumidityLimits, float64(*data.Humidity)) {
+		data.Humidity = nil
+	}
+}
+
+// plausible checks a reading of the device against the limits of the metric. Readings outside of the limits are
+// counted and logged.
+func (c *NetatmoCollector) plausible(device *netatmo.Device, metric string, limits Limits, value float64) bool {
+	if limits.contains(value) {
+		return true
+	}
+
+	c.filteredReadings.WithLabelValues(metric).Inc()
+	c.Log.WithFields(logrus.Fields{
+		"station": device.StationName, //nolint: staticcheck
+		"module":  moduleName(device),
+		"metric":  metric,
+		"value":   value,
+	}).Debug("Reading is outside of limits.")
+	return false
+}
+
+// stationIncluded checks the name and ID of a station against the included and excluded stations.
+// Excluded stations take precedence over included ones.
+func (c *NetatmoCollector) stationIncluded(dev *netatmo.Device) bool {
+	stationName := dev.StationName //nolint: staticcheck
+	matches := func(list []string) bool {
+		for _, item := range list {
+			if item == stationName || item == dev.ID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if matches(c.ExcludeStations) {
+		return false
+	}
+
+	return len(c.IncludeStations) == 0 || matches(c.IncludeStations)
+}
+
+// logFilteredStations logs the stations which are not exported because of the station filter.
+func (c *NetatmoCollector) logFilteredStations(devices *netatmo.DeviceCollection, homeCoaches []*api.HomeCoach) {
+	var stations []*netatmo.Device
+	if devices != nil {
+		stations = append(stations, devices.Devices()...)
+	}
+	for _, homeCoach := range homeCoaches {
+		stations = append(stations, &homeCoach.Device)
+	}
+
+	for _, dev := range stations {
+		if !c.stationIncluded(dev) {
+			c.Log.Infof("Station %q (%s) is filtered and will not be exported.", dev.StationName, dev.ID) //nolint: staticcheck
+		}
+	}
+}
+
+// collectHome sends the metrics for a home with NetAtmo Energy devices and its rooms. Rooms without thermostat or
+// valves are skipped.
+func (c *NetatmoCollector) collectHome(ch chan<- prometheus.Metric, home *api.Home) {
+	if home.ScheduleID != "" {
+		c.sendMetric(ch, scheduleInfoDesc, prometheus.GaugeValue, 1, home.Name, home.ScheduleID, home.ScheduleName)
+	}
+
+	for _, room := range home.Rooms {
+		if !room.HasThermostat() {
+			continue
+		}
+
+		if room.MeasuredTemperature != nil {
+			c.sendMetric(ch, roomTemperatureDesc, prometheus.GaugeValue, *room.MeasuredTemperature, room.Name, home.Name)
+		}
+
+		if room.SetpointTemperature != nil {
+			c.sendMetric(ch, roomSetpointDesc, prometheus.GaugeValue, *room.SetpointTemperature, room.Name, home.Name)
+		}
+
+		if room.HeatingPowerRequest != nil {
+			heatingPower := float64(*room.HeatingPowerRequest)
+			c.sendMetric(ch, valveOpenDesc, prometheus.GaugeValue, heatingPower, room.Name, home.Name)
+			c.sendMetric(ch, heatingPowerRequestDesc, prometheus.GaugeValue, heatingPower, room.Name, home.Name)
+		}
+
+		if room.BoilerOn != nil {
+			boilerOn := 0.0
+			if *room.BoilerOn {
+				boilerOn = 1.0
+			}
+			c.sendMetric(ch, boilerOnDesc, prometheus.GaugeValue, boilerOn, room.Name, home.Name)
+		}
+	}
 }
 
-func (c *NetatmoCollector) collectData(ch chan<- prometheus.Metric, device *netatmo.Device, stationName, homeName string) {
-	moduleName := device.ModuleName
-	if moduleName == "" {
-		moduleName = "id-" + device.ID
+// collectHomeCoach sends the metrics for a Healthy Home Coach device. It returns false if there was no fresh data available.
+func (c *NetatmoCollector) collectHomeCoach(ch chan<- prometheus.Metric, homeCoach *api.HomeCoach, stationName string) bool {
+	homeName := homeCoach.HomeName
+	c.sendMetric(ch, moduleIsMainDesc, prometheus.GaugeValue, 1, moduleName(&homeCoach.Device), stationName, homeName)
+	if !c.collectData(ch, &homeCoach.Device, stationName, homeName, nil) {
+		return false
 	}
 
+	if homeCoach.HealthIndex != nil {
+		c.sendMetric(ch, healthIndexDesc, prometheus.GaugeValue, float64(*homeCoach.HealthIndex), moduleName(&homeCoach.Device), stationName, homeName)
+	}
+
+	return true
+}
+
+// windSpeed returns the wind speed measured by the wind gauge of a station in km/h. It returns nil if the station has
+// no wind gauge or its data is stale.
+func (c *NetatmoCollector) windSpeed(station *netatmo.Device) *float64 {
+	for _, module := range station.LinkedModules {
+		if module == nil || module.DashboardData.WindStrength == nil || module.DashboardData.LastMeasure == nil {
+			continue
+		}
+
+		if c.clock().Sub(time.Unix(*module.DashboardData.LastMeasure, 0)) > c.StaleThreshold {
+			continue
+		}
+
+		windSpeed := float64(*module.DashboardData.WindStrength)
+		return &windSpeed
+	}
+
+	return nil
+}
+
+// collectData sends the metrics for a single device. It returns false if there was no fresh data available.
+// The windSpeed is used for calculating the apparent temperature and can be nil.
+func (c *NetatmoCollector) collectData(ch chan<- prometheus.Metric, device *netatmo.Device, stationName, homeName string, windSpeed *float64) bool {
+	moduleName := moduleName(device)
+	c.sendMetric(ch, moduleInfoDesc, prometheus.GaugeValue, 1, moduleName, stationName, homeName, device.Type)
+
+	// Modules which became unreachable can still have recent data in the cache.
+	if reachable := c.details(device).Reachable; c.SkipUnreachable && reachable != nil && !*reachable {
+		c.Log.WithFields(logrus.Fields{
+			"station": stationName,
+			"module":  moduleName,
+		}).Debug("Module is unreachable, not exporting its data.")
+		return false
+	}
+
+	// The dashboard data is missing for new or offline modules. The library decodes it as an empty struct in that case.
 	data := device.DashboardData
 
 	if data.LastMeasure == nil {
-		c.Log.Debugf("No data available.")
-		return
+		c.Log.WithFields(logrus.Fields{
+			"station": stationName,
+			"module":  moduleName,
+		}).Debug("No data available.")
+		return false
 	}
 
 	date := time.Unix(*data.LastMeasure, 0)
+	c.sendMetric(ch, moduleLastSeenDesc, prometheus.GaugeValue, float64(date.Unix()), moduleName, stationName, homeName)
+
 	dataAge := c.clock().Sub(date)
+	c.sendMetric(ch, clockSkewDesc, prometheus.GaugeValue, -dataAge.Seconds(), moduleName, stationName, homeName)
+	if dataAge < 0 {
+		if -dataAge > maxClockSkew {
+			c.Log.WithFields(logrus.Fields{
+				"station": stationName,
+				"module":  moduleName,
+				"skew":    -dataAge,
+			}).Warn("Measurement is in the future.")
+		}
+
+		// Measurements from the future are considered fresh, until they are older than the stale threshold.
+		dataAge = 0
+	}
+
 	if dataAge > c.StaleThreshold {
-		c.Log.Debugf("Data is stale for %s: %s > %s", moduleName, dataAge, c.StaleThreshold)
-		return
+		c.Log.WithFields(logrus.Fields{
+			"station":   stationName,
+			"module":    moduleName,
+			"age":       dataAge,
+			"threshold": c.StaleThreshold,
+		}).Debug("Data is stale.")
+		return false
 	}
 
 	c.sendMetric(ch, updatedDesc, prometheus.GaugeValue, float64(date.UTC().Unix()), moduleName, stationName, homeName)
+	c.sendMetric(ch, expectedNextReportDesc, prometheus.GaugeValue, convertTime(c.expectedNextReport(device, date)), moduleName, stationName, homeName)
 
 	if data.Temperature != nil {
-		c.sendMetric(ch, tempDesc, prometheus.GaugeValue, float64(*data.Temperature), moduleName, stationName, homeName)
+		c.sendMetric(ch, tempDesc, prometheus.GaugeValue, c.TemperaturePrecision.round(float64(*data.Temperature)), moduleName, stationName, homeName)
 	}
 
 	if data.Humidity != nil {
 		c.sendMetric(ch, humidityDesc, prometheus.GaugeValue, float64(*data.Humidity), moduleName, stationName, homeName)
+
+		// Comfort only makes sense indoors.
+		if device.Type != outdoorModuleType {
+			c.sendMetric(ch, humidityComfortDesc, prometheus.GaugeValue, c.HumidityComfort.comfort(float64(*data.Humidity)), moduleName, stationName, homeName)
+		}
+	}
+
+	if data.Temperature != nil && data.Humidity != nil {
+		apparent := apparentTemperature(float64(*data.Temperature), float64(*data.Humidity), windSpeed)
+		c.sendMetric(ch, apparentTemperatureDesc, prometheus.GaugeValue, c.TemperaturePrecision.round(apparent), moduleName, stationName, homeName)
 	}
 
 	if data.CO2 != nil {
 		c.sendMetric(ch, cotwoDesc, prometheus.GaugeValue, float64(*data.CO2), moduleName, stationName, homeName)
+
+		alert := 0.0
+		if *data.CO2 > c.CO2AlertThreshold {
+			alert = 1
+		}
+		c.sendMetric(ch, cotwoAlertDesc, prometheus.GaugeValue, alert, moduleName, stationName, homeName)
+	}
+
+	if peak, ok := c.peak(device, peakCO2); ok {
+		c.sendMetric(ch, cotwoMaxDesc, prometheus.GaugeValue, peak, moduleName, stationName, homeName)
 	}
 
 	if data.Noise != nil {
 		c.sendMetric(ch, noiseDesc, prometheus.GaugeValue, float64(*data.Noise), moduleName, stationName, homeName)
 	}
 
+	if peak, ok := c.peak(device, peakNoise); ok {
+		c.sendMetric(ch, noiseMaxDesc, prometheus.GaugeValue, peak, moduleName, stationName, homeName)
+	}
+
 	if data.Pressure != nil {
-		c.sendMetric(ch, pressureDesc, prometheus.GaugeValue, float64(*data.Pressure), moduleName, stationName, homeName)
+		c.sendMetric(ch, pressureDesc, prometheus.GaugeValue, c.PressurePrecision.round(float64(*data.Pressure)), moduleName, stationName, homeName)
+	}
+
+	if data.AbsolutePressure != nil {
+		c.sendMetric(ch, absolutePressureDesc, prometheus.GaugeValue, c.PressurePrecision.round(float64(*data.AbsolutePressure)), moduleName, stationName, homeName)
 	}
 
 	if data.WindStrength != nil {
@@ -268,30 +1639,234 @@ func (c *NetatmoCollector) collectData(ch chan<- prometheus.Metric, device *neta
 		c.sendMetric(ch, windDirectionDesc, prometheus.GaugeValue, float64(*data.WindAngle), moduleName, stationName, homeName)
 	}
 
+	if data.GustStrength != nil {
+		if gustMax, ok := c.gustMax[device.ID]; ok {
+			c.sendMetric(ch, gustMaxDesc, prometheus.GaugeValue, gustMax, moduleName, stationName, homeName)
+		}
+	}
+
 	if data.Rain != nil {
 		c.sendMetric(ch, rainDesc, prometheus.GaugeValue, float64(*data.Rain), moduleName, stationName, homeName)
 	}
 
+	if rate, ok := c.rainRate(device); ok {
+		c.sendMetric(ch, rainRateDesc, prometheus.GaugeValue, rate, moduleName, stationName, homeName)
+	}
+
 	if device.BatteryPercent != nil {
 		c.sendMetric(ch, batteryDesc, prometheus.GaugeValue, float64(*device.BatteryPercent), moduleName, stationName, homeName)
 	}
+	details := c.details(device)
+	if details.BatteryVoltage != nil {
+		c.sendMetric(ch, batteryVoltageDesc, prometheus.GaugeValue, float64(*details.BatteryVoltage), moduleName, stationName, homeName)
+		if status, ok := batteryStatus(device.Type, *details.BatteryVoltage); ok {
+			c.sendMetric(ch, batteryStatusDesc, prometheus.GaugeValue, status, moduleName, stationName, homeName)
+		}
+	}
 	if device.WifiStatus != nil {
 		c.sendMetric(ch, wifiDesc, prometheus.GaugeValue, float64(*device.WifiStatus), moduleName, stationName, homeName)
+		c.sendMetric(ch, wifiQualityDesc, prometheus.GaugeValue, c.WifiThresholds.quality(*device.WifiStatus), moduleName, stationName, homeName)
+		c.sendMetric(ch, wifiCategoryDesc, prometheus.GaugeValue, c.WifiThresholds.category(*device.WifiStatus), moduleName, stationName, homeName)
 	}
 	if device.RFStatus != nil {
 		c.sendMetric(ch, rfDesc, prometheus.GaugeValue, float64(*device.RFStatus), moduleName, stationName, homeName)
+		c.sendMetric(ch, rfQualityDesc, prometheus.GaugeValue, c.RFThresholds.quality(*device.RFStatus), moduleName, stationName, homeName)
+		c.sendMetric(ch, rfCategoryDesc, prometheus.GaugeValue, c.RFThresholds.category(*device.RFStatus), moduleName, stationName, homeName)
+	}
+
+	return true
+}
+
+// timezoneOffset returns the offset of the timezone with the name from UTC at now in seconds. It returns false if the
+// name is empty or not a known timezone. The timezones are loaded once and kept.
+func (c *NetatmoCollector) timezoneOffset(name string, now time.Time) (float64, bool) {
+	if name == "" {
+		return 0, false
+	}
+
+	value, ok := c.timezones.Load(name)
+	if !ok {
+		// Unknown timezones are stored as nil, so that loading them is not tried again.
+		location, _ := time.LoadLocation(name)
+		value, _ = c.timezones.LoadOrStore(name, location)
 	}
+
+	location := value.(*time.Location)
+	if location == nil {
+		return 0, false
+	}
+
+	_, offset := now.In(location).Zone()
+	return float64(offset), true
+}
+
+// details returns the values of the device, which are not part of the data returned by the ReadFunction. They are
+// empty if no DetailsFunction is set or it does not know the device.
+func (c *NetatmoCollector) details(device *netatmo.Device) api.DeviceDetails {
+	if c.DetailsFunction == nil {
+		return api.DeviceDetails{}
+	}
+
+	details, _ := c.DetailsFunction(device.ID)
+	return details
+}
+
+// meanValue calculates the average of the values added to it. Missing values are skipped.
+type meanValue struct {
+	sum   float64
+	count int
+}
+
+func (m *meanValue) add(value *float32) {
+	if value == nil {
+		return
+	}
+
+	m.sum += float64(*value)
+	m.count++
+}
+
+func (m meanValue) mean() (float64, bool) {
+	if m.count == 0 {
+		return 0, false
+	}
+
+	return m.sum / float64(m.count), true
+}
+
+// freshRatio counts how many of the modules provided fresh data.
+type freshRatio struct {
+	fresh int
+	total int
+}
+
+func (r *freshRatio) add(fresh bool) {
+	if fresh {
+		r.fresh++
+	}
+	r.total++
+}
+
+func (r *freshRatio) merge(other freshRatio) {
+	r.fresh += other.fresh
+	r.total += other.total
+}
+
+// ratio returns the fraction of modules with fresh data. Without any modules nothing is missing, so the result is one.
+func (r freshRatio) ratio() float64 {
+	if r.total == 0 {
+		return 1
+	}
+
+	return float64(r.fresh) / float64(r.total)
+}
+
+// isStale returns true, if the device has data, but it is older than the stale threshold.
+func (c *NetatmoCollector) isStale(device *netatmo.Device, now time.Time) bool {
+	if device.DashboardData.LastMeasure == nil {
+		return false
+	}
+
+	return now.Sub(time.Unix(*device.DashboardData.LastMeasure, 0)) > c.StaleThreshold
+}
+
+// newComputedDesc creates the description of a metric calculated by the exporter instead of being reported by the
+// NetAtmo API. The help text of these metrics says so, so that they can be told apart from the reported values.
+func newComputedDesc(fqName, help string, variableLabels []string) *prometheus.Desc {
+	return prometheus.NewDesc(fqName, help+" Computed by the exporter.", variableLabels, nil)
+}
+
+// moduleName returns the name used in the module label for the device.
+func moduleName(device *netatmo.Device) string {
+	if device.ModuleName == "" {
+		return "id-" + device.ID
+	}
+
+	return device.ModuleName
+}
+
+// collectSafely runs collect and recovers from a panic in it, so that a bug triggered by the data of one device does
+// not prevent the metrics of the other devices from being exported.
+func (c *NetatmoCollector) collectSafely(deviceID string, collect func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.collectPanics.Inc()
+			c.Log.WithFields(logrus.Fields{
+				"device": deviceID,
+				"panic":  r,
+				"stack":  string(debug.Stack()),
+			}).Error("Recovered from panic while collecting metrics.")
+		}
+	}()
+
+	collect()
 }
 
 func (c *NetatmoCollector) sendMetric(ch chan<- prometheus.Metric, desc *prometheus.Desc, valueType prometheus.ValueType, value float64, labelValues ...string) {
 	m, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
 	if err != nil {
-		c.Log.Errorf("Error creating %s metric: %s", updatedDesc.String(), err)
+		name := MetricName(desc)
+		c.metricErrors.WithLabelValues(name).Inc()
+		c.Log.Errorf("Error creating %s metric: %s", name, err)
 		return
 	}
 	ch <- m
 }
 
+// MetricName returns the name of the metric described by desc.
+func MetricName(desc *prometheus.Desc) string {
+	match := fqNameRegexp.FindStringSubmatch(desc.String())
+	if match == nil {
+		return desc.String()
+	}
+
+	return match[1]
+}
+
+// signalQuality converts a raw signal strength into a percentage. Values at or beyond worst map to 0 and values at
+// or beyond best map to 100.
+func signalQuality(value int32, worst, best int32) float64 {
+	quality := float64(worst-value) / float64(worst-best) * 100
+	switch {
+	case quality < 0:
+		return 0
+	case quality > 100:
+		return 100
+	default:
+		return quality
+	}
+}
+
+// errorReason returns a short category for an error returned by the ReadFunction.
+func errorReason(err error) string {
+	var (
+		netErr       net.Error
+		retrieveErr  *oauth2.RetrieveError
+		syntaxErr    *json.SyntaxError
+		unmarshalErr *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.Is(err, netatmo.ErrNotAuthenticated):
+		return "not_authenticated"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &retrieveErr):
+		return "token"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case errors.As(err, &syntaxErr), errors.As(err, &unmarshalErr):
+		return "decode"
+	default:
+		return "api"
+	}
+}
+
+// convertTime returns the time in seconds since the epoch. It is used for the time of measurements, which is only
+// provided in whole seconds.
 func convertTime(t time.Time) float64 {
 	if t.IsZero() {
 		return 0.0
@@ -299,3 +1874,13 @@ func convertTime(t time.Time) float64 {
 
 	return float64(t.Unix())
 }
+
+// convertTimeMillis returns the time in seconds since the epoch with millisecond precision. It is used for the times
+// of the exporter itself, so that short refresh intervals can be measured.
+func convertTimeMillis(t time.Time) float64 {
+	if t.IsZero() {
+		return 0.0
+	}
+
+	return float64(t.UnixMilli()) / 1000
+}
@@ -1,15 +1,28 @@
 package collector
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	netatmo "github.com/exzz/netatmo-api-go"
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
 )
 
 func TestRefreshData(t *testing.T) {
@@ -53,8 +66,8 @@ func TestRefreshData(t *testing.T) {
 			c := New(logrus.New(), tc.readFunction, 0, 0)
 			c.RefreshData(tc.time)
 
-			if c.cacheTimestamp != tc.wantTime {
-				t.Errorf("got time %s, want %s", c.cacheTimestamp, tc.wantTime)
+			if c.stationsTimestamp != tc.wantTime {
+				t.Errorf("got time %s, want %s", c.stationsTimestamp, tc.wantTime)
 			}
 
 			if diff := cmp.Diff(c.cachedData, tc.wantData); diff != "" {
@@ -100,6 +113,442 @@ func TestRefreshDataResetError(t *testing.T) {
 	}
 }
 
+func TestRefreshDataConsecutiveFailures(t *testing.T) {
+	testError := errors.New("test error")
+	fail := true
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		if fail {
+			return nil, testError
+		}
+		return &netatmo.DeviceCollection{}, nil
+	}, 0, 0)
+
+	tt := []struct {
+		fail bool
+		want int
+	}{
+		{fail: true, want: 1},
+		{fail: true, want: 2},
+		{fail: true, want: 3},
+		{fail: false, want: 0},
+		{fail: true, want: 1},
+	}
+
+	for i, tc := range tt {
+		fail = tc.fail
+		c.RefreshData(time.Unix(int64(i), 0))
+
+		if got := c.consecutiveRefreshFailures(); got != tc.want {
+			t.Errorf("after refresh %d got %d failures, want %d", i, got, tc.want)
+		}
+	}
+}
+
+func TestRefreshDataKeepsCache(t *testing.T) {
+	testData := &netatmo.DeviceCollection{}
+	testError := errors.New("test error")
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return testData, nil
+	}, time.Minute, time.Hour)
+	c.RefreshData(time.Unix(0, 0))
+
+	c.ReadFunction = func() (*netatmo.DeviceCollection, error) {
+		return nil, testError
+	}
+	c.RefreshData(time.Unix(60, 0))
+
+	if c.cachedData != testData {
+		t.Errorf("failed refresh changed cached data: got %v, want %v", c.cachedData, testData)
+	}
+
+	if c.stationsTimestamp != time.Unix(0, 0) {
+		t.Errorf("failed refresh changed cache time: got %s, want %s", c.stationsTimestamp, time.Unix(0, 0))
+	}
+}
+
+func TestRefreshDataKeepsStationTime(t *testing.T) {
+	testError := errors.New("test error")
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return &netatmo.DeviceCollection{}, nil
+	}, time.Minute, time.Hour)
+	c.ReadHomeCoachFunction = func() ([]*api.HomeCoach, error) {
+		return nil, nil
+	}
+	c.RefreshData(time.Unix(0, 0))
+
+	c.ReadFunction = func() (*netatmo.DeviceCollection, error) {
+		return nil, testError
+	}
+	c.RefreshData(time.Unix(60, 0))
+
+	if c.stationsTimestamp != time.Unix(0, 0) {
+		t.Errorf("failed station read changed station time: got %s, want %s", c.stationsTimestamp, time.Unix(0, 0))
+	}
+
+	if c.homeCoachTimestamp != time.Unix(60, 0) {
+		t.Errorf("got home coach time %s, want %s", c.homeCoachTimestamp, time.Unix(60, 0))
+	}
+}
+
+func TestNetatmoCollector_CollectServingStaleCache(t *testing.T) {
+	tt := []struct {
+		desc          string
+		jitter        time.Duration
+		failedRefresh bool
+		scrape        time.Time
+		wantStale     string
+	}{
+		{
+			desc:          "failed refresh",
+			failedRefresh: true,
+			scrape:        time.Unix(120, 0),
+			wantStale:     "1",
+		},
+		{
+			desc:      "waiting for jitter",
+			jitter:    30 * time.Second,
+			scrape:    time.Unix(80, 0),
+			wantStale: "0",
+		},
+		{
+			desc:      "after jitter",
+			jitter:    30 * time.Second,
+			scrape:    time.Unix(100, 0),
+			wantStale: "1",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+				return &netatmo.DeviceCollection{}, nil
+			}, time.Minute, time.Hour)
+			c.randomDuration = func(time.Duration) time.Duration {
+				return tc.jitter
+			}
+			c.RefreshJitter = tc.jitter
+			c.RefreshData(time.Unix(0, 0))
+
+			c.ReadFunction = func() (*netatmo.DeviceCollection, error) {
+				return nil, errors.New("test error")
+			}
+			if tc.failedRefresh {
+				c.RefreshData(tc.scrape)
+			}
+			c.clock = func() time.Time {
+				return tc.scrape
+			}
+
+			expected := strings.NewReader(`# HELP netatmo_serving_stale_cache One if the cached data is older than the time between refreshes including the jitter, for example because a refresh is running or failed. Only present once data has been cached. Computed by the exporter.
+# TYPE netatmo_serving_stale_cache gauge
+netatmo_serving_stale_cache ` + tc.wantStale + `
+`)
+
+			if err := testutil.CollectAndCompare(c, expected, "netatmo_serving_stale_cache"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRefreshDataPartialError(t *testing.T) {
+	testData := &netatmo.DeviceCollection{}
+	testHomeCoaches := []*api.HomeCoach{{}}
+	testError := errors.New("test error")
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return testData, nil
+	}, 0, 0)
+	c.ReadHomeCoachFunction = func() ([]*api.HomeCoach, error) {
+		return nil, testError
+	}
+	c.RefreshData(time.Unix(0, 0))
+
+	if c.lastRefreshError != testError {
+		t.Errorf("got error %q, want %q", c.lastRefreshError, testError)
+	}
+
+	if c.cachedData != testData {
+		t.Errorf("got data %v, want %v", c.cachedData, testData)
+	}
+
+	c.ReadFunction = func() (*netatmo.DeviceCollection, error) {
+		return nil, testError
+	}
+	c.ReadHomeCoachFunction = func() ([]*api.HomeCoach, error) {
+		return testHomeCoaches, nil
+	}
+	c.RefreshData(time.Unix(1, 0))
+
+	if c.lastRefreshError != testError {
+		t.Errorf("got error %q, want %q", c.lastRefreshError, testError)
+	}
+
+	if c.cachedData != testData {
+		t.Errorf("cached data was not kept: got %v, want %v", c.cachedData, testData)
+	}
+
+	if diff := cmp.Diff(c.cachedHomeCoaches, testHomeCoaches); diff != "" {
+		t.Errorf("home coaches differ: -got+want\n%s", diff)
+	}
+}
+
+func TestRefreshDataErrorFields(t *testing.T) {
+	testError := errors.New("test error")
+	log, hook := test.NewNullLogger()
+
+	c := New(log, func() (*netatmo.DeviceCollection, error) {
+		return nil, testError
+	}, 0, 0)
+	c.RefreshData(time.Unix(0, 0))
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("no log entry written")
+	}
+
+	wantFields := logrus.Fields{
+		"source":        "stations",
+		"reason":        "api",
+		logrus.ErrorKey: testError,
+	}
+	if diff := cmp.Diff(entry.Data, wantFields, cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Errorf("fields differ: -got+want\n%s", diff)
+	}
+}
+
+func TestRefreshDataSummary(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 100, "Temperature": 5}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	var devices netatmo.DeviceCollection
+	if err := json.Unmarshal([]byte(body), &devices); err != nil {
+		t.Fatalf("error decoding test data: %s", err)
+	}
+
+	now := time.Unix(3600, 0)
+	log, hook := test.NewNullLogger()
+	c := New(log, func() (*netatmo.DeviceCollection, error) {
+		now = now.Add(2 * time.Second)
+		return &devices, nil
+	}, time.Minute, 30*time.Minute)
+	c.clock = func() time.Time { return now }
+	c.RefreshData(now)
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Refresh completed." {
+		t.Fatalf("got log entry %v, want refresh summary", entry)
+	}
+
+	if entry.Level != logrus.InfoLevel {
+		t.Errorf("got level %s, want %s", entry.Level, logrus.InfoLevel)
+	}
+
+	wantFields := logrus.Fields{
+		"stations": 1,
+		"modules":  2,
+		"stale":    1,
+		"duration": 2 * time.Second,
+	}
+	if diff := cmp.Diff(entry.Data, wantFields); diff != "" {
+		t.Errorf("fields differ: -got+want\n%s", diff)
+	}
+}
+
+func TestRefreshDataSummaryPartialFailure(t *testing.T) {
+	testError := errors.New("test error")
+	log, hook := test.NewNullLogger()
+
+	c := New(log, func() (*netatmo.DeviceCollection, error) {
+		return nil, testError
+	}, time.Minute, 30*time.Minute)
+	c.ReadHomeCoachFunction = func() ([]*api.HomeCoach, error) {
+		return []*api.HomeCoach{{Device: netatmo.Device{ID: "70:ee:50:00:00:02"}}}, nil
+	}
+	c.RefreshData(time.Unix(3600, 0))
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Refresh partially failed." {
+		t.Fatalf("got log entry %v, want refresh summary", entry)
+	}
+
+	if entry.Level != logrus.WarnLevel {
+		t.Errorf("got level %s, want %s", entry.Level, logrus.WarnLevel)
+	}
+
+	if entry.Data[logrus.ErrorKey] != testError {
+		t.Errorf("got error %v, want %v", entry.Data[logrus.ErrorKey], testError)
+	}
+}
+
+func TestRefreshDataNoStations(t *testing.T) {
+	log, hook := test.NewNullLogger()
+
+	c := New(log, func() (*netatmo.DeviceCollection, error) {
+		return &netatmo.DeviceCollection{}, nil
+	}, 0, 0)
+	c.RefreshData(time.Unix(0, 0))
+
+	var entry *logrus.Entry
+	for _, e := range hook.AllEntries() {
+		if e.Level == logrus.WarnLevel {
+			entry = e
+		}
+	}
+	if entry == nil {
+		t.Fatal("no warning written")
+	}
+
+	if c.deviceCount != 0 {
+		t.Errorf("got device count %d, want 0", c.deviceCount)
+	}
+}
+
+func TestNetatmoCollector_CollectPanic(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Broken",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "06:00:00:00:00:01",
+            "module_name": "Wind",
+            "type": "NAModule2",
+            "dashboard_data": {"time_utc": 3500, "GustStrength": 20}
+          }
+        ]
+      },
+      {
+        "_id": "70:ee:50:00:00:02",
+        "station_name": "Working",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 22}
+      }
+    ]
+  }
+}`
+
+	log, hook := test.NewNullLogger()
+	c := newTestCollector(t, body)
+	c.Log = log
+	c.DetailsFunction = func(id string) (api.DeviceDetails, bool) {
+		if id == "06:00:00:00:00:01" {
+			panic("test panic")
+		}
+		return api.DeviceDetails{}, false
+	}
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_collect_panics_total Total number of devices whose metrics were not completely exported, because collecting them caused a panic.
+# TYPE netatmo_collect_panics_total counter
+netatmo_collect_panics_total 1
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Indoor",station="Broken"} 21
+netatmo_sensor_temperature_celsius{home="",module="Indoor",station="Working"} 22
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_collect_panics_total", "netatmo_sensor_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
+
+	var entry *logrus.Entry
+	for _, e := range hook.AllEntries() {
+		if e.Level == logrus.ErrorLevel {
+			entry = e
+		}
+	}
+	if entry == nil {
+		t.Fatal("no error written")
+	}
+
+	if device := entry.Data["device"]; device != "70:ee:50:00:00:01" {
+		t.Errorf("got device %q, want %q", device, "70:ee:50:00:00:01")
+	}
+}
+
+func TestNetatmoCollector_CollectFailOnError(t *testing.T) {
+	testError := errors.New("test error")
+	for _, failOnError := range []bool{false, true} {
+		c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+			return nil, testError
+		}, time.Hour, time.Hour)
+		c.FailOnError = failOnError
+		c.RefreshData(time.Now())
+
+		registry := prometheus.NewPedanticRegistry()
+		registry.MustRegister(c)
+
+		_, err := registry.Gather()
+		if gotErr := err != nil; gotErr != failOnError {
+			t.Errorf("with FailOnError %v got error %v", failOnError, err)
+		}
+	}
+}
+
+func TestNetatmoCollector_CollectStartupGrace(t *testing.T) {
+	now := time.Unix(3600, 0)
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return nil, errors.New("network not ready")
+	}, time.Hour, time.Hour)
+	c.clock = func() time.Time {
+		return now
+	}
+	c.startTime = now
+	c.StartupGrace = time.Minute
+	c.RefreshData(now)
+
+	expected := strings.NewReader(`# HELP netatmo_warming_up One during the startup grace period, while netatmo_up is not reported because no data has been read yet. Zero otherwise.
+# TYPE netatmo_warming_up gauge
+netatmo_warming_up 1
+`)
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_up", "netatmo_warming_up"); err != nil {
+		t.Errorf("during grace period: %s", err)
+	}
+
+	now = now.Add(time.Minute)
+	expected = strings.NewReader(`# HELP netatmo_up Zero if there was an error during the last refresh try.
+# TYPE netatmo_up gauge
+netatmo_up 0
+# HELP netatmo_warming_up One during the startup grace period, while netatmo_up is not reported because no data has been read yet. Zero otherwise.
+# TYPE netatmo_warming_up gauge
+netatmo_warming_up 0
+`)
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_up", "netatmo_warming_up"); err != nil {
+		t.Errorf("after grace period: %s", err)
+	}
+}
+
 func TestNetatmoCollector_Collect(t *testing.T) {
 	testDevices := &netatmo.DeviceCollection{}
 	testDevices.Body.Devices = []*netatmo.Device{
@@ -170,48 +619,172 @@ func TestNetatmoCollector_Collect(t *testing.T) {
 		{
 			desc: "success, no data",
 			data: &netatmo.DeviceCollection{},
-			wantMetrics: `# HELP netatmo_cache_updated_time Contains the time of the cached data.
+			wantMetrics: `# HELP netatmo_cache_age_seconds Contains the age of the cached data in seconds. Only present once data has been cached.
+# TYPE netatmo_cache_age_seconds gauge
+netatmo_cache_age_seconds 0
+# HELP netatmo_cache_served_total Total number of scrapes which were served from the cache without triggering a refresh.
+# TYPE netatmo_cache_served_total counter
+netatmo_cache_served_total 1
+# HELP netatmo_cache_updated_time Contains the time the cached station data was read.
 		# TYPE netatmo_cache_updated_time gauge
 		netatmo_cache_updated_time 3600
+		# HELP netatmo_collect_panics_total Total number of devices whose metrics were not completely exported, because collecting them caused a panic.
+		# TYPE netatmo_collect_panics_total counter
+		netatmo_collect_panics_total 0
+		# HELP netatmo_consecutive_refresh_failures Number of refreshes which failed in a row. Reset to zero by a successful refresh.
+		# TYPE netatmo_consecutive_refresh_failures gauge
+		netatmo_consecutive_refresh_failures 0
+		# HELP netatmo_data_completeness_ratio Fraction of all modules which provided fresh data during the last refresh. One if there are no modules. Computed by the exporter.
+		# TYPE netatmo_data_completeness_ratio gauge
+		netatmo_data_completeness_ratio 1
+		# HELP netatmo_devices_total Number of stations returned by the last successful refresh. Zero means the account does not contain any stations.
+		# TYPE netatmo_devices_total gauge
+		netatmo_devices_total 0
+		# HELP netatmo_last_error_info One if there was an error during the last refresh try, zero otherwise. The reason label contains the category of the error.
+		# TYPE netatmo_last_error_info gauge
+		netatmo_last_error_info{reason=""} 0
+		# HELP netatmo_last_scrape_duration_seconds Contains the time it took to complete the previous scrape.
+		# TYPE netatmo_last_scrape_duration_seconds gauge
+		netatmo_last_scrape_duration_seconds 0
 		# HELP netatmo_last_refresh_duration_seconds Contains the time it took for the last refresh to complete, even if it was unsuccessful.
 		# TYPE netatmo_last_refresh_duration_seconds gauge
 		netatmo_last_refresh_duration_seconds 0
 		# HELP netatmo_last_refresh_time Contains the time of the last refresh try, successful or not.
 		# TYPE netatmo_last_refresh_time gauge
 		netatmo_last_refresh_time 3600
+		# HELP netatmo_next_refresh_time Contains the time after which the next scrape will refresh the cached data. Contains the current time while a refresh is running.
+		# TYPE netatmo_next_refresh_time gauge
+		netatmo_next_refresh_time 7200
 		# HELP netatmo_refresh_interval_seconds Contains the configured refresh interval in seconds. This is provided as a convenience for calculations with the cache update time.
 		# TYPE netatmo_refresh_interval_seconds gauge
 		netatmo_refresh_interval_seconds 3600
+		# HELP netatmo_refresh_triggered_total Total number of scrapes which triggered a refresh of the cached data.
+		# TYPE netatmo_refresh_triggered_total counter
+		netatmo_refresh_triggered_total 0
+		# HELP netatmo_scrapes_total Total number of scrapes of the exporter.
+		# TYPE netatmo_scrapes_total counter
+		netatmo_scrapes_total 1
+		# HELP netatmo_serving_stale_cache One if the cached data is older than the time between refreshes including the jitter, for example because a refresh is running or failed. Only present once data has been cached. Computed by the exporter.
+		# TYPE netatmo_serving_stale_cache gauge
+		netatmo_serving_stale_cache 0
+		# HELP netatmo_stale_modules_total Number of modules with data older than the stale threshold. Modules without any data are not counted. Computed by the exporter.
+		# TYPE netatmo_stale_modules_total gauge
+		netatmo_stale_modules_total 0
 		# HELP netatmo_up Zero if there was an error during the last refresh try.
 		# TYPE netatmo_up gauge
 		netatmo_up 1
+		# HELP netatmo_warming_up One during the startup grace period, while netatmo_up is not reported because no data has been read yet. Zero otherwise.
+		# TYPE netatmo_warming_up gauge
+		netatmo_warming_up 0
 		`,
 		},
 		{
 			desc: "success",
 			data: testDevices,
-			wantMetrics: `# HELP netatmo_cache_updated_time Contains the time of the cached data.
+			wantMetrics: `# HELP netatmo_cache_age_seconds Contains the age of the cached data in seconds. Only present once data has been cached.
+# TYPE netatmo_cache_age_seconds gauge
+netatmo_cache_age_seconds 0
+# HELP netatmo_cache_served_total Total number of scrapes which were served from the cache without triggering a refresh.
+# TYPE netatmo_cache_served_total counter
+netatmo_cache_served_total 1
+# HELP netatmo_cache_updated_time Contains the time the cached station data was read.
 # TYPE netatmo_cache_updated_time gauge
 netatmo_cache_updated_time 3600
+# HELP netatmo_collect_panics_total Total number of devices whose metrics were not completely exported, because collecting them caused a panic.
+# TYPE netatmo_collect_panics_total counter
+netatmo_collect_panics_total 0
+# HELP netatmo_consecutive_refresh_failures Number of refreshes which failed in a row. Reset to zero by a successful refresh.
+# TYPE netatmo_consecutive_refresh_failures gauge
+netatmo_consecutive_refresh_failures 0
+# HELP netatmo_data_completeness_ratio Fraction of all modules which provided fresh data during the last refresh. One if there are no modules. Computed by the exporter.
+# TYPE netatmo_data_completeness_ratio gauge
+netatmo_data_completeness_ratio 1
+# HELP netatmo_devices_total Number of stations returned by the last successful refresh. Zero means the account does not contain any stations.
+# TYPE netatmo_devices_total gauge
+netatmo_devices_total 1
+# HELP netatmo_last_error_info One if there was an error during the last refresh try, zero otherwise. The reason label contains the category of the error.
+# TYPE netatmo_last_error_info gauge
+netatmo_last_error_info{reason=""} 0
+# HELP netatmo_last_scrape_duration_seconds Contains the time it took to complete the previous scrape.
+# TYPE netatmo_last_scrape_duration_seconds gauge
+netatmo_last_scrape_duration_seconds 0
 # HELP netatmo_last_refresh_duration_seconds Contains the time it took for the last refresh to complete, even if it was unsuccessful.
 # TYPE netatmo_last_refresh_duration_seconds gauge
 netatmo_last_refresh_duration_seconds 0
 # HELP netatmo_last_refresh_time Contains the time of the last refresh try, successful or not.
 # TYPE netatmo_last_refresh_time gauge
 netatmo_last_refresh_time 3600
+# HELP netatmo_module_info Contains information about all known modules, even if they do not have fresh data. Value is always 1.
+# TYPE netatmo_module_info gauge
+netatmo_module_info{home="Home",module="Bedroom",station="Home (Living Room)",type="NAModule4"} 1
+netatmo_module_info{home="Home",module="Living Room",station="Home (Living Room)",type="NAMain"} 1
+netatmo_module_info{home="Home",module="Outside",station="Home (Living Room)",type="NAModule1"} 1
+netatmo_module_info{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)",type="NAModule4"} 1
+# HELP netatmo_module_is_main One if the module is the main device of the station, zero for modules linked to the station.
+# TYPE netatmo_module_is_main gauge
+netatmo_module_is_main{home="Home",module="Bedroom",station="Home (Living Room)"} 0
+netatmo_module_is_main{home="Home",module="Living Room",station="Home (Living Room)"} 1
+netatmo_module_is_main{home="Home",module="Outside",station="Home (Living Room)"} 0
+netatmo_module_is_main{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 0
+# HELP netatmo_module_last_seen_time Contains the time of the most recent measurement of a module, even if the data is stale.
+# TYPE netatmo_module_last_seen_time gauge
+netatmo_module_last_seen_time{home="Home",module="Bedroom",station="Home (Living Room)"} 3502
+netatmo_module_last_seen_time{home="Home",module="Living Room",station="Home (Living Room)"} 3500
+netatmo_module_last_seen_time{home="Home",module="Outside",station="Home (Living Room)"} 3501
+netatmo_module_last_seen_time{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 3503
+# HELP netatmo_next_refresh_time Contains the time after which the next scrape will refresh the cached data. Contains the current time while a refresh is running.
+# TYPE netatmo_next_refresh_time gauge
+netatmo_next_refresh_time 7200
 # HELP netatmo_refresh_interval_seconds Contains the configured refresh interval in seconds. This is provided as a convenience for calculations with the cache update time.
 # TYPE netatmo_refresh_interval_seconds gauge
 netatmo_refresh_interval_seconds 3600
+# HELP netatmo_refresh_triggered_total Total number of scrapes which triggered a refresh of the cached data.
+# TYPE netatmo_refresh_triggered_total counter
+netatmo_refresh_triggered_total 0
+# HELP netatmo_scrapes_total Total number of scrapes of the exporter.
+# TYPE netatmo_scrapes_total counter
+netatmo_scrapes_total 1
+# HELP netatmo_sensor_absolute_pressure_mb Atmospheric pressure measurement in millibar at the altitude of the station
+# TYPE netatmo_sensor_absolute_pressure_mb gauge
+netatmo_sensor_absolute_pressure_mb{home="Home",module="Living Room",station="Home (Living Room)"} 987
+# HELP netatmo_sensor_apparent_temperature_celsius Apparent temperature in celsius. Heat index (Rothfusz) at or above 26.7°C, wind chill (Environment Canada) at or below 10°C with wind above 4.8 km/h, otherwise the measured temperature. Computed by the exporter.
+# TYPE netatmo_sensor_apparent_temperature_celsius gauge
+netatmo_sensor_apparent_temperature_celsius{home="Home",module="Bedroom",station="Home (Living Room)"} 17
+netatmo_sensor_apparent_temperature_celsius{home="Home",module="Living Room",station="Home (Living Room)"} 23
+netatmo_sensor_apparent_temperature_celsius{home="Home",module="Outside",station="Home (Living Room)"} 5
+netatmo_sensor_apparent_temperature_celsius{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 23
 # HELP netatmo_sensor_battery_percent Battery remaining life (10: low)
 # TYPE netatmo_sensor_battery_percent gauge
 netatmo_sensor_battery_percent{home="Home",module="Bedroom",station="Home (Living Room)"} 55
 netatmo_sensor_battery_percent{home="Home",module="Outside",station="Home (Living Room)"} 70
 netatmo_sensor_battery_percent{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 60
+# HELP netatmo_sensor_clock_skew_seconds Difference between the time of the most recent measurement and the time of the exporter in seconds. Positive values mean the measurement is in the future. Computed by the exporter.
+# TYPE netatmo_sensor_clock_skew_seconds gauge
+netatmo_sensor_clock_skew_seconds{home="Home",module="Bedroom",station="Home (Living Room)"} -98
+netatmo_sensor_clock_skew_seconds{home="Home",module="Living Room",station="Home (Living Room)"} -100
+netatmo_sensor_clock_skew_seconds{home="Home",module="Outside",station="Home (Living Room)"} -99
+netatmo_sensor_clock_skew_seconds{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} -97
+# HELP netatmo_sensor_co2_alert One if the carbondioxide measurement is above the alert threshold, zero otherwise. Computed by the exporter.
+# TYPE netatmo_sensor_co2_alert gauge
+netatmo_sensor_co2_alert{home="Home",module="Bedroom",station="Home (Living Room)"} 0
+netatmo_sensor_co2_alert{home="Home",module="Living Room",station="Home (Living Room)"} 0
+netatmo_sensor_co2_alert{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 0
 # HELP netatmo_sensor_co2_ppm Carbondioxide measurement in parts per million
 # TYPE netatmo_sensor_co2_ppm gauge
 netatmo_sensor_co2_ppm{home="Home",module="Bedroom",station="Home (Living Room)"} 510
 netatmo_sensor_co2_ppm{home="Home",module="Living Room",station="Home (Living Room)"} 650
 netatmo_sensor_co2_ppm{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 750
+# HELP netatmo_sensor_expected_next_report_time Time when the next measurement of the module is expected, based on the shortest observed interval between measurements. Computed by the exporter.
+# TYPE netatmo_sensor_expected_next_report_time gauge
+netatmo_sensor_expected_next_report_time{home="Home",module="Bedroom",station="Home (Living Room)"} 4102
+netatmo_sensor_expected_next_report_time{home="Home",module="Living Room",station="Home (Living Room)"} 4100
+netatmo_sensor_expected_next_report_time{home="Home",module="Outside",station="Home (Living Room)"} 4101
+netatmo_sensor_expected_next_report_time{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 4103
+# HELP netatmo_sensor_humidity_comfort Humidity compared to the comfortable range. -1 means too dry, 0 comfortable and 1 too humid. Not available for outdoor modules. Computed by the exporter.
+# TYPE netatmo_sensor_humidity_comfort gauge
+netatmo_sensor_humidity_comfort{home="Home",module="Bedroom",station="Home (Living Room)"} 0
+netatmo_sensor_humidity_comfort{home="Home",module="Living Room",station="Home (Living Room)"} 0
+netatmo_sensor_humidity_comfort{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 1
 # HELP netatmo_sensor_humidity_percent Relative humidity measurement in percent
 # TYPE netatmo_sensor_humidity_percent gauge
 netatmo_sensor_humidity_percent{home="Home",module="Bedroom",station="Home (Living Room)"} 52
@@ -221,9 +794,19 @@ netatmo_sensor_humidity_percent{home="Home",module="id-aa:bb:cc:dd:ee:f3",statio
 # HELP netatmo_sensor_noise_db Noise measurement in decibels
 # TYPE netatmo_sensor_noise_db gauge
 netatmo_sensor_noise_db{home="Home",module="Living Room",station="Home (Living Room)"} 40
-# HELP netatmo_sensor_pressure_mb Atmospheric pressure measurement in millibar
+# HELP netatmo_sensor_pressure_mb Atmospheric pressure measurement in millibar, reduced to sea level by NetAtmo using the altitude of the station
 # TYPE netatmo_sensor_pressure_mb gauge
 netatmo_sensor_pressure_mb{home="Home",module="Living Room",station="Home (Living Room)"} 1234
+# HELP netatmo_sensor_rf_quality RF signal quality using the configured thresholds (0: bad, 1: average, 2: good). Computed by the exporter.
+# TYPE netatmo_sensor_rf_quality gauge
+netatmo_sensor_rf_quality{home="Home",module="Bedroom",station="Home (Living Room)"} 1
+netatmo_sensor_rf_quality{home="Home",module="Outside",station="Home (Living Room)"} 2
+netatmo_sensor_rf_quality{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 1
+# HELP netatmo_sensor_rf_quality_percent RF signal quality in percent (0: lowest, 100: highest), derived from the RF signal strength. Computed by the exporter.
+# TYPE netatmo_sensor_rf_quality_percent gauge
+netatmo_sensor_rf_quality_percent{home="Home",module="Bedroom",station="Home (Living Room)"} 33.33333333333333
+netatmo_sensor_rf_quality_percent{home="Home",module="Outside",station="Home (Living Room)"} 100
+netatmo_sensor_rf_quality_percent{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 66.66666666666666
 # HELP netatmo_sensor_rf_signal_strength RF signal strength (90: lowest, 60: highest)
 # TYPE netatmo_sensor_rf_signal_strength gauge
 netatmo_sensor_rf_signal_strength{home="Home",module="Bedroom",station="Home (Living Room)"} 80
@@ -235,18 +818,48 @@ netatmo_sensor_temperature_celsius{home="Home",module="Bedroom",station="Home (L
 netatmo_sensor_temperature_celsius{home="Home",module="Living Room",station="Home (Living Room)"} 23
 netatmo_sensor_temperature_celsius{home="Home",module="Outside",station="Home (Living Room)"} 5
 netatmo_sensor_temperature_celsius{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 23
-# HELP netatmo_sensor_updated Timestamp of last update
+# HELP netatmo_sensor_updated Timestamp of last update. Deprecated, use netatmo_module_last_seen_time instead, which is also available for stale data.
 # TYPE netatmo_sensor_updated gauge
 netatmo_sensor_updated{home="Home",module="Bedroom",station="Home (Living Room)"} 3502
 netatmo_sensor_updated{home="Home",module="Living Room",station="Home (Living Room)"} 3500
 netatmo_sensor_updated{home="Home",module="Outside",station="Home (Living Room)"} 3501
 netatmo_sensor_updated{home="Home",module="id-aa:bb:cc:dd:ee:f3",station="Home (Living Room)"} 3503
+# HELP netatmo_sensor_wifi_quality Wifi signal quality using the configured thresholds (0: bad, 1: average, 2: good). Computed by the exporter.
+# TYPE netatmo_sensor_wifi_quality gauge
+netatmo_sensor_wifi_quality{home="Home",module="Living Room",station="Home (Living Room)"} 2
+# HELP netatmo_sensor_wifi_quality_percent Wifi signal quality in percent (0: bad, 100: good), derived from the wifi signal strength. Computed by the exporter.
+# TYPE netatmo_sensor_wifi_quality_percent gauge
+netatmo_sensor_wifi_quality_percent{home="Home",module="Living Room",station="Home (Living Room)"} 100
 # HELP netatmo_sensor_wifi_signal_strength Wifi signal strength (86: bad, 71: avg, 56: good)
 # TYPE netatmo_sensor_wifi_signal_strength gauge
 netatmo_sensor_wifi_signal_strength{home="Home",module="Living Room",station="Home (Living Room)"} 45
+# HELP netatmo_serving_stale_cache One if the cached data is older than the time between refreshes including the jitter, for example because a refresh is running or failed. Only present once data has been cached. Computed by the exporter.
+# TYPE netatmo_serving_stale_cache gauge
+netatmo_serving_stale_cache 0
+# HELP netatmo_stale_modules_total Number of modules with data older than the stale threshold. Modules without any data are not counted. Computed by the exporter.
+# TYPE netatmo_stale_modules_total gauge
+netatmo_stale_modules_total 0
+# HELP netatmo_station_indoor_outdoor_temperature_delta_celsius Difference between the temperature of the main module and the outdoor module of the station in celsius. Only available if both modules have fresh data. Computed by the exporter.
+# TYPE netatmo_station_indoor_outdoor_temperature_delta_celsius gauge
+netatmo_station_indoor_outdoor_temperature_delta_celsius{home="Home",station="Home (Living Room)"} 18
+# HELP netatmo_station_mean_temperature_celsius Average temperature of all modules of the station with fresh data in celsius. Computed by the exporter.
+# TYPE netatmo_station_mean_temperature_celsius gauge
+netatmo_station_mean_temperature_celsius{home="Home",station="Home (Living Room)"} 17
+# HELP netatmo_station_data_completeness_ratio Fraction of the modules of the station which provided fresh data during the last refresh. Computed by the exporter.
+# TYPE netatmo_station_data_completeness_ratio gauge
+netatmo_station_data_completeness_ratio{home="Home",station="Home (Living Room)"} 1
+# HELP netatmo_station_module_count Number of modules linked to the station in the data of the last successful refresh.
+# TYPE netatmo_station_module_count gauge
+netatmo_station_module_count{home="Home",station="Home (Living Room)"} 3
+# HELP netatmo_station_up One if the station and all its modules provided fresh data during the last refresh, zero otherwise.
+# TYPE netatmo_station_up gauge
+netatmo_station_up{home="Home",station="Home (Living Room)"} 1
 # HELP netatmo_up Zero if there was an error during the last refresh try.
 # TYPE netatmo_up gauge
 netatmo_up 1
+# HELP netatmo_warming_up One during the startup grace period, while netatmo_up is not reported because no data has been read yet. Zero otherwise.
+# TYPE netatmo_warming_up gauge
+netatmo_warming_up 0
 `,
 		},
 	}
@@ -276,14 +889,1898 @@ netatmo_up 1
 	}
 }
 
-func int32Ptr(i int32) *int32 {
-	return &i
+func TestNetatmoCollector_CollectDuplicateModule(t *testing.T) {
+	sharedModule := &netatmo.Device{
+		ID:         "aa:bb:cc:dd:ee:f1",
+		ModuleName: "Outside",
+		Type:       "NAModule1",
+		DashboardData: netatmo.DashboardData{
+			Temperature: float32Ptr(5),
+			LastMeasure: int64Ptr(3500),
+		},
+	}
+	testDevices := &netatmo.DeviceCollection{}
+	testDevices.Body.Devices = []*netatmo.Device{
+		{
+			ID:            "aa:bb:cc:dd:ee:f0",
+			ModuleName:    "Living Room",
+			StationName:   "First",
+			Type:          "NAMain",
+			LinkedModules: []*netatmo.Device{sharedModule},
+		},
+		{
+			ID:            "aa:bb:cc:dd:ee:e0",
+			ModuleName:    "Office",
+			StationName:   "Second",
+			Type:          "NAMain",
+			LinkedModules: []*netatmo.Device{sharedModule},
+		},
+	}
+
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+	read := func() (*netatmo.DeviceCollection, error) {
+		return testDevices, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	c.clock = mockClock
+	c.RefreshData(mockClock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Outside",station="First"} 5
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
 }
 
-func int64Ptr(i int64) *int64 {
-	return &i
+func TestNetatmoCollector_CollectMissingDashboardData(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "New Module",
+            "type": "NAModule1",
+            "dashboard_data": null
+          },
+          null
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.RefreshData(c.clock())
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_module_info Contains information about all known modules, even if they do not have fresh data. Value is always 1.
+# TYPE netatmo_module_info gauge
+netatmo_module_info{home="",module="Indoor",station="Home",type="NAMain"} 1
+netatmo_module_info{home="",module="New Module",station="Home",type="NAModule1"} 1
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Indoor",station="Home"} 21
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_module_info", "netatmo_sensor_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
 }
 
-func float32Ptr(f float32) *float32 {
-	return &f
+func TestNetatmoCollector_CollectStaleModules(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 100, "Temperature": 5}
+          },
+          {
+            "_id": "02:00:00:00:00:02",
+            "module_name": "New Module",
+            "type": "NAModule1",
+            "dashboard_data": null
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_stale_modules_total Number of modules with data older than the stale threshold. Modules without any data are not counted. Computed by the exporter.
+# TYPE netatmo_stale_modules_total gauge
+netatmo_stale_modules_total 1
+# HELP netatmo_station_mean_temperature_celsius Average temperature of all modules of the station with fresh data in celsius. Computed by the exporter.
+# TYPE netatmo_station_mean_temperature_celsius gauge
+netatmo_station_mean_temperature_celsius{home="",station="Home"} 21
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_stale_modules_total", "netatmo_station_mean_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectDataCompleteness(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 100, "Temperature": 5}
+          },
+          {
+            "_id": "02:00:00:00:00:02",
+            "module_name": "New Module",
+            "type": "NAModule1",
+            "dashboard_data": null
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_data_completeness_ratio Fraction of all modules which provided fresh data during the last refresh. One if there are no modules. Computed by the exporter.
+# TYPE netatmo_data_completeness_ratio gauge
+netatmo_data_completeness_ratio 0.3333333333333333
+# HELP netatmo_station_data_completeness_ratio Fraction of the modules of the station which provided fresh data during the last refresh. Computed by the exporter.
+# TYPE netatmo_station_data_completeness_ratio gauge
+netatmo_station_data_completeness_ratio{home="",station="Home"} 0.3333333333333333
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_data_completeness_ratio",
+		"netatmo_station_data_completeness_ratio"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectModuleCount(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 5}
+          },
+          {
+            "_id": "02:00:00:00:00:02",
+            "module_name": "Removed",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 6}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.RefreshData(c.clock())
+
+	// The removed module is kept in the cache, because its data is not stale yet.
+	c.ReadFunction = func() (*netatmo.DeviceCollection, error) {
+		var devices netatmo.DeviceCollection
+		if err := json.Unmarshal([]byte(body), &devices); err != nil {
+			return nil, err
+		}
+
+		station := devices.Devices()[0]
+		station.LinkedModules = station.LinkedModules[:1]
+		return &devices, nil
+	}
+	c.RefreshData(c.clock().Add(time.Minute))
+
+	expected := strings.NewReader(`# HELP netatmo_station_module_count Number of modules linked to the station in the data of the last successful refresh.
+# TYPE netatmo_station_module_count gauge
+netatmo_station_module_count{home="",station="Home"} 1
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_station_module_count"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectIndoorOutdoorDelta(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21.5},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 4}
+          }
+        ]
+      },
+      {
+        "_id": "70:ee:50:00:00:02",
+        "station_name": "Cabin",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 18},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:02",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 100, "Temperature": -3}
+          }
+        ]
+      },
+      {
+        "_id": "70:ee:50:00:00:03",
+        "station_name": "Office",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 22}
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_station_indoor_outdoor_temperature_delta_celsius Difference between the temperature of the main module and the outdoor module of the station in celsius. Only available if both modules have fresh data. Computed by the exporter.
+# TYPE netatmo_station_indoor_outdoor_temperature_delta_celsius gauge
+netatmo_station_indoor_outdoor_temperature_delta_celsius{home="",station="Home"} 17.5
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_station_indoor_outdoor_temperature_delta_celsius"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectFilteredReadings(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21, "Humidity": 140},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 0, "Humidity": 60}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.TemperatureLimits = Limits{Min: 5, Max: 50}
+	c.HumidityLimits = Limits{Min: 0, Max: 100}
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_filtered_readings_total Total number of readings which were not exported, because they were outside of the configured limits.
+# TYPE netatmo_filtered_readings_total counter
+netatmo_filtered_readings_total{metric="humidity"} 1
+netatmo_filtered_readings_total{metric="temperature"} 1
+# HELP netatmo_sensor_humidity_percent Relative humidity measurement in percent
+# TYPE netatmo_sensor_humidity_percent gauge
+netatmo_sensor_humidity_percent{home="",module="Outdoor",station="Home"} 60
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Indoor",station="Home"} 21
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_filtered_readings_total", "netatmo_sensor_humidity_percent", "netatmo_sensor_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectApparentTemperature(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 2, "Humidity": 50},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 2, "Humidity": 80}
+          },
+          {
+            "_id": "06:00:00:00:00:01",
+            "module_name": "Wind",
+            "type": "NAModule2",
+            "dashboard_data": {"time_utc": 3500, "WindStrength": 20}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_apparent_temperature_celsius Apparent temperature in celsius. Heat index (Rothfusz) at or above 26.7°C, wind chill (Environment Canada) at or below 10°C with wind above 4.8 km/h, otherwise the measured temperature. Computed by the exporter.
+# TYPE netatmo_sensor_apparent_temperature_celsius gauge
+netatmo_sensor_apparent_temperature_celsius{home="",module="Indoor",station="Home"} 2
+netatmo_sensor_apparent_temperature_celsius{home="",module="Outdoor",station="Home"} -2.7185510668639328
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_apparent_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectClockSkew(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3900, "Temperature": 21}
+      }
+    ]
+  }
+}`
+
+	log, hook := test.NewNullLogger()
+	c := newTestCollector(t, body)
+	c.Log = log
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_clock_skew_seconds Difference between the time of the most recent measurement and the time of the exporter in seconds. Positive values mean the measurement is in the future. Computed by the exporter.
+# TYPE netatmo_sensor_clock_skew_seconds gauge
+netatmo_sensor_clock_skew_seconds{home="",module="Indoor",station="Home"} 300
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Indoor",station="Home"} 21
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_clock_skew_seconds", "netatmo_sensor_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Measurement is in the future." {
+		t.Errorf("got log entry %v, want warning about the future measurement", entry)
+	}
+}
+
+func TestNetatmoCollector_CollectStationFilter(t *testing.T) {
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+	testDevices := &netatmo.DeviceCollection{}
+	testDevices.Body.Devices = []*netatmo.Device{
+		{
+			ID:          "70:ee:50:00:00:01",
+			StationName: "Home",
+			DashboardData: netatmo.DashboardData{
+				LastMeasure: int64Ptr(3500),
+			},
+		},
+		{
+			ID:          "70:ee:50:00:00:02",
+			StationName: "Office",
+			DashboardData: netatmo.DashboardData{
+				LastMeasure: int64Ptr(3500),
+			},
+		},
+	}
+
+	tt := []struct {
+		desc            string
+		includeStations []string
+		excludeStations []string
+		wantStations    []string
+	}{
+		{
+			desc:         "no filter",
+			wantStations: []string{"Home", "Office"},
+		},
+		{
+			desc:            "include by name",
+			includeStations: []string{"Home"},
+			wantStations:    []string{"Home"},
+		},
+		{
+			desc:            "exclude by id",
+			excludeStations: []string{"70:ee:50:00:00:01"},
+			wantStations:    []string{"Office"},
+		},
+		{
+			desc:            "exclude takes precedence",
+			includeStations: []string{"Home", "Office"},
+			excludeStations: []string{"Office"},
+			wantStations:    []string{"Home"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+				return testDevices, nil
+			}, time.Hour, time.Hour)
+			c.clock = mockClock
+			c.IncludeStations = tc.includeStations
+			c.ExcludeStations = tc.excludeStations
+			c.RefreshData(mockClock())
+
+			var expected strings.Builder
+			expected.WriteString("# HELP netatmo_station_up One if the station and all its modules provided fresh data during the last refresh, zero otherwise.\n")
+			expected.WriteString("# TYPE netatmo_station_up gauge\n")
+			for _, station := range tc.wantStations {
+				fmt.Fprintf(&expected, "netatmo_station_up{home=\"\",station=%q} 1\n", station)
+			}
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(expected.String()), "netatmo_station_up"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNetatmoCollector_CollectSingleRefresh(t *testing.T) {
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	read := func() (*netatmo.DeviceCollection, error) {
+		reads.Add(1)
+		<-release
+		return &netatmo.DeviceCollection{}, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	c.clock = mockClock
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testutil.CollectAndCount(c)
+		}()
+	}
+	wg.Wait()
+	close(release)
+
+	// Give a possible second refresh the chance to run.
+	time.Sleep(10 * time.Millisecond)
+
+	if got := reads.Load(); got != 1 {
+		t.Errorf("got %d reads, want 1", got)
+	}
+}
+
+func TestNetatmoCollector_CollectRefreshInProgress(t *testing.T) {
+	var now atomic.Int64
+	now.Store(3600)
+	mockClock := func() time.Time {
+		return time.Unix(now.Load(), 0)
+	}
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	done := make(chan struct{})
+	read := func() (*netatmo.DeviceCollection, error) {
+		defer func() {
+			done <- struct{}{}
+		}()
+
+		reads.Add(1)
+		<-release
+		return &netatmo.DeviceCollection{}, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	c.clock = mockClock
+
+	testutil.CollectAndCount(c)
+
+	// The refresh interval has passed, but the first refresh is still running.
+	now.Add(120)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testutil.CollectAndCount(c)
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+	<-done
+
+	if got := reads.Load(); got != 1 {
+		t.Errorf("got %d reads while refresh was running, want 1", got)
+	}
+
+	// Wait for the refresh to finish, a new refresh can be started afterwards.
+	for c.refreshing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	now.Add(120)
+	testutil.CollectAndCount(c)
+	<-done
+
+	if got := reads.Load(); got != 2 {
+		t.Errorf("got %d reads after refresh finished, want 2", got)
+	}
+}
+
+func TestNetatmoCollector_RefreshBlockingRead(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	var reads atomic.Int32
+	read := func() (*netatmo.DeviceCollection, error) {
+		reads.Add(1)
+		<-release
+		return &netatmo.DeviceCollection{}, nil
+	}
+
+	c := New(logrus.New(), read, 50*time.Millisecond, time.Hour)
+
+	for i := 1; i <= 2; i++ {
+		refreshed := make(chan error, 1)
+		go func() {
+			_, err := c.ForceRefresh()
+			refreshed <- err
+		}()
+
+		// The hanging read is abandoned after the refresh interval, so the following refresh can start.
+		select {
+		case err := <-refreshed:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got error %v in refresh %d, want deadline exceeded", err, i)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("refresh %d did not return", i)
+		}
+	}
+
+	if got := reads.Load(); got != 2 {
+		t.Errorf("got %d reads, want 2", got)
+	}
+}
+
+func TestNetatmoCollector_CollectRefreshCounters(t *testing.T) {
+	done := make(chan struct{}, 1)
+	read := func() (*netatmo.DeviceCollection, error) {
+		done <- struct{}{}
+		return &netatmo.DeviceCollection{}, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	c.clock = func() time.Time {
+		return time.Unix(3600, 0)
+	}
+
+	testutil.CollectAndCount(c)
+	<-done
+	for c.refreshing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	testutil.CollectAndCount(c)
+
+	expected := strings.NewReader(`# HELP netatmo_cache_served_total Total number of scrapes which were served from the cache without triggering a refresh.
+# TYPE netatmo_cache_served_total counter
+netatmo_cache_served_total 2
+# HELP netatmo_refresh_triggered_total Total number of scrapes which triggered a refresh of the cached data.
+# TYPE netatmo_refresh_triggered_total counter
+netatmo_refresh_triggered_total 1
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_cache_served_total", "netatmo_refresh_triggered_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_InitialRefreshRetry(t *testing.T) {
+	tt := []struct {
+		desc      string
+		failures  int
+		wantReads int32
+		wantReady bool
+	}{
+		{
+			desc:      "success after retry",
+			failures:  2,
+			wantReads: 3,
+			wantReady: true,
+		},
+		{
+			desc:      "retries exhausted",
+			failures:  10,
+			wantReads: initialRefreshRetries + 1,
+			wantReady: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var reads atomic.Int32
+			read := func() (*netatmo.DeviceCollection, error) {
+				if int(reads.Add(1)) <= tc.failures {
+					return nil, errors.New("network not ready")
+				}
+
+				return &netatmo.DeviceCollection{}, nil
+			}
+
+			c := New(logrus.New(), read, time.Minute, time.Hour)
+			c.clock = func() time.Time {
+				return time.Unix(3600, 0)
+			}
+			c.initialRetryDelay = time.Millisecond
+
+			testutil.CollectAndCount(c)
+			for !c.refreshing.CompareAndSwap(false, true) {
+				time.Sleep(time.Millisecond)
+			}
+
+			if got := reads.Load(); got != tc.wantReads {
+				t.Errorf("got %d reads, want %d", got, tc.wantReads)
+			}
+
+			c.cacheLock.RLock()
+			ready := c.hasCachedData()
+			c.cacheLock.RUnlock()
+			if ready != tc.wantReady {
+				t.Errorf("got ready %v, want %v", ready, tc.wantReady)
+			}
+		})
+	}
+}
+
+func TestNetatmoCollector_ReadRetries(t *testing.T) {
+	tt := []struct {
+		desc      string
+		failures  int
+		retries   int
+		interval  time.Duration
+		sleepErr  error
+		wantReads int32
+		wantErr   bool
+	}{
+		{
+			desc:      "no retries",
+			failures:  1,
+			interval:  time.Hour,
+			wantReads: 1,
+			wantErr:   true,
+		},
+		{
+			desc:      "success after retry",
+			failures:  2,
+			retries:   3,
+			interval:  time.Hour,
+			wantReads: 3,
+		},
+		{
+			desc:      "retries exhausted",
+			failures:  10,
+			retries:   3,
+			interval:  time.Hour,
+			wantReads: 4,
+			wantErr:   true,
+		},
+		{
+			desc:      "limited by refresh interval",
+			failures:  10,
+			retries:   3,
+			interval:  90 * time.Second,
+			wantReads: 2,
+			wantErr:   true,
+		},
+		{
+			desc:      "limited by refresh deadline",
+			failures:  10,
+			retries:   3,
+			interval:  time.Hour,
+			sleepErr:  context.DeadlineExceeded,
+			wantReads: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var reads atomic.Int32
+			read := func() (*netatmo.DeviceCollection, error) {
+				if int(reads.Add(1)) <= tc.failures {
+					return nil, errors.New("network not ready")
+				}
+
+				return &netatmo.DeviceCollection{}, nil
+			}
+
+			// Every call of the clock advances it by one minute.
+			var now atomic.Int64
+			c := New(logrus.New(), read, tc.interval, time.Hour)
+			c.clock = func() time.Time {
+				return time.Unix(now.Add(60), 0)
+			}
+			c.sleep = func(_ context.Context, d time.Duration) error {
+				if d != 5*time.Second {
+					t.Errorf("got sleep of %s, want %s", d, 5*time.Second)
+				}
+
+				return tc.sleepErr
+			}
+			c.ReadRetries = tc.retries
+			c.ReadRetryDelay = 5 * time.Second
+			c.RefreshData(c.clock())
+
+			if got := reads.Load(); got != tc.wantReads {
+				t.Errorf("got %d reads, want %d", got, tc.wantReads)
+			}
+
+			if _, err := c.refreshStatus(); (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, want error %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("got error %q, want none", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNetatmoCollector_Ready(t *testing.T) {
+	var reads atomic.Int32
+	done := make(chan struct{})
+	read := func() (*netatmo.DeviceCollection, error) {
+		if reads.Add(1) == 1 {
+			defer close(done)
+		}
+		return &netatmo.DeviceCollection{}, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	c.clock = func() time.Time {
+		return time.Unix(3600, 0)
+	}
+
+	if c.Ready() {
+		t.Error("collector is ready before first refresh")
+	}
+
+	if got := reads.Load(); got != 0 {
+		t.Errorf("got %d reads from readiness check, want none", got)
+	}
+
+	c.Start()
+	<-done
+	for c.refreshing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if !c.Ready() {
+		t.Error("collector is not ready after refresh")
+	}
+}
+
+func TestClaimRefreshJitter(t *testing.T) {
+	c := New(logrus.New(), nil, time.Minute, time.Hour)
+	c.RefreshJitter = 30 * time.Second
+	c.randomDuration = func(max time.Duration) time.Duration {
+		return max
+	}
+
+	tt := []struct {
+		time    time.Time
+		wantDue bool
+	}{
+		{time: time.Unix(1000, 0), wantDue: true},
+		{time: time.Unix(1060, 0), wantDue: false},
+		{time: time.Unix(1089, 0), wantDue: false},
+		{time: time.Unix(1090, 0), wantDue: true},
+		{time: time.Unix(1150, 0), wantDue: false},
+	}
+
+	for _, tc := range tt {
+		_, due := c.claimRefresh(tc.time)
+		if due != tc.wantDue {
+			t.Errorf("at %d got due %v, want %v", tc.time.Unix(), due, tc.wantDue)
+		}
+		c.refreshing.Store(false)
+	}
+}
+
+func TestNextRefresh(t *testing.T) {
+	c := New(logrus.New(), nil, time.Minute, time.Hour)
+	c.RefreshJitter = 30 * time.Second
+	c.randomDuration = func(max time.Duration) time.Duration {
+		return max
+	}
+
+	now := time.Unix(1000, 0)
+	c.claimRefresh(now)
+	if got, want := c.nextRefresh(now), now; !got.Equal(want) {
+		t.Errorf("got next refresh %s while refreshing, want %s", got, want)
+	}
+
+	c.refreshing.Store(false)
+	if got, want := c.nextRefresh(now), time.Unix(1090, 0); !got.Equal(want) {
+		t.Errorf("got next refresh %s, want %s", got, want)
+	}
+}
+
+func TestNetatmoCollector_CollectHomeCoach(t *testing.T) {
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+
+	read := func() (*netatmo.DeviceCollection, error) {
+		return &netatmo.DeviceCollection{}, nil
+	}
+	readHomeCoaches := func() ([]*api.HomeCoach, error) {
+		return []*api.HomeCoach{
+			{
+				Device: netatmo.Device{
+					ID:          "70:ee:50:00:00:01",
+					ModuleName:  "Bedroom",
+					StationName: "Bedroom",
+					Type:        "NHC",
+					WifiStatus:  int32Ptr(50),
+					DashboardData: netatmo.DashboardData{
+						Temperature: float32Ptr(21),
+						Humidity:    int32Ptr(50),
+						CO2:         int32Ptr(800),
+						Noise:       int32Ptr(35),
+						Pressure:    float32Ptr(1012),
+						LastMeasure: int64Ptr(3500),
+					},
+				},
+				HealthIndex: int32Ptr(1),
+			},
+			{
+				Device: netatmo.Device{
+					ID:          "70:ee:50:00:00:02",
+					ModuleName:  "Office",
+					StationName: "Office",
+					Type:        "NHC",
+					DashboardData: netatmo.DashboardData{
+						Temperature: float32Ptr(20),
+						LastMeasure: int64Ptr(0),
+					},
+				},
+				HealthIndex: int32Ptr(3),
+			},
+		}, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, 30*time.Minute)
+	c.ReadHomeCoachFunction = readHomeCoaches
+	c.clock = mockClock
+	c.RefreshData(mockClock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_health_index Health index computed by the Healthy Home Coach (0: healthy, 1: fine, 2: fair, 3: poor, 4: unhealthy)
+# TYPE netatmo_sensor_health_index gauge
+netatmo_sensor_health_index{home="",module="Bedroom",station="Bedroom"} 1
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Bedroom",station="Bedroom"} 21
+# HELP netatmo_station_up One if the station and all its modules provided fresh data during the last refresh, zero otherwise.
+# TYPE netatmo_station_up gauge
+netatmo_station_up{home="",station="Bedroom"} 1
+netatmo_station_up{home="",station="Office"} 0
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_health_index", "netatmo_sensor_temperature_celsius", "netatmo_station_up"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectHomes(t *testing.T) {
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+	measured := 20.5
+	setpoint := 21.0
+	boilerOn := true
+
+	read := func() (*netatmo.DeviceCollection, error) {
+		return nil, errors.New("no weather station")
+	}
+	readHomes := func() ([]*api.Home, error) {
+		return []*api.Home{
+			{
+				ID:           "home1",
+				Name:         "Home",
+				ScheduleID:   "s2",
+				ScheduleName: "Winter",
+				Rooms: []*api.Room{
+					{
+						ID:                  "1",
+						Name:                "Living Room",
+						MeasuredTemperature: &measured,
+						SetpointTemperature: &setpoint,
+						HeatingPowerRequest: int32Ptr(40),
+						BoilerOn:            &boilerOn,
+					},
+					{
+						ID:                  "2",
+						Name:                "Hallway",
+						HeatingPowerRequest: int32Ptr(0),
+					},
+				},
+			},
+		}, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, 30*time.Minute)
+	c.clock = mockClock
+	c.ReadHomesFunction = readHomes
+	c.RefreshData(mockClock())
+
+	expected := strings.NewReader(`# HELP netatmo_home_schedule_info Contains the currently selected heating schedule of a home. Value is always 1.
+# TYPE netatmo_home_schedule_info gauge
+netatmo_home_schedule_info{home="Home",schedule="Winter",schedule_id="s2"} 1
+# HELP netatmo_room_heating_power_request Heating power requested by a room in percent
+# TYPE netatmo_room_heating_power_request gauge
+netatmo_room_heating_power_request{home="Home",room="Living Room"} 40
+# HELP netatmo_room_setpoint_celsius Target temperature of a room managed by NetAtmo Energy devices in celsius
+# TYPE netatmo_room_setpoint_celsius gauge
+netatmo_room_setpoint_celsius{home="Home",room="Living Room"} 21
+# HELP netatmo_room_temperature_celsius Temperature measured in a room managed by NetAtmo Energy devices in celsius
+# TYPE netatmo_room_temperature_celsius gauge
+netatmo_room_temperature_celsius{home="Home",room="Living Room"} 20.5
+# HELP netatmo_thermostat_boiler_on One if the thermostat in a room has turned on the boiler, zero otherwise
+# TYPE netatmo_thermostat_boiler_on gauge
+netatmo_thermostat_boiler_on{home="Home",room="Living Room"} 1
+# HELP netatmo_valve_open_percent Heating power requested by a room in percent, which corresponds to the opening of its valves
+# TYPE netatmo_valve_open_percent gauge
+netatmo_valve_open_percent{home="Home",room="Living Room"} 40
+`)
+
+	metricNames := []string{
+		"netatmo_home_schedule_info",
+		"netatmo_room_heating_power_request",
+		"netatmo_room_setpoint_celsius",
+		"netatmo_room_temperature_celsius",
+		"netatmo_thermostat_boiler_on",
+		"netatmo_valve_open_percent",
+	}
+	if err := testutil.CollectAndCompare(c, expected, metricNames...); err != nil {
+		t.Error(err)
+	}
+
+	// Room data is not exported anymore once it is stale.
+	c.clock = func() time.Time {
+		return time.Unix(3600+3600, 0)
+	}
+	c.lastRefresh = c.clock()
+	if count := testutil.CollectAndCount(c, metricNames...); count != 0 {
+		t.Errorf("got %d stale room metrics, want 0", count)
+	}
+}
+
+func TestNetatmoCollector_UpdatedIsGauge(t *testing.T) {
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+	testDevices := &netatmo.DeviceCollection{}
+	testDevices.Body.Devices = []*netatmo.Device{
+		{
+			ID:          "70:ee:50:00:00:01",
+			StationName: "Home",
+			DashboardData: netatmo.DashboardData{
+				LastMeasure: int64Ptr(3500),
+			},
+		},
+	}
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return testDevices, nil
+	}, time.Hour, time.Hour)
+	c.clock = mockClock
+	c.RefreshData(mockClock())
+
+	// The update time can stay the same or go backwards after a device reset, so it must not be a counter.
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("error gathering metrics: %s", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "netatmo_sensor_updated" {
+			continue
+		}
+
+		if family.GetType() != dto.MetricType_GAUGE {
+			t.Errorf("got type %s, want %s", family.GetType(), dto.MetricType_GAUGE)
+		}
+		return
+	}
+
+	t.Error("metric netatmo_sensor_updated not found")
+}
+
+func TestNetatmoCollector_CollectLastSeen(t *testing.T) {
+	tt := []struct {
+		desc        string
+		lastMeasure int64
+		want        string
+	}{
+		{
+			desc:        "fresh",
+			lastMeasure: 3500,
+			want: `# HELP netatmo_module_last_seen_time Contains the time of the most recent measurement of a module, even if the data is stale.
+# TYPE netatmo_module_last_seen_time gauge
+netatmo_module_last_seen_time{home="",module="Indoor",station="Home"} 3500
+# HELP netatmo_sensor_updated Timestamp of last update. Deprecated, use netatmo_module_last_seen_time instead, which is also available for stale data.
+# TYPE netatmo_sensor_updated gauge
+netatmo_sensor_updated{home="",module="Indoor",station="Home"} 3500
+`,
+		},
+		{
+			desc:        "stale",
+			lastMeasure: 100,
+			want: `# HELP netatmo_module_last_seen_time Contains the time of the most recent measurement of a module, even if the data is stale.
+# TYPE netatmo_module_last_seen_time gauge
+netatmo_module_last_seen_time{home="",module="Indoor",station="Home"} 100
+`,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			mockClock := func() time.Time {
+				return time.Unix(3600, 0)
+			}
+			testDevices := &netatmo.DeviceCollection{}
+			testDevices.Body.Devices = []*netatmo.Device{
+				{
+					ID:          "70:ee:50:00:00:01",
+					StationName: "Home",
+					ModuleName:  "Indoor",
+					DashboardData: netatmo.DashboardData{
+						LastMeasure: int64Ptr(tc.lastMeasure),
+					},
+				},
+			}
+
+			c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+				return testDevices, nil
+			}, time.Hour, 30*time.Minute)
+			c.clock = mockClock
+			c.RefreshData(mockClock())
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tc.want), "netatmo_module_last_seen_time", "netatmo_sensor_updated"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSignalQuality(t *testing.T) {
+	tt := []struct {
+		desc        string
+		value       int32
+		worst       int32
+		best        int32
+		wantQuality float64
+	}{
+		{
+			desc:        "wifi bad",
+			value:       wifiStrengthBad,
+			worst:       wifiStrengthBad,
+			best:        wifiStrengthGood,
+			wantQuality: 0,
+		},
+		{
+			desc:        "wifi worse than bad",
+			value:       95,
+			worst:       wifiStrengthBad,
+			best:        wifiStrengthGood,
+			wantQuality: 0,
+		},
+		{
+			desc:        "wifi average",
+			value:       71,
+			worst:       wifiStrengthBad,
+			best:        wifiStrengthGood,
+			wantQuality: 50,
+		},
+		{
+			desc:        "wifi good",
+			value:       wifiStrengthGood,
+			worst:       wifiStrengthBad,
+			best:        wifiStrengthGood,
+			wantQuality: 100,
+		},
+		{
+			desc:        "wifi better than good",
+			value:       40,
+			worst:       wifiStrengthBad,
+			best:        wifiStrengthGood,
+			wantQuality: 100,
+		},
+		{
+			desc:        "rf lowest",
+			value:       rfStrengthLowest,
+			worst:       rfStrengthLowest,
+			best:        rfStrengthHighest,
+			wantQuality: 0,
+		},
+		{
+			desc:        "rf middle",
+			value:       75,
+			worst:       rfStrengthLowest,
+			best:        rfStrengthHighest,
+			wantQuality: 50,
+		},
+		{
+			desc:        "rf highest",
+			value:       rfStrengthHighest,
+			worst:       rfStrengthLowest,
+			best:        rfStrengthHighest,
+			wantQuality: 100,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			quality := signalQuality(tc.value, tc.worst, tc.best)
+			if quality != tc.wantQuality {
+				t.Errorf("got quality %f, want %f", quality, tc.wantQuality)
+			}
+		})
+	}
+}
+
+func TestSendMetricError(t *testing.T) {
+	c := New(logrus.New(), nil, time.Minute, time.Hour)
+
+	ch := make(chan prometheus.Metric, 1)
+	c.sendMetric(ch, tempDesc, prometheus.GaugeValue, 21, "missing labels")
+	if len(ch) != 0 {
+		t.Error("invalid metric should not be sent")
+	}
+
+	expected := strings.NewReader(`# HELP netatmo_metric_errors_total Total number of metrics which were not exported, because they could not be created.
+# TYPE netatmo_metric_errors_total counter
+netatmo_metric_errors_total{metric="netatmo_sensor_temperature_celsius"} 1
+`)
+
+	if err := testutil.CollectAndCompare(c.metricErrors, expected); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSignalThresholdsCategory(t *testing.T) {
+	thresholds := SignalThresholds{Bad: 80, Good: 60}
+
+	tt := []struct {
+		value        int32
+		wantCategory float64
+	}{
+		{value: 90, wantCategory: 0},
+		{value: 80, wantCategory: 0},
+		{value: 79, wantCategory: 1},
+		{value: 61, wantCategory: 1},
+		{value: 60, wantCategory: 2},
+		{value: 40, wantCategory: 2},
+	}
+
+	for _, tc := range tt {
+		if got := thresholds.category(tc.value); got != tc.wantCategory {
+			t.Errorf("for %d got category %f, want %f", tc.value, got, tc.wantCategory)
+		}
+	}
+}
+
+func TestConvertTime(t *testing.T) {
+	tt := []struct {
+		desc       string
+		time       time.Time
+		wantTime   float64
+		wantMillis float64
+	}{
+		{
+			desc:       "zero",
+			time:       time.Time{},
+			wantTime:   0,
+			wantMillis: 0,
+		},
+		{
+			desc:       "whole seconds",
+			time:       time.Unix(3600, 0),
+			wantTime:   3600,
+			wantMillis: 3600,
+		},
+		{
+			desc:       "sub-second",
+			time:       time.Unix(3600, 250*int64(time.Millisecond)+999),
+			wantTime:   3600,
+			wantMillis: 3600.25,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if got := convertTime(tc.time); got != tc.wantTime {
+				t.Errorf("got time %f, want %f", got, tc.wantTime)
+			}
+
+			if got := convertTimeMillis(tc.time); got != tc.wantMillis {
+				t.Errorf("got millis %f, want %f", got, tc.wantMillis)
+			}
+		})
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	tt := []struct {
+		desc       string
+		err        error
+		wantReason string
+	}{
+		{
+			desc:       "not authenticated",
+			err:        netatmo.ErrNotAuthenticated,
+			wantReason: "not_authenticated",
+		},
+		{
+			desc:       "deadline",
+			err:        fmt.Errorf("wrapped: %w", context.DeadlineExceeded),
+			wantReason: "timeout",
+		},
+		{
+			desc: "token",
+			err: &url.Error{
+				Op:  "Get",
+				URL: "https://api.netatmo.net/api/getstationsdata",
+				Err: &oauth2.RetrieveError{},
+			},
+			wantReason: "token",
+		},
+		{
+			desc: "network",
+			err: &url.Error{
+				Op:  "Get",
+				URL: "https://api.netatmo.net/api/getstationsdata",
+				Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			},
+			wantReason: "network",
+		},
+		{
+			desc:       "decode",
+			err:        &json.SyntaxError{},
+			wantReason: "decode",
+		},
+		{
+			desc:       "other",
+			err:        errors.New("got error 403: invalid access token"),
+			wantReason: "api",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			reason := errorReason(tc.err)
+			if reason != tc.wantReason {
+				t.Errorf("got reason %q, want %q", reason, tc.wantReason)
+			}
+		})
+	}
+}
+
+// newTestCollector creates a collector, which reads the stations contained in body. Its clock is fixed at one hour after
+// the epoch, so measurements in body are fresh when they are a few minutes older.
+func newTestCollector(t *testing.T, body string) *NetatmoCollector {
+	t.Helper()
+
+	var devices netatmo.DeviceCollection
+	if err := json.Unmarshal([]byte(body), &devices); err != nil {
+		t.Fatalf("error decoding test data: %s", err)
+	}
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return &devices, nil
+	}, time.Minute, 30*time.Minute)
+	c.clock = func() time.Time {
+		return time.Unix(3600, 0)
+	}
+
+	return c
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func float32Ptr(f float32) *float32 {
+	return &f
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestForceRefresh(t *testing.T) {
+	now := time.Unix(3600, 0)
+	reads := 0
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		reads++
+		return &netatmo.DeviceCollection{}, nil
+	}, time.Hour, time.Hour)
+	c.clock = func() time.Time { return now }
+	c.RefreshData(now)
+
+	now = now.Add(time.Minute)
+	if _, err := c.ForceRefresh(); err != nil {
+		t.Fatalf("got error %q", err)
+	}
+
+	if reads != 2 {
+		t.Errorf("got %d reads, want 2", reads)
+	}
+
+	if next := c.nextRefresh(now); !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("got next refresh %s, want %s", next, now.Add(time.Hour))
+	}
+
+	c.refreshing.Store(true)
+	if _, err := c.ForceRefresh(); err != ErrRefreshRunning {
+		t.Errorf("got error %v, want %v", err, ErrRefreshRunning)
+	}
+}
+
+func TestCachedDevice(t *testing.T) {
+	devices := &netatmo.DeviceCollection{}
+	devices.Body.Devices = []*netatmo.Device{
+		{
+			ID:         "station",
+			ModuleName: "Indoor",
+			LinkedModules: []*netatmo.Device{
+				{
+					ID:         "outdoor",
+					ModuleName: "Outdoor",
+				},
+			},
+		},
+	}
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return devices, nil
+	}, time.Hour, time.Hour)
+	c.ReadHomeCoachFunction = func() ([]*api.HomeCoach, error) {
+		return []*api.HomeCoach{
+			{
+				Device: netatmo.Device{
+					ID:         "homecoach",
+					ModuleName: "Bedroom",
+				},
+			},
+		}, nil
+	}
+	c.RefreshData(time.Unix(3600, 0))
+
+	tt := []struct {
+		id         string
+		wantModule string
+		wantFound  bool
+	}{
+		{
+			id:         "station",
+			wantModule: "Indoor",
+			wantFound:  true,
+		},
+		{
+			id:         "outdoor",
+			wantModule: "Outdoor",
+			wantFound:  true,
+		},
+		{
+			id:         "homecoach",
+			wantModule: "Bedroom",
+			wantFound:  true,
+		},
+		{
+			id:        "unknown",
+			wantFound: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.id, func(t *testing.T) {
+			t.Parallel()
+
+			device, found := c.CachedDevice(tc.id)
+			if found != tc.wantFound {
+				t.Fatalf("got found %v, want %v", found, tc.wantFound)
+			}
+
+			if found && device.ModuleName != tc.wantModule {
+				t.Errorf("got module %q, want %q", device.ModuleName, tc.wantModule)
+			}
+		})
+	}
+}
+
+func TestPrecisionRound(t *testing.T) {
+	tt := []struct {
+		desc      string
+		precision Precision
+		value     float64
+		want      float64
+	}{
+		{
+			desc:      "no rounding",
+			precision: NoRounding,
+			value:     21.37,
+			want:      21.37,
+		},
+		{
+			desc:      "whole numbers",
+			precision: 0,
+			value:     1012.6,
+			want:      1013,
+		},
+		{
+			desc:      "one decimal",
+			precision: 1,
+			value:     21.37,
+			want:      21.4,
+		},
+		{
+			desc:      "negative value",
+			precision: 1,
+			value:     -3.25,
+			want:      -3.3,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.precision.round(tc.value); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNetatmoCollector_CollectRounding(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Indoor",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21.37, "Pressure": 1012.6, "Noise": 42},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 5.11}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.TemperaturePrecision = 1
+	c.PressurePrecision = 0
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_noise_db Noise measurement in decibels
+# TYPE netatmo_sensor_noise_db gauge
+netatmo_sensor_noise_db{home="",module="Indoor",station="Home"} 42
+# HELP netatmo_sensor_pressure_mb Atmospheric pressure measurement in millibar, reduced to sea level by NetAtmo using the altitude of the station
+# TYPE netatmo_sensor_pressure_mb gauge
+netatmo_sensor_pressure_mb{home="",module="Indoor",station="Home"} 1013
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Indoor",station="Home"} 21.4
+netatmo_sensor_temperature_celsius{home="",module="Outdoor",station="Home"} 5.1
+# HELP netatmo_station_indoor_outdoor_temperature_delta_celsius Difference between the temperature of the main module and the outdoor module of the station in celsius. Only available if both modules have fresh data. Computed by the exporter.
+# TYPE netatmo_station_indoor_outdoor_temperature_delta_celsius gauge
+netatmo_station_indoor_outdoor_temperature_delta_celsius{home="",station="Home"} 16.3
+# HELP netatmo_station_mean_temperature_celsius Average temperature of all modules of the station with fresh data in celsius. Computed by the exporter.
+# TYPE netatmo_station_mean_temperature_celsius gauge
+netatmo_station_mean_temperature_celsius{home="",station="Home"} 13.2
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_noise_db", "netatmo_sensor_pressure_mb", "netatmo_sensor_temperature_celsius",
+		"netatmo_station_indoor_outdoor_temperature_delta_celsius", "netatmo_station_mean_temperature_celsius"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectComfort(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Living Room",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "CO2": 1500, "Humidity": 30},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Humidity": 90}
+          },
+          {
+            "_id": "03:00:00:00:00:01",
+            "module_name": "Bedroom",
+            "type": "NAModule4",
+            "dashboard_data": {"time_utc": 3500, "CO2": 1200, "Humidity": 55}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	c.CO2AlertThreshold = 1400
+	c.HumidityComfort = Limits{Min: 35, Max: 50}
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_co2_alert One if the carbondioxide measurement is above the alert threshold, zero otherwise. Computed by the exporter.
+# TYPE netatmo_sensor_co2_alert gauge
+netatmo_sensor_co2_alert{home="",module="Bedroom",station="Home"} 0
+netatmo_sensor_co2_alert{home="",module="Living Room",station="Home"} 1
+# HELP netatmo_sensor_humidity_comfort Humidity compared to the comfortable range. -1 means too dry, 0 comfortable and 1 too humid. Not available for outdoor modules. Computed by the exporter.
+# TYPE netatmo_sensor_humidity_comfort gauge
+netatmo_sensor_humidity_comfort{home="",module="Bedroom",station="Home"} 1
+netatmo_sensor_humidity_comfort{home="",module="Living Room",station="Home"} -1
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_co2_alert", "netatmo_sensor_humidity_comfort"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatteryStatus(t *testing.T) {
+	tt := []struct {
+		moduleType string
+		voltage    int32
+		wantStatus float64
+		wantOK     bool
+	}{
+		{moduleType: "NAModule1", voltage: 6000, wantStatus: 4, wantOK: true},
+		{moduleType: "NAModule1", voltage: 5500, wantStatus: 4, wantOK: true},
+		{moduleType: "NAModule1", voltage: 5499, wantStatus: 3, wantOK: true},
+		{moduleType: "NAModule1", voltage: 4000, wantStatus: 1, wantOK: true},
+		{moduleType: "NAModule1", voltage: 3999, wantStatus: 0, wantOK: true},
+		{moduleType: "NAModule4", voltage: 5500, wantStatus: 3, wantOK: true},
+		{moduleType: "NAModule4", voltage: 4600, wantStatus: 1, wantOK: true},
+		{moduleType: "NAModule2", voltage: 4800, wantStatus: 2, wantOK: true},
+		{moduleType: "NAMain", voltage: 5000, wantOK: false},
+	}
+
+	for _, tc := range tt {
+		status, ok := batteryStatus(tc.moduleType, tc.voltage)
+		if ok != tc.wantOK || status != tc.wantStatus {
+			t.Errorf("for %s at %d mV got %f/%v, want %f/%v", tc.moduleType, tc.voltage, status, ok, tc.wantStatus, tc.wantOK)
+		}
+	}
+}
+
+func TestNetatmoCollector_CollectBatteryStatus(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Living Room",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 10}
+          },
+          {
+            "_id": "03:00:00:00:00:01",
+            "module_name": "Bedroom",
+            "type": "NAModule4",
+            "dashboard_data": {"time_utc": 3500, "Temperature": 19}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	// The bedroom module has no voltage, so neither the voltage nor the status is exported for it.
+	c.DetailsFunction = func(id string) (api.DeviceDetails, bool) {
+		if id == "02:00:00:00:00:01" {
+			return api.DeviceDetails{BatteryVoltage: int32Ptr(4700)}, true
+		}
+
+		return api.DeviceDetails{}, false
+	}
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_sensor_battery_millivolts Battery voltage of the module in millivolts
+# TYPE netatmo_sensor_battery_millivolts gauge
+netatmo_sensor_battery_millivolts{home="",module="Outdoor",station="Home"} 4700
+# HELP netatmo_sensor_battery_status Battery level derived from the battery voltage using the thresholds of the module type (0: very low, 1: low, 2: medium, 3: high, 4: full). Computed by the exporter.
+# TYPE netatmo_sensor_battery_status gauge
+netatmo_sensor_battery_status{home="",module="Outdoor",station="Home"} 2
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_sensor_battery_millivolts", "netatmo_sensor_battery_status"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectStationInfo(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "home_name": "Home",
+        "module_name": "Living Room",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21}
+      },
+      {
+        "_id": "70:ee:50:00:00:02",
+        "station_name": "Cabin",
+        "home_name": "Cabin",
+        "module_name": "Kitchen",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 18}
+      },
+      {
+        "_id": "70:ee:50:00:00:03",
+        "station_name": "Office",
+        "home_name": "Office",
+        "module_name": "Desk",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 22}
+      }
+    ]
+  }
+}`
+
+	c := newTestCollector(t, body)
+	// The cabin has no city and an unknown timezone and there are no details for the office.
+	places := map[string]*api.Place{
+		"70:ee:50:00:00:01": {Country: "DE", City: "Berlin", Timezone: "Europe/Berlin"},
+		"70:ee:50:00:00:02": {Country: "NO", Timezone: "Europe/Nowhere"},
+	}
+	c.DetailsFunction = func(id string) (api.DeviceDetails, bool) {
+		place, ok := places[id]
+		return api.DeviceDetails{Place: place}, ok
+	}
+	c.RefreshData(c.clock())
+
+	expected := strings.NewReader(`# HELP netatmo_station_info Contains the location of the station. The city is empty if NetAtmo does not know it. Value is always 1.
+# TYPE netatmo_station_info gauge
+netatmo_station_info{city="",country="NO",home="Cabin",station="Cabin",timezone="Europe/Nowhere"} 1
+netatmo_station_info{city="Berlin",country="DE",home="Home",station="Home",timezone="Europe/Berlin"} 1
+# HELP netatmo_station_timezone_offset_seconds Offset of the current local time at the station from UTC in seconds, including daylight saving time.
+# TYPE netatmo_station_timezone_offset_seconds gauge
+netatmo_station_timezone_offset_seconds{home="Home",station="Home"} 3600
+`)
+
+	if err := testutil.CollectAndCompare(c, expected, "netatmo_station_info", "netatmo_station_timezone_offset_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetatmoCollector_CollectSkipUnreachable(t *testing.T) {
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "module_name": "Living Room",
+        "type": "NAMain",
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 3000, "Temperature": 10}
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	tests := []struct {
+		name            string
+		skipUnreachable bool
+		wantMetrics     string
+	}{
+		{
+			name: "default",
+			wantMetrics: `# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Living Room",station="Home"} 21
+netatmo_sensor_temperature_celsius{home="",module="Outdoor",station="Home"} 10
+`,
+		},
+		{
+			name:            "skip unreachable",
+			skipUnreachable: true,
+			wantMetrics: `# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="",module="Living Room",station="Home"} 21
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestCollector(t, body)
+			c.SkipUnreachable = tt.skipUnreachable
+			c.DetailsFunction = func(id string) (api.DeviceDetails, bool) {
+				reachable := id != "02:00:00:00:00:01"
+				return api.DeviceDetails{Reachable: &reachable}, true
+			}
+			c.RefreshData(c.clock())
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.wantMetrics), "netatmo_sensor_temperature_celsius"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUnit(t *testing.T) {
+	unitSuffixes := []string{"_celsius", "_percent", "_ppm", "_db", "_mb", "_mm", "_kph", "_degrees", "_millivolts", "_ratio", "_seconds", "_time"}
+
+	c := New(logrus.New(), func() (*netatmo.DeviceCollection, error) {
+		return &netatmo.DeviceCollection{}, nil
+	}, time.Hour, 30*time.Minute)
+
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		defer close(descs)
+		c.Describe(descs)
+	}()
+
+	for desc := range descs {
+		name := MetricName(desc)
+		for _, suffix := range unitSuffixes {
+			if strings.HasSuffix(name, suffix) && Units[name] == "" {
+				t.Errorf("metric %s has no unit", name)
+			}
+		}
+	}
+
+	for _, name := range []string{prefix + "up", sensorPrefix + "battery_status", prefix + "station_info"} {
+		if unit := Units[name]; unit != "" {
+			t.Errorf("got unit %q for %s, want none", unit, name)
+		}
+	}
 }
@@ -0,0 +1,28 @@
+package collector
+
+import netatmo "github.com/exzz/netatmo-api-go"
+
+// observeGusts records the highest gust strength measured by each wind gauge since the exporter was started.
+func (c *NetatmoCollector) observeGusts(devices *netatmo.DeviceCollection) {
+	if devices == nil {
+		return
+	}
+
+	for _, station := range devices.Devices() {
+		if station == nil {
+			continue
+		}
+
+		for _, module := range station.LinkedModules {
+			if module == nil || module.DashboardData.GustStrength == nil {
+				continue
+			}
+			strength := float64(*module.DashboardData.GustStrength)
+
+			if current, ok := c.gustMax[module.ID]; ok && current >= strength {
+				continue
+			}
+			c.gustMax[module.ID] = strength
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestObserveGusts(t *testing.T) {
+	c := New(logrus.New(), nil, time.Minute, time.Hour)
+	devices := func(wind, other int32) *netatmo.DeviceCollection {
+		collection := &netatmo.DeviceCollection{}
+		collection.Body.Devices = []*netatmo.Device{
+			{
+				ID: "station",
+				LinkedModules: []*netatmo.Device{
+					{ID: "wind", DashboardData: netatmo.DashboardData{GustStrength: int32Ptr(wind)}},
+					{ID: "other", DashboardData: netatmo.DashboardData{GustStrength: int32Ptr(other)}},
+					{ID: "outdoor"},
+				},
+			},
+		}
+		return collection
+	}
+
+	tt := []struct {
+		wind      int32
+		other     int32
+		wantWind  float64
+		wantOther float64
+	}{
+		{wind: 12, other: 5, wantWind: 12, wantOther: 5},
+		{wind: 30, other: 5, wantWind: 30, wantOther: 5},
+		{wind: 8, other: 6, wantWind: 30, wantOther: 6},
+		{wind: 31, other: 0, wantWind: 31, wantOther: 6},
+	}
+
+	for i, tc := range tt {
+		c.observeGusts(devices(tc.wind, tc.other))
+
+		if got := c.gustMax["wind"]; got != tc.wantWind {
+			t.Errorf("after refresh %d got %.0f for wind, want %.0f", i, got, tc.wantWind)
+		}
+
+		if got := c.gustMax["other"]; got != tc.wantOther {
+			t.Errorf("after refresh %d got %.0f for other, want %.0f", i, got, tc.wantOther)
+		}
+
+		if _, ok := c.gustMax["outdoor"]; ok {
+			t.Errorf("after refresh %d got gust for module without wind gauge", i)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+)
+
+// influxMeasurement is the name of the measurement used for all lines written by WriteInflux.
+const influxMeasurement = "netatmo"
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// WriteInflux writes the cached data of all modules in InfluxDB line protocol. Each module is written as one line
+// tagged with the station, module and home, using the time of the measurement as timestamp. Like for the Prometheus
+// metrics, a refresh is started if one is due and stale modules are left out.
+func (c *NetatmoCollector) WriteInflux(w io.Writer) error {
+	now := c.clock()
+	c.triggerRefresh(now)
+
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+
+	out := bufio.NewWriter(w)
+	if c.cachedData != nil {
+		// Modules can be linked to more than one station. Only the first occurrence is written.
+		seen := make(map[string]bool)
+		for _, dev := range c.cachedData.Devices() {
+			if dev == nil || !c.stationIncluded(dev) {
+				continue
+			}
+
+			stationName := c.stationLabel(dev)
+			seen[dev.ID] = true
+			c.writeInfluxLine(out, dev, stationName, dev.HomeName, now)
+
+			for _, module := range dev.LinkedModules {
+				if module == nil || seen[module.ID] {
+					continue
+				}
+				seen[module.ID] = true
+
+				c.writeInfluxLine(out, module, stationName, dev.HomeName, now)
+			}
+		}
+	}
+
+	for _, homeCoach := range c.cachedHomeCoaches {
+		if !c.stationIncluded(&homeCoach.Device) {
+			continue
+		}
+
+		c.writeInfluxLine(out, &homeCoach.Device, c.stationLabel(&homeCoach.Device), homeCoach.HomeName, now)
+	}
+
+	return out.Flush()
+}
+
+func (c *NetatmoCollector) writeInfluxLine(out *bufio.Writer, device *netatmo.Device, stationName, homeName string, now time.Time) {
+	data := device.DashboardData
+	if data.LastMeasure == nil || now.Sub(time.Unix(*data.LastMeasure, 0)) > c.StaleThreshold {
+		return
+	}
+
+	var fields []string
+	addInt := func(name string, value *int32) {
+		if value != nil {
+			fields = append(fields, name+"="+strconv.FormatInt(int64(*value), 10)+"i")
+		}
+	}
+	addFloat := func(name string, value *float32, precision Precision) {
+		if value != nil {
+			fields = append(fields, name+"="+strconv.FormatFloat(precision.round(float64(*value)), 'f', -1, 32))
+		}
+	}
+
+	addFloat("temperature", data.Temperature, c.TemperaturePrecision)
+	addInt("humidity", data.Humidity)
+	addInt("co2", data.CO2)
+	addInt("noise", data.Noise)
+	addFloat("pressure", data.Pressure, c.PressurePrecision)
+	addFloat("absolute_pressure", data.AbsolutePressure, c.PressurePrecision)
+	addInt("wind_strength", data.WindStrength)
+	addInt("wind_angle", data.WindAngle)
+	addInt("gust_strength", data.GustStrength)
+	addFloat("rain", data.Rain, NoRounding)
+	addInt("battery_percent", device.BatteryPercent)
+	addInt("wifi_status", device.WifiStatus)
+	addInt("rf_status", device.RFStatus)
+	if len(fields) == 0 {
+		return
+	}
+
+	// Empty tag values are not allowed in the line protocol, so these tags are left out.
+	key := influxMeasurement
+	for _, tag := range []struct{ name, value string }{
+		{"home", homeName},
+		{"module", moduleName(device)},
+		{"station", stationName},
+	} {
+		if tag.value != "" {
+			key += "," + tag.name + "=" + influxTagEscaper.Replace(tag.value)
+		}
+	}
+
+	fmt.Fprintf(out, "%s %s %d\n", key, strings.Join(fields, ","), time.Unix(*data.LastMeasure, 0).UnixNano())
+}
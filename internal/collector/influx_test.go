@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWriteInflux(t *testing.T) {
+	mockClock := func() time.Time {
+		return time.Unix(3600, 0)
+	}
+	const body = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:00:00:01",
+        "station_name": "Home",
+        "home_name": "My Home",
+        "module_name": "Living Room",
+        "type": "NAMain",
+        "wifi_status": 50,
+        "dashboard_data": {"time_utc": 3500, "Temperature": 21.5, "Humidity": 45, "CO2": 600},
+        "modules": [
+          {
+            "_id": "02:00:00:00:00:01",
+            "module_name": "Outdoor",
+            "type": "NAModule1",
+            "battery_percent": 80,
+            "dashboard_data": {"time_utc": 3400, "Temperature": -2.3}
+          },
+          {
+            "_id": "02:00:00:00:00:02",
+            "module_name": "Stale",
+            "type": "NAModule1",
+            "dashboard_data": {"time_utc": 100, "Temperature": 5}
+          },
+          {
+            "_id": "02:00:00:00:00:03",
+            "module_name": "New Module",
+            "type": "NAModule1",
+            "dashboard_data": null
+          }
+        ]
+      }
+    ]
+  }
+}`
+
+	var devices netatmo.DeviceCollection
+	if err := json.Unmarshal([]byte(body), &devices); err != nil {
+		t.Fatalf("error decoding test data: %s", err)
+	}
+	read := func() (*netatmo.DeviceCollection, error) {
+		return &devices, nil
+	}
+
+	c := New(logrus.New(), read, time.Hour, 30*time.Minute)
+	c.clock = mockClock
+	c.RefreshData(mockClock())
+
+	var out strings.Builder
+	if err := c.WriteInflux(&out); err != nil {
+		t.Fatalf("got error %q", err)
+	}
+
+	want := `netatmo,home=My\ Home,module=Living\ Room,station=Home temperature=21.5,humidity=45i,co2=600i,wifi_status=50i 3500000000000
+netatmo,home=My\ Home,module=Outdoor,station=Home temperature=-2.3,battery_percent=80i 3400000000000
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/transport"
+)
+
+const testAccessToken = "test-access-token"
+
+// newMockServer creates a server which mimics the NetAtmo API by serving the recorded responses from the testdata directory.
+func newMockServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/getstationsdata", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+			http.Error(w, `{"error":{"code":2,"message":"Invalid access token"}}`, http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, "testdata/getstationsdata.json")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newMockClient creates a netatmo client which sends all requests to the server using the provided access token.
+func newMockClient(t *testing.T, server *httptest.Server, accessToken string) *netatmo.Client {
+	t.Helper()
+
+	apiTransport, err := transport.New(transport.Options{
+		APIURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("error creating transport: %s", err)
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Transport: apiTransport,
+	})
+
+	client := netatmo.NewClient(netatmo.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+	}, nil)
+	client.InitWithToken(ctx, &oauth2.Token{
+		AccessToken: accessToken,
+		Expiry:      time.Now().Add(time.Hour),
+	})
+
+	return client
+}
+
+func TestIntegrationCollect(t *testing.T) {
+	server := newMockServer(t)
+	client := newMockClient(t, server, testAccessToken)
+
+	mockClock := func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+
+	c := New(logrus.New(), client.Read, 10*time.Minute, time.Hour)
+	c.clock = mockClock
+	c.RefreshData(mockClock())
+
+	if c.lastRefreshError != nil {
+		t.Fatalf("error during refresh: %s", c.lastRefreshError)
+	}
+
+	// The wind module is stale and the bedroom module did not report any data, so both only have the info metric.
+	// The wind module still has its last-seen time.
+	expected := `# HELP netatmo_module_info Contains information about all known modules, even if they do not have fresh data. Value is always 1.
+# TYPE netatmo_module_info gauge
+netatmo_module_info{home="Home",module="Bedroom",station="Home (Living Room)",type="NAModule4"} 1
+netatmo_module_info{home="Home",module="Living Room",station="Home (Living Room)",type="NAMain"} 1
+netatmo_module_info{home="Home",module="Outdoor",station="Home (Living Room)",type="NAModule1"} 1
+netatmo_module_info{home="Home",module="Rain gauge",station="Home (Living Room)",type="NAModule3"} 1
+netatmo_module_info{home="Home",module="Wind",station="Home (Living Room)",type="NAModule2"} 1
+# HELP netatmo_module_is_main One if the module is the main device of the station, zero for modules linked to the station.
+# TYPE netatmo_module_is_main gauge
+netatmo_module_is_main{home="Home",module="Bedroom",station="Home (Living Room)"} 0
+netatmo_module_is_main{home="Home",module="Living Room",station="Home (Living Room)"} 1
+netatmo_module_is_main{home="Home",module="Outdoor",station="Home (Living Room)"} 0
+netatmo_module_is_main{home="Home",module="Rain gauge",station="Home (Living Room)"} 0
+netatmo_module_is_main{home="Home",module="Wind",station="Home (Living Room)"} 0
+# HELP netatmo_module_last_seen_time Contains the time of the most recent measurement of a module, even if the data is stale.
+# TYPE netatmo_module_last_seen_time gauge
+netatmo_module_last_seen_time{home="Home",module="Living Room",station="Home (Living Room)"} 1699999700
+netatmo_module_last_seen_time{home="Home",module="Outdoor",station="Home (Living Room)"} 1699999650
+netatmo_module_last_seen_time{home="Home",module="Rain gauge",station="Home (Living Room)"} 1699999660
+netatmo_module_last_seen_time{home="Home",module="Wind",station="Home (Living Room)"} 1699990000
+# HELP netatmo_sensor_absolute_pressure_mb Atmospheric pressure measurement in millibar at the altitude of the station
+# TYPE netatmo_sensor_absolute_pressure_mb gauge
+netatmo_sensor_absolute_pressure_mb{home="Home",module="Living Room",station="Home (Living Room)"} 990.25
+# HELP netatmo_sensor_apparent_temperature_celsius Apparent temperature in celsius. Heat index (Rothfusz) at or above 26.7°C, wind chill (Environment Canada) at or below 10°C with wind above 4.8 km/h, otherwise the measured temperature. Computed by the exporter.
+# TYPE netatmo_sensor_apparent_temperature_celsius gauge
+netatmo_sensor_apparent_temperature_celsius{home="Home",module="Living Room",station="Home (Living Room)"} 21.5
+netatmo_sensor_apparent_temperature_celsius{home="Home",module="Outdoor",station="Home (Living Room)"} 4.25
+# HELP netatmo_sensor_battery_percent Battery remaining life (10: low)
+# TYPE netatmo_sensor_battery_percent gauge
+netatmo_sensor_battery_percent{home="Home",module="Outdoor",station="Home (Living Room)"} 78
+netatmo_sensor_battery_percent{home="Home",module="Rain gauge",station="Home (Living Room)"} 64
+# HELP netatmo_sensor_co2_ppm Carbondioxide measurement in parts per million
+# TYPE netatmo_sensor_co2_ppm gauge
+netatmo_sensor_co2_ppm{home="Home",module="Living Room",station="Home (Living Room)"} 612
+# HELP netatmo_sensor_humidity_percent Relative humidity measurement in percent
+# TYPE netatmo_sensor_humidity_percent gauge
+netatmo_sensor_humidity_percent{home="Home",module="Living Room",station="Home (Living Room)"} 48
+netatmo_sensor_humidity_percent{home="Home",module="Outdoor",station="Home (Living Room)"} 87
+# HELP netatmo_sensor_noise_db Noise measurement in decibels
+# TYPE netatmo_sensor_noise_db gauge
+netatmo_sensor_noise_db{home="Home",module="Living Room",station="Home (Living Room)"} 38
+# HELP netatmo_sensor_pressure_mb Atmospheric pressure measurement in millibar, reduced to sea level by NetAtmo using the altitude of the station
+# TYPE netatmo_sensor_pressure_mb gauge
+netatmo_sensor_pressure_mb{home="Home",module="Living Room",station="Home (Living Room)"} 1015.5
+# HELP netatmo_sensor_rain_amount_mm Rain amount in millimeters
+# TYPE netatmo_sensor_rain_amount_mm gauge
+netatmo_sensor_rain_amount_mm{home="Home",module="Rain gauge",station="Home (Living Room)"} 0.25
+# HELP netatmo_sensor_rf_signal_strength RF signal strength (90: lowest, 60: highest)
+# TYPE netatmo_sensor_rf_signal_strength gauge
+netatmo_sensor_rf_signal_strength{home="Home",module="Outdoor",station="Home (Living Room)"} 61
+netatmo_sensor_rf_signal_strength{home="Home",module="Rain gauge",station="Home (Living Room)"} 70
+# HELP netatmo_sensor_temperature_celsius Temperature measurement in celsius
+# TYPE netatmo_sensor_temperature_celsius gauge
+netatmo_sensor_temperature_celsius{home="Home",module="Living Room",station="Home (Living Room)"} 21.5
+netatmo_sensor_temperature_celsius{home="Home",module="Outdoor",station="Home (Living Room)"} 4.25
+# HELP netatmo_sensor_updated Timestamp of last update. Deprecated, use netatmo_module_last_seen_time instead, which is also available for stale data.
+# TYPE netatmo_sensor_updated gauge
+netatmo_sensor_updated{home="Home",module="Living Room",station="Home (Living Room)"} 1699999700
+netatmo_sensor_updated{home="Home",module="Outdoor",station="Home (Living Room)"} 1699999650
+netatmo_sensor_updated{home="Home",module="Rain gauge",station="Home (Living Room)"} 1699999660
+# HELP netatmo_sensor_wifi_signal_strength Wifi signal strength (86: bad, 71: avg, 56: good)
+# TYPE netatmo_sensor_wifi_signal_strength gauge
+netatmo_sensor_wifi_signal_strength{home="Home",module="Living Room",station="Home (Living Room)"} 52
+# HELP netatmo_sensor_wind_direction_degrees Wind direction in degrees
+# TYPE netatmo_sensor_wind_direction_degrees gauge
+# HELP netatmo_sensor_wind_strength_kph Wind strength in kilometers per hour
+# TYPE netatmo_sensor_wind_strength_kph gauge
+# HELP netatmo_station_module_count Number of modules linked to the station in the data of the last successful refresh.
+# TYPE netatmo_station_module_count gauge
+netatmo_station_module_count{home="Home",station="Home (Living Room)"} 4
+# HELP netatmo_station_up One if the station and all its modules provided fresh data during the last refresh, zero otherwise.
+# TYPE netatmo_station_up gauge
+netatmo_station_up{home="Home",station="Home (Living Room)"} 0
+`
+
+	metricNames := []string{
+		"netatmo_module_info",
+		"netatmo_module_is_main",
+		"netatmo_module_last_seen_time",
+		"netatmo_sensor_apparent_temperature_celsius",
+		"netatmo_sensor_absolute_pressure_mb",
+		"netatmo_sensor_battery_percent",
+		"netatmo_sensor_co2_ppm",
+		"netatmo_sensor_humidity_percent",
+		"netatmo_sensor_noise_db",
+		"netatmo_sensor_pressure_mb",
+		"netatmo_sensor_rain_amount_mm",
+		"netatmo_sensor_rf_signal_strength",
+		"netatmo_sensor_temperature_celsius",
+		"netatmo_sensor_updated",
+		"netatmo_sensor_wifi_signal_strength",
+		"netatmo_sensor_wind_direction_degrees",
+		"netatmo_sensor_wind_strength_kph",
+		"netatmo_station_module_count",
+		"netatmo_station_up",
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), metricNames...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntegrationInvalidToken(t *testing.T) {
+	server := newMockServer(t)
+	client := newMockClient(t, server, "invalid-token")
+
+	c := New(logrus.New(), client.Read, 10*time.Minute, time.Hour)
+	c.RefreshData(time.Now())
+
+	if c.lastRefreshError == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if reason := errorReason(c.lastRefreshError); reason != "api" {
+		t.Errorf("got reason %q, want %q", reason, "api")
+	}
+}
@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"strings"
+	"text/template"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+)
+
+// StationLabelData contains the fields of a station, which can be used in the template for the station label.
+type StationLabelData struct {
+	ID   string
+	Name string
+	Home string
+}
+
+// ParseStationLabelTemplate parses the template used for the station label. The template is executed once using
+// example data, so that references to unknown fields are detected before the first scrape.
+func ParseStationLabelTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("station-label").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.Execute(&strings.Builder{}, StationLabelData{}); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// stationLabel returns the value of the station label for a device. Without a template or when the template does not
+// produce a value, the station name is used.
+func (c *NetatmoCollector) stationLabel(device *netatmo.Device) string {
+	stationName := device.StationName //nolint: staticcheck
+	if c.StationLabelTemplate == nil {
+		return stationName
+	}
+
+	var sb strings.Builder
+	if err := c.StationLabelTemplate.Execute(&sb, StationLabelData{
+		ID:   device.ID,
+		Name: stationName,
+		Home: device.HomeName,
+	}); err != nil {
+		c.Log.WithError(err).Debugf("Error executing station label template for %s, using station name.", device.ID)
+		return stationName
+	}
+
+	label := strings.TrimSpace(sb.String())
+	if label == "" {
+		c.Log.Debugf("Station label template is empty for %s, using station name.", device.ID)
+		return stationName
+	}
+
+	return label
+}
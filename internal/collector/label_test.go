@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseStationLabelTemplate(t *testing.T) {
+	tt := []struct {
+		text    string
+		wantErr bool
+	}{
+		{text: "{{ .Name }}"},
+		{text: "{{ .Home }}-{{ .ID }}"},
+		{text: "{{ .Name ", wantErr: true},
+		{text: "{{ .City }}", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		_, err := ParseStationLabelTemplate(tc.text)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("for %q got error %v, want error %v", tc.text, err, tc.wantErr)
+		}
+	}
+}
+
+func TestStationLabel(t *testing.T) {
+	device := &netatmo.Device{
+		ID:          "70:ee:50:00:00:01",
+		StationName: "My Home (backyard)", //nolint: staticcheck
+		HomeName:    "My Home",
+	}
+
+	tt := []struct {
+		desc string
+		text string
+		want string
+	}{
+		{
+			desc: "no template",
+			want: "My Home (backyard)",
+		},
+		{
+			desc: "home and id",
+			text: `{{ .Home | printf "%.2s" }}-{{ .ID }}`,
+			want: "My-70:ee:50:00:00:01",
+		},
+		{
+			desc: "empty",
+			text: `{{ if false }}unused{{ end }}`,
+			want: "My Home (backyard)",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			c := New(logrus.New(), nil, time.Minute, time.Hour)
+			if tc.text != "" {
+				tmpl, err := ParseStationLabelTemplate(tc.text)
+				if err != nil {
+					t.Fatalf("error parsing template: %s", err)
+				}
+				c.StationLabelTemplate = tmpl
+			}
+
+			if got := c.stationLabel(device); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+)
+
+// mergeDevices merges freshly read data into the cached data. Devices and modules which are missing from the fresh
+// data or do not contain any measurements are taken from the cached data, as long as their data is newer than cutoff.
+func mergeDevices(cached, fresh *netatmo.DeviceCollection, cutoff time.Time) *netatmo.DeviceCollection {
+	if cached == nil {
+		return fresh
+	}
+
+	result := &netatmo.DeviceCollection{}
+	result.Body.Devices = mergeDeviceList(cached.Devices(), fresh.Devices(), cutoff)
+	return result
+}
+
+func mergeDeviceList(cached, fresh []*netatmo.Device, cutoff time.Time) []*netatmo.Device {
+	cachedDevices := make(map[string]*netatmo.Device, len(cached))
+	for _, device := range cached {
+		if device == nil {
+			continue
+		}
+
+		cachedDevices[device.ID] = device
+	}
+
+	var result []*netatmo.Device
+	seen := make(map[string]bool, len(fresh))
+	for _, device := range fresh {
+		if device == nil {
+			continue
+		}
+
+		seen[device.ID] = true
+		result = append(result, mergeDevice(cachedDevices[device.ID], device, cutoff))
+	}
+
+	for _, device := range cached {
+		if device == nil || seen[device.ID] || !hasDataAfter(device, cutoff) {
+			continue
+		}
+
+		result = append(result, device)
+	}
+
+	return result
+}
+
+func mergeDevice(cached, fresh *netatmo.Device, cutoff time.Time) *netatmo.Device {
+	if cached == nil {
+		return fresh
+	}
+
+	merged := *fresh
+	if fresh.DashboardData.LastMeasure == nil && hasDataAfter(cached, cutoff) {
+		merged.DashboardData = cached.DashboardData
+	}
+
+	merged.LinkedModules = mergeDeviceList(cached.LinkedModules, fresh.LinkedModules, cutoff)
+
+	return &merged
+}
+
+func hasDataAfter(device *netatmo.Device, cutoff time.Time) bool {
+	lastMeasure := device.DashboardData.LastMeasure
+	if lastMeasure == nil {
+		return false
+	}
+
+	return time.Unix(*lastMeasure, 0).After(cutoff)
+}
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeDevices(t *testing.T) {
+	cutoff := time.Unix(1000, 0)
+	createCollection := func(devices ...*netatmo.Device) *netatmo.DeviceCollection {
+		dc := &netatmo.DeviceCollection{}
+		dc.Body.Devices = devices
+		return dc
+	}
+	createDevice := func(id string, lastMeasure *int64, temperature float32, modules ...*netatmo.Device) *netatmo.Device {
+		return &netatmo.Device{
+			ID: id,
+			DashboardData: netatmo.DashboardData{
+				Temperature: float32Ptr(temperature),
+				LastMeasure: lastMeasure,
+			},
+			LinkedModules: modules,
+		}
+	}
+
+	tt := []struct {
+		desc     string
+		cached   *netatmo.DeviceCollection
+		fresh    *netatmo.DeviceCollection
+		wantData *netatmo.DeviceCollection
+	}{
+		{
+			desc:     "no cached data",
+			cached:   nil,
+			fresh:    createCollection(createDevice("station", int64Ptr(2000), 20)),
+			wantData: createCollection(createDevice("station", int64Ptr(2000), 20)),
+		},
+		{
+			desc:     "fresh data replaces cache",
+			cached:   createCollection(createDevice("station", int64Ptr(1500), 19)),
+			fresh:    createCollection(createDevice("station", int64Ptr(2000), 20)),
+			wantData: createCollection(createDevice("station", int64Ptr(2000), 20)),
+		},
+		{
+			desc: "partial response keeps cached module",
+			cached: createCollection(createDevice("station", int64Ptr(1500), 19,
+				createDevice("outdoor", int64Ptr(1500), 5),
+				createDevice("rain", int64Ptr(1500), 0),
+			)),
+			fresh: createCollection(createDevice("station", int64Ptr(2000), 20,
+				createDevice("outdoor", nil, 0),
+			)),
+			wantData: createCollection(createDevice("station", int64Ptr(2000), 20,
+				createDevice("outdoor", int64Ptr(1500), 5),
+				createDevice("rain", int64Ptr(1500), 0),
+			)),
+		},
+		{
+			desc: "missing station is kept",
+			cached: createCollection(
+				createDevice("station-a", int64Ptr(1500), 19),
+				createDevice("station-b", int64Ptr(1500), 21),
+			),
+			fresh: createCollection(
+				createDevice("station-a", int64Ptr(2000), 20),
+			),
+			wantData: createCollection(
+				createDevice("station-a", int64Ptr(2000), 20),
+				createDevice("station-b", int64Ptr(1500), 21),
+			),
+		},
+		{
+			desc: "stale cached data is dropped",
+			cached: createCollection(
+				createDevice("station-a", int64Ptr(1500), 19,
+					createDevice("outdoor", int64Ptr(500), 5),
+				),
+				createDevice("station-b", int64Ptr(500), 21),
+			),
+			fresh: createCollection(
+				createDevice("station-a", int64Ptr(2000), 20),
+			),
+			wantData: createCollection(
+				createDevice("station-a", int64Ptr(2000), 20),
+			),
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			data := mergeDevices(tc.cached, tc.fresh, cutoff)
+
+			if diff := cmp.Diff(data, tc.wantData); diff != "" {
+				t.Errorf("data differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
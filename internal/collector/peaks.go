@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+const (
+	peakCO2   = "co2"
+	peakNoise = "noise"
+)
+
+type peakKey struct {
+	id     string
+	metric string
+}
+
+type peakSample struct {
+	time  time.Time
+	value float64
+}
+
+// observePeaks records the CO2 and noise measurements of all devices, so that the maximum within the peak window can
+// be calculated. The NetAtmo API does not provide these values, so they are only kept in memory and start again
+// when the exporter is restarted.
+func (c *NetatmoCollector) observePeaks(devices *netatmo.DeviceCollection, homeCoaches []*api.HomeCoach, now time.Time) {
+	if c.PeakWindow <= 0 {
+		return
+	}
+
+	var all []*netatmo.Device
+	if devices != nil {
+		for _, station := range devices.Devices() {
+			if station == nil {
+				continue
+			}
+
+			all = append(all, station)
+			all = append(all, station.LinkedModules...)
+		}
+	}
+	for _, homeCoach := range homeCoaches {
+		all = append(all, &homeCoach.Device)
+	}
+
+	for _, device := range all {
+		if device == nil || device.DashboardData.LastMeasure == nil {
+			continue
+		}
+		measured := time.Unix(*device.DashboardData.LastMeasure, 0)
+
+		if device.DashboardData.CO2 != nil {
+			c.addPeakSample(peakKey{device.ID, peakCO2}, measured, float64(*device.DashboardData.CO2))
+		}
+		if device.DashboardData.Noise != nil {
+			c.addPeakSample(peakKey{device.ID, peakNoise}, measured, float64(*device.DashboardData.Noise))
+		}
+	}
+
+	cutoff := now.Add(-c.PeakWindow)
+	for key, samples := range c.peaks {
+		for len(samples) > 0 && samples[0].time.Before(cutoff) {
+			samples = samples[1:]
+		}
+
+		if len(samples) == 0 {
+			delete(c.peaks, key)
+			continue
+		}
+		c.peaks[key] = samples
+	}
+}
+
+// addPeakSample appends a measurement, unless it has already been recorded during a previous refresh.
+func (c *NetatmoCollector) addPeakSample(key peakKey, measured time.Time, value float64) {
+	samples := c.peaks[key]
+	if len(samples) > 0 && !measured.After(samples[len(samples)-1].time) {
+		return
+	}
+
+	c.peaks[key] = append(samples, peakSample{time: measured, value: value})
+}
+
+// peak returns the highest value of the metric measured by the device within the peak window.
+func (c *NetatmoCollector) peak(device *netatmo.Device, metric string) (float64, bool) {
+	cutoff := c.clock().Add(-c.PeakWindow)
+
+	var result float64
+	found := false
+	for _, sample := range c.peaks[peakKey{device.ID, metric}] {
+		if sample.time.Before(cutoff) {
+			continue
+		}
+
+		if !found || sample.value > result {
+			result = sample.value
+			found = true
+		}
+	}
+
+	return result, found
+}
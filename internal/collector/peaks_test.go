@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPeaks(t *testing.T) {
+	lastMeasure := int64(0)
+	co2 := int32(0)
+	read := func() (*netatmo.DeviceCollection, error) {
+		dc := &netatmo.DeviceCollection{}
+		dc.Body.Devices = []*netatmo.Device{
+			{
+				ID: "station",
+				DashboardData: netatmo.DashboardData{
+					CO2:         int32Ptr(co2),
+					LastMeasure: int64Ptr(lastMeasure),
+				},
+			},
+		}
+		return dc, nil
+	}
+
+	now := time.Unix(0, 0)
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	c.PeakWindow = time.Hour
+	c.clock = func() time.Time { return now }
+	device := &netatmo.Device{ID: "station"}
+
+	tt := []struct {
+		lastMeasure int64
+		co2         int32
+		want        float64
+	}{
+		{lastMeasure: 0, co2: 800, want: 800},
+		{lastMeasure: 600, co2: 1200, want: 1200},
+		{lastMeasure: 600, co2: 500, want: 1200},
+		{lastMeasure: 1200, co2: 600, want: 1200},
+		{lastMeasure: 4300, co2: 700, want: 700},
+	}
+
+	for _, tc := range tt {
+		lastMeasure = tc.lastMeasure
+		co2 = tc.co2
+		now = time.Unix(tc.lastMeasure, 0)
+		c.RefreshData(now)
+
+		got, ok := c.peak(device, peakCO2)
+		if !ok {
+			t.Fatalf("after measurement at %d no peak found", tc.lastMeasure)
+		}
+
+		if got != tc.want {
+			t.Errorf("after measurement at %d got %f, want %f", tc.lastMeasure, got, tc.want)
+		}
+	}
+
+	if _, ok := c.peak(device, peakNoise); ok {
+		t.Error("found noise peak without noise measurement")
+	}
+}
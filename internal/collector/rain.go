@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+)
+
+// rainRate keeps the last cumulative rain amount of a rain gauge and the rate calculated from the previous one.
+type rainRate struct {
+	lastMeasure int64
+	total       float64
+	rate        float64
+	valid       bool
+}
+
+// observeRain calculates the rain rate of all rain gauges using the change of the rain amount of the last 24 hours
+// between two measurements. When the amount decreases, because older rain is not counted anymore, the rate is zero.
+func (c *NetatmoCollector) observeRain(devices *netatmo.DeviceCollection) {
+	if devices == nil {
+		return
+	}
+
+	for _, station := range devices.Devices() {
+		if station == nil {
+			continue
+		}
+
+		for _, module := range station.LinkedModules {
+			if module == nil || module.DashboardData.LastMeasure == nil || module.DashboardData.Rain1Day == nil {
+				continue
+			}
+			lastMeasure := *module.DashboardData.LastMeasure
+			total := float64(*module.DashboardData.Rain1Day)
+
+			previous, ok := c.rainRates[module.ID]
+			if !ok {
+				c.rainRates[module.ID] = &rainRate{lastMeasure: lastMeasure, total: total}
+				continue
+			}
+
+			if lastMeasure <= previous.lastMeasure {
+				continue
+			}
+
+			elapsed := time.Duration(lastMeasure-previous.lastMeasure) * time.Second
+			previous.rate = 0
+			if total > previous.total {
+				previous.rate = (total - previous.total) / elapsed.Hours()
+			}
+			previous.valid = true
+			previous.lastMeasure = lastMeasure
+			previous.total = total
+		}
+	}
+}
+
+// rainRate returns the rain rate of the device in millimeters per hour.
+func (c *NetatmoCollector) rainRate(device *netatmo.Device) (float64, bool) {
+	rate, ok := c.rainRates[device.ID]
+	if !ok || !rate.valid {
+		return 0, false
+	}
+
+	return rate.rate, true
+}
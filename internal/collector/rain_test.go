@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	netatmo "github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRainRate(t *testing.T) {
+	lastMeasure := int64(0)
+	rain := float32(0)
+	read := func() (*netatmo.DeviceCollection, error) {
+		dc := &netatmo.DeviceCollection{}
+		dc.Body.Devices = []*netatmo.Device{
+			{
+				ID: "station",
+				LinkedModules: []*netatmo.Device{
+					{
+						ID: "rain",
+						DashboardData: netatmo.DashboardData{
+							Rain1Day:    float32Ptr(rain),
+							LastMeasure: int64Ptr(lastMeasure),
+						},
+					},
+				},
+			},
+		}
+		return dc, nil
+	}
+
+	c := New(logrus.New(), read, time.Minute, time.Hour)
+	device := &netatmo.Device{ID: "rain"}
+
+	tt := []struct {
+		lastMeasure int64
+		rain        float32
+		wantOK      bool
+		want        float64
+	}{
+		{lastMeasure: 0, rain: 1, wantOK: false},
+		{lastMeasure: 600, rain: 1.5, wantOK: true, want: 3},
+		{lastMeasure: 600, rain: 2, wantOK: true, want: 3},
+		{lastMeasure: 1200, rain: 1.5, wantOK: true, want: 0},
+		{lastMeasure: 3000, rain: 3, wantOK: true, want: 3},
+	}
+
+	for _, tc := range tt {
+		lastMeasure = tc.lastMeasure
+		rain = tc.rain
+		c.RefreshData(time.Unix(tc.lastMeasure, 0))
+
+		got, ok := c.rainRate(device)
+		if ok != tc.wantOK {
+			t.Fatalf("after measurement at %d got ok %v, want %v", tc.lastMeasure, ok, tc.wantOK)
+		}
+
+		if math.Abs(got-tc.want) > 0.001 {
+			t.Errorf("after measurement at %d got %f, want %f", tc.lastMeasure, got, tc.want)
+		}
+	}
+}
@@ -4,51 +4,227 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/exzz/netatmo-api-go"
+	"github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+	"github.com/xperimental/netatmo-exporter/v2/internal/transport"
+	"github.com/xperimental/netatmo-exporter/v2/internal/vault"
 )
 
+// UnixSocketPrefix marks listen addresses, which are the path of a Unix domain socket.
+const UnixSocketPrefix = "unix:"
+
 const (
-	envVarListenAddress       = "NETATMO_EXPORTER_ADDR"
-	envVarExternalURL         = "NETATMO_EXPORTER_EXTERNAL_URL"
-	envVarTokenFile           = "NETATMO_EXPORTER_TOKEN_FILE"
-	envVarDebugHandlers       = "DEBUG_HANDLERS"
-	envVarLogLevel            = "NETATMO_LOG_LEVEL"
-	envVarRefreshInterval     = "NETATMO_REFRESH_INTERVAL"
-	envVarStaleDuration       = "NETATMO_AGE_STALE"
-	envVarNetatmoClientID     = "NETATMO_CLIENT_ID"
-	envVarNetatmoClientSecret = "NETATMO_CLIENT_SECRET"
-
-	flagListenAddress       = "addr"
-	flagExternalURL         = "external-url"
-	flagTokenFile           = "token-file"
-	flagDebugHandlers       = "debug-handlers"
-	flagLogLevel            = "log-level"
-	flagRefreshInterval     = "refresh-interval"
-	flagStaleDuration       = "age-stale"
-	flagNetatmoClientID     = "client-id"
-	flagNetatmoClientSecret = "client-secret"
-
-	defaultRefreshInterval = 8 * time.Minute
-	defaultStaleDuration   = 60 * time.Minute
+	envVarListenAddress         = "NETATMO_EXPORTER_ADDR"
+	envVarExternalURL           = "NETATMO_EXPORTER_EXTERNAL_URL"
+	envVarRoutePrefix           = "NETATMO_EXPORTER_ROUTE_PREFIX"
+	envVarTokenFile             = "NETATMO_EXPORTER_TOKEN_FILE"
+	envVarDebugHandlers         = "DEBUG_HANDLERS"
+	envVarLogLevel              = "NETATMO_LOG_LEVEL"
+	envVarTimezone              = "NETATMO_EXPORTER_TIMEZONE"
+	envVarRefreshInterval       = "NETATMO_REFRESH_INTERVAL"
+	envVarRefreshJitter         = "NETATMO_REFRESH_JITTER"
+	envVarAdaptiveRefresh       = "NETATMO_ADAPTIVE_REFRESH"
+	envVarAdaptiveRefreshMin    = "NETATMO_ADAPTIVE_REFRESH_MIN"
+	envVarAdaptiveRefreshMax    = "NETATMO_ADAPTIVE_REFRESH_MAX"
+	envVarStaleDuration         = "NETATMO_AGE_STALE"
+	envVarNetatmoClientID       = "NETATMO_CLIENT_ID"
+	envVarNetatmoClientSecret   = "NETATMO_CLIENT_SECRET"
+	envVarProxyURL              = "NETATMO_EXPORTER_PROXY_URL"
+	envVarUserAgent             = "NETATMO_EXPORTER_USER_AGENT"
+	envVarDryRun                = "NETATMO_EXPORTER_DRY_RUN"
+	envVarOnce                  = "NETATMO_EXPORTER_ONCE"
+	envVarEnableHomeCoach       = "NETATMO_ENABLE_HOMECOACH"
+	envVarEnableInflux          = "NETATMO_EXPORTER_ENABLE_INFLUX"
+	envVarEnableEnergy          = "NETATMO_ENABLE_ENERGY"
+	envVarAPIURL                = "NETATMO_API_URL"
+	envVarHistoryHours          = "NETATMO_HISTORY_HOURS"
+	envVarIncludeStations       = "NETATMO_INCLUDE_STATIONS"
+	envVarStationIDs            = "NETATMO_STATION_ID"
+	envVarSharedCacheFile       = "NETATMO_EXPORTER_SHARED_CACHE_FILE"
+	envVarDisableCompression    = "NETATMO_EXPORTER_DISABLE_COMPRESSION"
+	envVarMaxRequestsInFlight   = "NETATMO_EXPORTER_MAX_REQUESTS_IN_FLIGHT"
+	envVarScrapeTimeout         = "NETATMO_EXPORTER_SCRAPE_TIMEOUT"
+	envVarFailScrapeOnError     = "NETATMO_EXPORTER_FAIL_SCRAPE_ON_ERROR"
+	envVarSkipUnreachable       = "NETATMO_EXPORTER_SKIP_UNREACHABLE"
+	envVarPeakWindow            = "NETATMO_EXPORTER_PEAK_WINDOW"
+	envVarReadRetries           = "NETATMO_READ_RETRIES"
+	envVarReadRetryDelay        = "NETATMO_READ_RETRY_DELAY"
+	envVarStationLabelTemplate  = "NETATMO_EXPORTER_STATION_LABEL_TEMPLATE"
+	envVarWifiThresholds        = "NETATMO_EXPORTER_WIFI_THRESHOLDS"
+	envVarRFThresholds          = "NETATMO_EXPORTER_RF_THRESHOLDS"
+	envVarExtraLabels           = "NETATMO_EXPORTER_EXTRA_LABELS"
+	envVarStartupGrace          = "NETATMO_EXPORTER_STARTUP_GRACE"
+	envVarFromFile              = "NETATMO_EXPORTER_FROM_FILE"
+	envVarExcludeStations       = "NETATMO_EXCLUDE_STATIONS"
+	envVarCACertFile            = "NETATMO_EXPORTER_CA_CERT_FILE"
+	envVarTLSCertFile           = "NETATMO_EXPORTER_TLS_CERT_FILE"
+	envVarTLSKeyFile            = "NETATMO_EXPORTER_TLS_KEY_FILE"
+	envVarClientCAFile          = "NETATMO_EXPORTER_CLIENT_CA_FILE"
+	envVarAuthBearerToken       = "NETATMO_EXPORTER_AUTH_BEARER_TOKEN"
+	envVarPushgatewayURL        = "NETATMO_EXPORTER_PUSHGATEWAY_URL"
+	envVarVaultURL              = "NETATMO_EXPORTER_VAULT_URL"
+	envVarVaultToken            = "NETATMO_EXPORTER_VAULT_TOKEN"
+	envVarVaultMount            = "NETATMO_EXPORTER_VAULT_MOUNT"
+	envVarVaultSecretPath       = "NETATMO_EXPORTER_VAULT_SECRET_PATH"
+	envVarTemperatureMin        = "NETATMO_TEMPERATURE_MIN"
+	envVarTemperatureMax        = "NETATMO_TEMPERATURE_MAX"
+	envVarHumidityMin           = "NETATMO_HUMIDITY_MIN"
+	envVarHumidityMax           = "NETATMO_HUMIDITY_MAX"
+	envVarCO2AlertThreshold     = "NETATMO_CO2_ALERT_THRESHOLD"
+	envVarHumidityComfort       = "NETATMO_HUMIDITY_COMFORT"
+	envVarRoundTemperature      = "NETATMO_ROUND_TEMPERATURE"
+	envVarRoundPressure         = "NETATMO_ROUND_PRESSURE"
+	envVarDisableRuntimeMetrics = "NETATMO_EXPORTER_DISABLE_RUNTIME_METRICS"
+
+	flagListenAddress         = "addr"
+	flagExternalURL           = "external-url"
+	flagRoutePrefix           = "route-prefix"
+	flagTokenFile             = "token-file"
+	flagDebugHandlers         = "debug-handlers"
+	flagLogLevel              = "log-level"
+	flagTimezone              = "timezone"
+	flagRefreshInterval       = "refresh-interval"
+	flagRefreshJitter         = "refresh-jitter"
+	flagAdaptiveRefresh       = "adaptive-refresh"
+	flagAdaptiveRefreshMin    = "adaptive-refresh-min"
+	flagAdaptiveRefreshMax    = "adaptive-refresh-max"
+	flagStaleDuration         = "age-stale"
+	flagNetatmoClientID       = "client-id"
+	flagNetatmoClientSecret   = "client-secret"
+	flagProxyURL              = "proxy-url"
+	flagUserAgent             = "user-agent"
+	flagDryRun                = "dry-run"
+	flagOnce                  = "once"
+	flagEnableHomeCoach       = "enable-homecoach"
+	flagEnableInflux          = "enable-influx"
+	flagEnableEnergy          = "enable-energy"
+	flagAPIURL                = "netatmo-api-url"
+	flagHistoryHours          = "history-hours"
+	flagIncludeStations       = "include-stations"
+	flagStationIDs            = "station-id"
+	flagSharedCacheFile       = "shared-cache-file"
+	flagDisableCompression    = "disable-compression"
+	flagMaxRequestsInFlight   = "max-requests-in-flight"
+	flagScrapeTimeout         = "scrape-timeout"
+	flagFailScrapeOnError     = "fail-scrape-on-error"
+	flagSkipUnreachable       = "skip-unreachable"
+	flagPeakWindow            = "peak-window"
+	flagReadRetries           = "read-retries"
+	flagReadRetryDelay        = "read-retry-delay"
+	flagStationLabelTemplate  = "station-label-template"
+	flagWifiThresholds        = "wifi-thresholds"
+	flagRFThresholds          = "rf-thresholds"
+	flagExtraLabels           = "extra-labels"
+	flagStartupGrace          = "startup-grace"
+	flagFromFile              = "from-file"
+	flagExcludeStations       = "exclude-stations"
+	flagCACertFile            = "ca-cert-file"
+	flagTLSCertFile           = "tls-cert-file"
+	flagTLSKeyFile            = "tls-key-file"
+	flagClientCAFile          = "client-ca-file"
+	flagAuthBearerToken       = "auth-bearer-token"
+	flagPushgatewayURL        = "pushgateway-url"
+	flagVaultURL              = "vault-url"
+	flagVaultToken            = "vault-token"
+	flagVaultMount            = "vault-mount"
+	flagVaultSecretPath       = "vault-secret-path"
+	flagTemperatureMin        = "temperature-min"
+	flagTemperatureMax        = "temperature-max"
+	flagHumidityMin           = "humidity-min"
+	flagHumidityMax           = "humidity-max"
+	flagCO2AlertThreshold     = "co2-alert-threshold"
+	flagHumidityComfort       = "humidity-comfort"
+	flagRoundTemperature      = "round-temperature"
+	flagRoundPressure         = "round-pressure"
+	flagDisableRuntimeMetrics = "disable-runtime-metrics"
+
+	defaultRefreshInterval    = 8 * time.Minute
+	defaultStaleDuration      = 60 * time.Minute
+	defaultTemperatureMin     = -100
+	defaultTemperatureMax     = 100
+	defaultHumidityMin        = 0
+	defaultHumidityMax        = 100
+	defaultPeakWindow         = 24 * time.Hour
+	defaultReadRetryDelay     = 10 * time.Second
+	defaultAdaptiveRefreshMin = time.Minute
+	defaultAdaptiveRefreshMax = 15 * time.Minute
+	maxRoundingDecimals       = 6
 )
 
 var (
 	defaultConfig = Config{
-		Addr:            ":9210",
-		LogLevel:        logLevel(logrus.InfoLevel),
-		RefreshInterval: defaultRefreshInterval,
-		StaleDuration:   defaultStaleDuration,
+		Addrs:              []string{":9210"},
+		LogLevel:           logLevel(logrus.InfoLevel),
+		RefreshInterval:    defaultRefreshInterval,
+		StaleDuration:      defaultStaleDuration,
+		APIURL:             transport.DefaultAPIURL,
+		TemperatureMin:     defaultTemperatureMin,
+		TemperatureMax:     defaultTemperatureMax,
+		HumidityMin:        defaultHumidityMin,
+		HumidityMax:        defaultHumidityMax,
+		PeakWindow:         defaultPeakWindow,
+		ReadRetryDelay:     defaultReadRetryDelay,
+		AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+		AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+		RoundTemperature:   int(collector.NoRounding),
+		RoundPressure:      int(collector.NoRounding),
+		CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+		HumidityComfort:    []int{int(collector.DefaultHumidityComfort.Min), int(collector.DefaultHumidityComfort.Max)},
+		WifiThresholds:     []int{int(collector.DefaultWifiThresholds.Bad), int(collector.DefaultWifiThresholds.Good)},
+		RFThresholds:       []int{int(collector.DefaultRFThresholds.Bad), int(collector.DefaultRFThresholds.Good)},
+		VaultMount:         vault.DefaultMount,
 	}
 
-	errNoBinaryName          = errors.New("need the binary name as first argument")
-	errNoListenAddress       = errors.New("no listen address")
-	errNoTokenFile           = errors.New("need a token file to save the token")
-	errNoNetatmoClientID     = errors.New("need a NetAtmo client ID")
-	errNoNetatmoClientSecret = errors.New("need a NetAtmo client secret")
+	errNoBinaryName            = errors.New("need the binary name as first argument")
+	errNoListenAddress         = errors.New("no listen address")
+	errInvalidListenAddress    = errors.New("listen address needs to have the form host:port or unix:/path/to.sock")
+	errNoExternalURL           = errors.New("need an external URL when only listening on Unix sockets")
+	errInvalidExternalURL      = errors.New("external URL needs to be an absolute URL")
+	errInvalidRoutePrefix      = errors.New("route prefix needs to be a clean path starting with a slash")
+	errNoTokenFile             = errors.New("need a token file to save the token")
+	errNoNetatmoClientID       = errors.New("need a NetAtmo client ID")
+	errNoNetatmoClientSecret   = errors.New("need a NetAtmo client secret")
+	errSecretFileConflict      = errors.New("secret can not be set directly and using a file at the same time")
+	errInvalidProxyScheme      = errors.New("proxy URL needs to use http, https or socks5 scheme")
+	errNoProxyHost             = errors.New("proxy URL needs a host")
+	errInvalidAPIURL           = errors.New("NetAtmo API URL needs to be an absolute URL")
+	errNegativeHistoryHours    = errors.New("history hours can not be negative")
+	errInvalidTimezone         = errors.New("timezone needs to be a name from the IANA time zone database, like Europe/Berlin")
+	errNegativeRefreshJitter   = errors.New("refresh jitter can not be negative")
+	errInvalidAdaptiveRefresh  = errors.New("adaptive refresh minimum needs to be positive and not larger than the maximum")
+	errInvalidPushgatewayURL   = errors.New("Pushgateway URL needs to be an absolute URL")
+	errInvalidVaultURL         = errors.New("Vault URL needs to be an absolute URL")
+	errNoVaultToken            = errors.New("need a Vault token for reading the credentials from Vault")
+	errNoVaultSecretPath       = errors.New("need the path of the secret for reading the credentials from Vault")
+	errInvalidTemperatureRange = errors.New("minimum temperature can not be greater than maximum temperature")
+	errInvalidHumidityRange    = errors.New("minimum humidity can not be greater than maximum humidity")
+	errInvalidRounding         = fmt.Errorf("number of decimals for rounding can not be greater than %d", maxRoundingDecimals)
+	errNegativeMaxRequests     = errors.New("maximum requests in flight can not be negative")
+	errNegativeScrapeTimeout   = errors.New("scrape timeout can not be negative")
+	errNegativePeakWindow      = errors.New("peak window can not be negative")
+	errNegativeReadRetries     = errors.New("read retries can not be negative")
+	errNegativeReadRetryDelay  = errors.New("read retry delay can not be negative")
+	errInvalidCO2Threshold     = errors.New("CO2 alert threshold needs to be positive")
+	errInvalidHumidityComfort  = errors.New("humidity comfort range needs to be two values between 0 and 100 with the lower value first")
+	errInvalidWifiThresholds   = errors.New("wifi thresholds need to be two values with the bad value greater than the good value")
+	errInvalidRFThresholds     = errors.New("RF thresholds need to be two values with the bad value greater than the good value")
+	errInvalidExtraLabelName   = errors.New("extra label names need to be valid Prometheus label names")
+	errNegativeStartupGrace    = errors.New("startup grace period can not be negative")
+	errIncompleteTLS           = errors.New("serving TLS needs a certificate and a key file")
+	errClientCAWithoutTLS      = errors.New("client certificates can only be used when serving TLS")
+	errFromFileCredentials     = errors.New("reading from a file can not be combined with NetAtmo credentials")
+	errFromFileAPIOptions      = errors.New("reading from a file can not be combined with options using the NetAtmo API")
 )
 
 type logLevel logrus.Level
@@ -71,16 +247,69 @@ func (l *logLevel) Set(value string) error {
 	return nil
 }
 
-// Config contains the configuration options.
+// Config contains the configuration options. Fields containing secrets are tagged with `redact:"true"` and URLs, which
+// can contain a password, with `redact:"url"`, so that they are removed by Redacted.
 type Config struct {
-	Addr            string
-	ExternalURL     string
-	TokenFile       string
-	DebugHandlers   bool
-	LogLevel        logLevel
-	RefreshInterval time.Duration
-	StaleDuration   time.Duration
-	Netatmo         netatmo.Config
+	Addrs                 []string
+	ExternalURL           string `redact:"url"`
+	RoutePrefix           string
+	TokenFile             string
+	DebugHandlers         bool
+	LogLevel              logLevel
+	Timezone              string
+	RefreshInterval       time.Duration
+	RefreshJitter         time.Duration
+	AdaptiveRefresh       bool
+	AdaptiveRefreshMin    time.Duration
+	AdaptiveRefreshMax    time.Duration
+	ReadRetries           int
+	ReadRetryDelay        time.Duration
+	StaleDuration         time.Duration
+	ProxyURL              string `redact:"url"`
+	UserAgent             string
+	DryRun                bool
+	Once                  bool
+	EnableHomeCoach       bool
+	EnableInflux          bool
+	EnableEnergy          bool
+	APIURL                string `redact:"url"`
+	HistoryHours          int
+	IncludeStations       []string
+	StationIDs            []string
+	SharedCacheFile       string
+	FromFile              string
+	DisableCompression    bool
+	MaxRequestsInFlight   int
+	ScrapeTimeout         time.Duration
+	FailScrapeOnError     bool
+	SkipUnreachable       bool
+	StartupGrace          time.Duration
+	PeakWindow            time.Duration
+	ExcludeStations       []string
+	StationLabelTemplate  string
+	ExtraLabels           map[string]string
+	CACertFile            string
+	TLSCertFile           string
+	TLSKeyFile            string
+	ClientCAFile          string
+	AuthBearerToken       string `redact:"true"`
+	PushgatewayURL        string `redact:"url"`
+	VaultURL              string `redact:"url"`
+	VaultToken            string `redact:"true"`
+	VaultMount            string
+	VaultSecretPath       string
+	TemperatureMin        float64
+	TemperatureMax        float64
+	HumidityMin           float64
+	HumidityMax           float64
+	RoundTemperature      int
+	RoundPressure         int
+	CO2AlertThreshold     int
+	HumidityComfort       []int
+	WifiThresholds        []int
+	RFThresholds          []int
+	DisableRuntimeMetrics bool
+	Netatmo               netatmo.Config
 }
 
 // Parse takes the arguments and environment variables provided and creates the Config from that.
@@ -92,15 +321,67 @@ func Parse(args []string, getEnv func(string) string) (Config, error) {
 	}
 
 	flagSet := pflag.NewFlagSet(args[0], pflag.ContinueOnError)
-	flagSet.StringVarP(&cfg.Addr, flagListenAddress, "a", cfg.Addr, "Address to listen on.")
-	flagSet.StringVar(&cfg.ExternalURL, flagExternalURL, cfg.ExternalURL, "External URL to use as base for OAuth redirect URL.")
-	flagSet.StringVar(&cfg.TokenFile, flagTokenFile, cfg.TokenFile, "Path to token file for loading/persisting authentication token.")
+	flagSet.VarP(newListValue(&cfg.Addrs), flagListenAddress, "a", "Addresses to listen on. Unix sockets can be used with unix:/path/to.sock.")
+	flagSet.StringVar(&cfg.ExternalURL, flagExternalURL, cfg.ExternalURL, "External URL to use as base for OAuth redirect URL and links.")
+	flagSet.StringVar(&cfg.RoutePrefix, flagRoutePrefix, cfg.RoutePrefix, "Path prefix of all HTTP handlers. Defaults to the path of the external URL.")
+	flagSet.StringVar(&cfg.TokenFile, flagTokenFile, cfg.TokenFile, "Path to token file for loading/persisting authentication token. Optional when using Vault.")
 	flagSet.BoolVar(&cfg.DebugHandlers, flagDebugHandlers, cfg.DebugHandlers, "Enables debugging HTTP handlers.")
 	flagSet.Var(&cfg.LogLevel, flagLogLevel, "Sets the minimum level output through logging.")
+	flagSet.StringVar(&cfg.Timezone, flagTimezone, cfg.Timezone, "Timezone used for presenting times in logs and on the website, for example Europe/Berlin. Uses the system timezone when empty.")
 	flagSet.DurationVar(&cfg.RefreshInterval, flagRefreshInterval, cfg.RefreshInterval, "Time interval used for internal caching of NetAtmo sensor data.")
+	flagSet.DurationVar(&cfg.RefreshJitter, flagRefreshJitter, cfg.RefreshJitter, "Maximum random delay added to the refresh interval to spread requests of several exporters.")
+	flagSet.BoolVar(&cfg.AdaptiveRefresh, flagAdaptiveRefresh, cfg.AdaptiveRefresh, "Schedules refreshes shortly after the next measurement is expected instead of using a fixed interval.")
+	flagSet.DurationVar(&cfg.AdaptiveRefreshMin, flagAdaptiveRefreshMin, cfg.AdaptiveRefreshMin, "Minimum time between two refreshes in adaptive mode.")
+	flagSet.DurationVar(&cfg.AdaptiveRefreshMax, flagAdaptiveRefreshMax, cfg.AdaptiveRefreshMax, "Maximum time between two refreshes in adaptive mode.")
 	flagSet.DurationVar(&cfg.StaleDuration, flagStaleDuration, cfg.StaleDuration, "Data age to consider as stale. Stale data does not create metrics anymore.")
 	flagSet.StringVarP(&cfg.Netatmo.ClientID, flagNetatmoClientID, "i", cfg.Netatmo.ClientID, "Client ID for NetAtmo app.")
 	flagSet.StringVarP(&cfg.Netatmo.ClientSecret, flagNetatmoClientSecret, "s", cfg.Netatmo.ClientSecret, "Client secret for NetAtmo app.")
+	flagSet.StringVar(&cfg.ProxyURL, flagProxyURL, cfg.ProxyURL, "Proxy to use for connecting to the NetAtmo API. Uses HTTP_PROXY and HTTPS_PROXY when not set.")
+	flagSet.BoolVar(&cfg.DryRun, flagDryRun, cfg.DryRun, "Read data from NetAtmo API once, print a summary and exit.")
+	flagSet.BoolVar(&cfg.Once, flagOnce, cfg.Once, "Refresh data once, print the metrics to stdout and exit without starting the server.")
+	flagSet.BoolVar(&cfg.EnableHomeCoach, flagEnableHomeCoach, cfg.EnableHomeCoach, "Enables reading data from Healthy Home Coach devices. Needs the read_homecoach scope.")
+	flagSet.BoolVar(&cfg.EnableInflux, flagEnableInflux, cfg.EnableInflux, "Enables the /influx endpoint providing the sensor data in InfluxDB line protocol.")
+	flagSet.BoolVar(&cfg.EnableEnergy, flagEnableEnergy, cfg.EnableEnergy, "Enables reading data of rooms with NetAtmo Energy devices. Needs the read_thermostat scope.")
+	flagSet.StringVar(&cfg.APIURL, flagAPIURL, cfg.APIURL, "Base URL of the NetAtmo API.")
+	flagSet.IntVar(&cfg.ReadRetries, flagReadRetries, cfg.ReadRetries, "Number of retries when reading the station data fails during a refresh.")
+	flagSet.DurationVar(&cfg.ReadRetryDelay, flagReadRetryDelay, cfg.ReadRetryDelay, "Delay between retries of reading the station data. Retries are limited to the refresh interval.")
+	flagSet.IntVar(&cfg.HistoryHours, flagHistoryHours, cfg.HistoryHours, "Number of hours of historical measurements provided on /history. Disabled when zero.")
+	flagSet.Var(newListValue(&cfg.StationIDs), flagStationIDs, "Only request the stations with these IDs from the NetAtmo API. Requests all stations when empty.")
+	flagSet.StringVar(&cfg.FromFile, flagFromFile, cfg.FromFile, "Read the station data from a JSON file instead of the NetAtmo API. The file is read again on every refresh.")
+	flagSet.StringVar(&cfg.SharedCacheFile, flagSharedCacheFile, cfg.SharedCacheFile, "File for sharing the station data between exporters using the same account. Disabled when empty.")
+	flagSet.Var(newListValue(&cfg.IncludeStations), flagIncludeStations, "Only export stations with these names or IDs. Exports all stations when empty.")
+	flagSet.Var(newListValue(&cfg.ExcludeStations), flagExcludeStations, "Do not export stations with these names or IDs. Takes precedence over included stations.")
+	flagSet.StringToStringVar(&cfg.ExtraLabels, flagExtraLabels, cfg.ExtraLabels, "Labels added to all metrics of the exporter, as name=value pairs.")
+	flagSet.StringVar(&cfg.StationLabelTemplate, flagStationLabelTemplate, cfg.StationLabelTemplate, "Go template used for the station label. Can use .Name, .ID and .Home. Uses the station name when empty.")
+	flagSet.StringVar(&cfg.CACertFile, flagCACertFile, cfg.CACertFile, "PEM file with additional CA certificates trusted for connections to the NetAtmo API.")
+	flagSet.StringVar(&cfg.TLSCertFile, flagTLSCertFile, cfg.TLSCertFile, "PEM file with the certificate for serving HTTPS. Needs --tls-key-file.")
+	flagSet.StringVar(&cfg.TLSKeyFile, flagTLSKeyFile, cfg.TLSKeyFile, "PEM file with the private key for serving HTTPS. Needs --tls-cert-file.")
+	flagSet.StringVar(&cfg.ClientCAFile, flagClientCAFile, cfg.ClientCAFile, "PEM file with CA certificates for verifying client certificates. All clients need a valid certificate when set. Needs TLS.")
+	flagSet.StringVar(&cfg.AuthBearerToken, flagAuthBearerToken, cfg.AuthBearerToken, "Bearer token required for all endpoints except the authorization redirects. Disabled when empty.")
+	flagSet.StringVar(&cfg.PushgatewayURL, flagPushgatewayURL, cfg.PushgatewayURL, "URL of a Pushgateway the metrics are pushed to after every refresh interval. Disabled when empty.")
+	flagSet.StringVar(&cfg.VaultURL, flagVaultURL, cfg.VaultURL, "URL of a HashiCorp Vault server the NetAtmo credentials are read from. Disabled when empty.")
+	flagSet.StringVar(&cfg.VaultToken, flagVaultToken, cfg.VaultToken, "Token used for accessing Vault.")
+	flagSet.StringVar(&cfg.VaultMount, flagVaultMount, cfg.VaultMount, "Mount path of the KV version 2 secrets engine in Vault.")
+	flagSet.StringVar(&cfg.VaultSecretPath, flagVaultSecretPath, cfg.VaultSecretPath, "Path of the secret containing the NetAtmo credentials in Vault.")
+	flagSet.Float64Var(&cfg.TemperatureMin, flagTemperatureMin, cfg.TemperatureMin, "Temperature readings below this value are not exported.")
+	flagSet.Float64Var(&cfg.TemperatureMax, flagTemperatureMax, cfg.TemperatureMax, "Temperature readings above this value are not exported.")
+	flagSet.Float64Var(&cfg.HumidityMin, flagHumidityMin, cfg.HumidityMin, "Humidity readings below this value are not exported.")
+	flagSet.Float64Var(&cfg.HumidityMax, flagHumidityMax, cfg.HumidityMax, "Humidity readings above this value are not exported.")
+	flagSet.IntVar(&cfg.RoundTemperature, flagRoundTemperature, cfg.RoundTemperature, "Number of decimals temperatures are rounded to, at most 6. Negative values disable rounding.")
+	flagSet.IntVar(&cfg.RoundPressure, flagRoundPressure, cfg.RoundPressure, "Number of decimals pressures are rounded to, at most 6. Negative values disable rounding.")
+	flagSet.IntVar(&cfg.CO2AlertThreshold, flagCO2AlertThreshold, cfg.CO2AlertThreshold, "CO2 measurements above this value in ppm are reported as alert.")
+	flagSet.Var(newIntListValue(&cfg.HumidityComfort), flagHumidityComfort, "Range of comfortable humidity in percent. Humidity below the range is reported as too dry, above as too humid.")
+	flagSet.Var(newIntListValue(&cfg.WifiThresholds), flagWifiThresholds, "Raw wifi signal strength values at which the signal is considered bad and good. Lower values mean a better signal.")
+	flagSet.Var(newIntListValue(&cfg.RFThresholds), flagRFThresholds, "Raw RF signal strength values at which the signal is considered bad and good. Lower values mean a better signal.")
+	flagSet.BoolVar(&cfg.DisableRuntimeMetrics, flagDisableRuntimeMetrics, cfg.DisableRuntimeMetrics, "Do not export the Go runtime and process metrics of the exporter.")
+	flagSet.BoolVar(&cfg.DisableCompression, flagDisableCompression, cfg.DisableCompression, "Disables compression of the metrics response.")
+	flagSet.IntVar(&cfg.MaxRequestsInFlight, flagMaxRequestsInFlight, cfg.MaxRequestsInFlight, "Maximum number of concurrent requests to the metrics endpoint. Unlimited when zero.")
+	flagSet.DurationVar(&cfg.ScrapeTimeout, flagScrapeTimeout, cfg.ScrapeTimeout, "Time after which requests to the metrics endpoint are aborted. Disabled when zero.")
+	flagSet.DurationVar(&cfg.PeakWindow, flagPeakWindow, cfg.PeakWindow, "Time window used for the highest CO2 and noise measurements. Disabled when zero.")
+	flagSet.DurationVar(&cfg.StartupGrace, flagStartupGrace, cfg.StartupGrace, "Time after startup during which netatmo_up is not reported until data has been read. Disabled when zero.")
+	flagSet.BoolVar(&cfg.FailScrapeOnError, flagFailScrapeOnError, cfg.FailScrapeOnError, "Fail requests to the metrics endpoint, when the last refresh was not successful.")
+	flagSet.BoolVar(&cfg.SkipUnreachable, flagSkipUnreachable, cfg.SkipUnreachable, "Do not export sensor metrics of modules, which the NetAtmo API reports as unreachable.")
+	flagSet.StringVar(&cfg.UserAgent, flagUserAgent, cfg.UserAgent, "User-Agent used for requests to the NetAtmo API. Defaults to \"netatmo-exporter/<version>\".")
 
 	if err := flagSet.Parse(args[1:]); err != nil {
 		return Config{}, err
@@ -110,12 +391,59 @@ func Parse(args []string, getEnv func(string) string) (Config, error) {
 		return Config{}, fmt.Errorf("error in environment: %s", err)
 	}
 
-	if len(cfg.Addr) == 0 {
+	if len(cfg.Addrs) == 0 {
 		return Config{}, errNoListenAddress
 	}
 
+	var tcpAddrs []string
+	for _, addr := range cfg.Addrs {
+		if path, ok := strings.CutPrefix(addr, UnixSocketPrefix); ok {
+			if path == "" {
+				return Config{}, errInvalidListenAddress
+			}
+
+			continue
+		}
+
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return Config{}, errInvalidListenAddress
+		}
+		tcpAddrs = append(tcpAddrs, addr)
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return Config{}, errIncompleteTLS
+	}
+
+	if cfg.ClientCAFile != "" && cfg.TLSCertFile == "" {
+		return Config{}, errClientCAWithoutTLS
+	}
+
+	if cfg.ExternalURL != "" {
+		externalURL, err := url.Parse(cfg.ExternalURL)
+		if err != nil || !externalURL.IsAbs() {
+			return Config{}, errInvalidExternalURL
+		}
+		cfg.ExternalURL = strings.TrimSuffix(cfg.ExternalURL, "/")
+
+		if cfg.RoutePrefix == "" {
+			// Like Prometheus, the handlers are served below the path of the external URL by default.
+			cfg.RoutePrefix = externalURL.Path
+		}
+	}
+
+	routePrefix, err := cleanRoutePrefix(cfg.RoutePrefix)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.RoutePrefix = routePrefix
+
 	if cfg.ExternalURL == "" {
-		host, port, err := net.SplitHostPort(cfg.Addr)
+		if len(tcpAddrs) == 0 {
+			return Config{}, errNoExternalURL
+		}
+
+		host, port, err := net.SplitHostPort(tcpAddrs[0])
 		if err != nil {
 			return Config{}, fmt.Errorf("error generating external URL from listen address: %w", err)
 		}
@@ -124,37 +452,228 @@ func Parse(args []string, getEnv func(string) string) (Config, error) {
 			host = "127.0.0.1"
 		}
 
-		cfg.ExternalURL = fmt.Sprintf("http://%s:%s", host, port)
+		scheme := "http://"
+		if cfg.TLSCertFile != "" {
+			scheme = "https://"
+		}
+
+		cfg.ExternalURL = scheme + net.JoinHostPort(host, port) + cfg.RoutePrefix
+	}
+
+	if cfg.FromFile != "" {
+		// The data is not read from the NetAtmo API, so no credentials are needed.
+		if cfg.Netatmo.ClientID != "" || cfg.Netatmo.ClientSecret != "" {
+			return Config{}, errFromFileCredentials
+		}
+
+		if cfg.EnableHomeCoach || cfg.EnableEnergy || len(cfg.StationIDs) > 0 || cfg.SharedCacheFile != "" || cfg.HistoryHours > 0 || cfg.VaultURL != "" {
+			return Config{}, errFromFileAPIOptions
+		}
+	} else {
+		// Vault also stores the refresh token, so the token file is optional when using it.
+		if cfg.TokenFile == "" && cfg.VaultURL == "" {
+			return Config{}, errNoTokenFile
+		}
+
+		// The credentials can also be stored in Vault, which is only read after parsing the configuration.
+		if len(cfg.Netatmo.ClientID) == 0 && cfg.VaultURL == "" {
+			return Config{}, errNoNetatmoClientID
+		}
+
+		if len(cfg.Netatmo.ClientSecret) == 0 && cfg.VaultURL == "" {
+			return Config{}, errNoNetatmoClientSecret
+		}
+	}
+
+	if cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			return Config{}, errInvalidTimezone
+		}
+	}
+
+	if cfg.RefreshJitter < 0 {
+		return Config{}, errNegativeRefreshJitter
 	}
 
-	if cfg.TokenFile == "" {
-		return Config{}, errNoTokenFile
+	if cfg.AdaptiveRefreshMin <= 0 || cfg.AdaptiveRefreshMin > cfg.AdaptiveRefreshMax {
+		return Config{}, errInvalidAdaptiveRefresh
 	}
 
-	if len(cfg.Netatmo.ClientID) == 0 {
-		return Config{}, errNoNetatmoClientID
+	if cfg.ReadRetries < 0 {
+		return Config{}, errNegativeReadRetries
 	}
 
-	if len(cfg.Netatmo.ClientSecret) == 0 {
-		return Config{}, errNoNetatmoClientSecret
+	if cfg.ReadRetryDelay < 0 {
+		return Config{}, errNegativeReadRetryDelay
 	}
 
 	if cfg.StaleDuration < cfg.RefreshInterval {
 		return Config{}, fmt.Errorf("stale duration smaller than refresh interval: %s < %s", cfg.StaleDuration, cfg.RefreshInterval)
 	}
 
+	if cfg.ProxyURL != "" {
+		if err := validateProxyURL(cfg.ProxyURL); err != nil {
+			return Config{}, err
+		}
+	}
+
+	apiURL, err := url.Parse(cfg.APIURL)
+	if err != nil || !apiURL.IsAbs() || apiURL.Host == "" {
+		return Config{}, errInvalidAPIURL
+	}
+
+	if cfg.HistoryHours < 0 {
+		return Config{}, errNegativeHistoryHours
+	}
+
+	if cfg.MaxRequestsInFlight < 0 {
+		return Config{}, errNegativeMaxRequests
+	}
+
+	if cfg.ScrapeTimeout < 0 {
+		return Config{}, errNegativeScrapeTimeout
+	}
+
+	if cfg.StartupGrace < 0 {
+		return Config{}, errNegativeStartupGrace
+	}
+
+	if cfg.PeakWindow < 0 {
+		return Config{}, errNegativePeakWindow
+	}
+
+	if cfg.TemperatureMin > cfg.TemperatureMax {
+		return Config{}, errInvalidTemperatureRange
+	}
+
+	if cfg.HumidityMin > cfg.HumidityMax {
+		return Config{}, errInvalidHumidityRange
+	}
+
+	if cfg.RoundTemperature > maxRoundingDecimals || cfg.RoundPressure > maxRoundingDecimals {
+		return Config{}, errInvalidRounding
+	}
+
+	for name := range cfg.ExtraLabels {
+		if !model.LabelName(name).IsValidLegacy() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return Config{}, errInvalidExtraLabelName
+		}
+	}
+
+	if cfg.CO2AlertThreshold <= 0 {
+		return Config{}, errInvalidCO2Threshold
+	}
+
+	if len(cfg.HumidityComfort) != 2 || cfg.HumidityComfort[0] < 0 || cfg.HumidityComfort[0] > cfg.HumidityComfort[1] || cfg.HumidityComfort[1] > 100 {
+		return Config{}, errInvalidHumidityComfort
+	}
+
+	if !validThresholds(cfg.WifiThresholds) {
+		return Config{}, errInvalidWifiThresholds
+	}
+
+	if !validThresholds(cfg.RFThresholds) {
+		return Config{}, errInvalidRFThresholds
+	}
+
+	if cfg.PushgatewayURL != "" {
+		pushgatewayURL, err := url.Parse(cfg.PushgatewayURL)
+		if err != nil || !pushgatewayURL.IsAbs() || pushgatewayURL.Host == "" {
+			return Config{}, errInvalidPushgatewayURL
+		}
+	}
+
+	if cfg.VaultURL != "" {
+		vaultURL, err := url.Parse(cfg.VaultURL)
+		if err != nil || !vaultURL.IsAbs() || vaultURL.Host == "" {
+			return Config{}, errInvalidVaultURL
+		}
+
+		if cfg.VaultToken == "" {
+			return Config{}, errNoVaultToken
+		}
+
+		if cfg.VaultSecretPath == "" {
+			return Config{}, errNoVaultSecretPath
+		}
+	}
+
 	return cfg, nil
 }
 
+// validThresholds checks that there is a bad and a good value. Lower values mean a better signal.
+func validThresholds(thresholds []int) bool {
+	return len(thresholds) == 2 && thresholds[0] > thresholds[1] && thresholds[1] >= 0
+}
+
+func validateProxyURL(rawURL string) error {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("error parsing proxy URL: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return errInvalidProxyScheme
+	}
+
+	if proxyURL.Host == "" {
+		return errNoProxyHost
+	}
+
+	return nil
+}
+
+// envSecret returns the value of the environment variable envVar. If envVar with the suffix "_FILE" is set instead,
+// the value is read from the file it points to, so secrets can be mounted as Docker or Kubernetes secrets.
+func envSecret(getenv func(string) string, envVar string) (string, error) {
+	fileVar := envVar + "_FILE"
+	fileName := getenv(fileVar)
+	if fileName == "" {
+		return getenv(envVar), nil
+	}
+
+	if getenv(envVar) != "" {
+		return "", fmt.Errorf("%w: %s and %s", errSecretFileConflict, envVar, fileVar)
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", fileVar, err)
+	}
+
+	// Files created by editors or using echo usually end with a newline, which is not part of the secret.
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// cleanRoutePrefix removes a trailing slash from the prefix. The root path results in an empty prefix.
+func cleanRoutePrefix(prefix string) (string, error) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return "", nil
+	}
+
+	if !strings.HasPrefix(prefix, "/") || path.Clean(prefix) != prefix || strings.ContainsAny(prefix, "?#") {
+		return "", errInvalidRoutePrefix
+	}
+
+	return prefix, nil
+}
+
 func applyEnvironment(cfg *Config, getenv func(string) string) error {
 	if envAddr := getenv(envVarListenAddress); envAddr != "" {
-		cfg.Addr = envAddr
+		cfg.Addrs = splitList(envAddr)
 	}
 
 	if externalURL := getenv(envVarExternalURL); externalURL != "" {
 		cfg.ExternalURL = externalURL
 	}
 
+	if routePrefix := getenv(envVarRoutePrefix); routePrefix != "" {
+		cfg.RoutePrefix = routePrefix
+	}
+
 	if tokenFile := getenv(envVarTokenFile); tokenFile != "" {
 		cfg.TokenFile = tokenFile
 	}
@@ -169,6 +688,10 @@ func applyEnvironment(cfg *Config, getenv func(string) string) error {
 		}
 	}
 
+	if timezone := getenv(envVarTimezone); timezone != "" {
+		cfg.Timezone = timezone
+	}
+
 	if envRefreshInterval := getenv(envVarRefreshInterval); envRefreshInterval != "" {
 		duration, err := time.ParseDuration(envRefreshInterval)
 		if err != nil {
@@ -178,6 +701,37 @@ func applyEnvironment(cfg *Config, getenv func(string) string) error {
 		cfg.RefreshInterval = duration
 	}
 
+	if envRefreshJitter := getenv(envVarRefreshJitter); envRefreshJitter != "" {
+		duration, err := time.ParseDuration(envRefreshJitter)
+		if err != nil {
+			return err
+		}
+
+		cfg.RefreshJitter = duration
+	}
+
+	if envAdaptiveRefresh := getenv(envVarAdaptiveRefresh); envAdaptiveRefresh != "" {
+		cfg.AdaptiveRefresh = true
+	}
+
+	if envAdaptiveRefreshMin := getenv(envVarAdaptiveRefreshMin); envAdaptiveRefreshMin != "" {
+		duration, err := time.ParseDuration(envAdaptiveRefreshMin)
+		if err != nil {
+			return err
+		}
+
+		cfg.AdaptiveRefreshMin = duration
+	}
+
+	if envAdaptiveRefreshMax := getenv(envVarAdaptiveRefreshMax); envAdaptiveRefreshMax != "" {
+		duration, err := time.ParseDuration(envAdaptiveRefreshMax)
+		if err != nil {
+			return err
+		}
+
+		cfg.AdaptiveRefreshMax = duration
+	}
+
 	if envStaleDuration := getenv(envVarStaleDuration); envStaleDuration != "" {
 		duration, err := time.ParseDuration(envStaleDuration)
 		if err != nil {
@@ -187,13 +741,303 @@ func applyEnvironment(cfg *Config, getenv func(string) string) error {
 		cfg.StaleDuration = duration
 	}
 
-	if envClientID := getenv(envVarNetatmoClientID); envClientID != "" {
+	envClientID, err := envSecret(getenv, envVarNetatmoClientID)
+	if err != nil {
+		return err
+	}
+	if envClientID != "" {
 		cfg.Netatmo.ClientID = envClientID
 	}
 
-	if envClientSecret := getenv(envVarNetatmoClientSecret); envClientSecret != "" {
+	envClientSecret, err := envSecret(getenv, envVarNetatmoClientSecret)
+	if err != nil {
+		return err
+	}
+	if envClientSecret != "" {
 		cfg.Netatmo.ClientSecret = envClientSecret
 	}
 
+	if envProxyURL := getenv(envVarProxyURL); envProxyURL != "" {
+		cfg.ProxyURL = envProxyURL
+	}
+
+	if envUserAgent := getenv(envVarUserAgent); envUserAgent != "" {
+		cfg.UserAgent = envUserAgent
+	}
+
+	if envCACertFile := getenv(envVarCACertFile); envCACertFile != "" {
+		cfg.CACertFile = envCACertFile
+	}
+
+	if envTLSCertFile := getenv(envVarTLSCertFile); envTLSCertFile != "" {
+		cfg.TLSCertFile = envTLSCertFile
+	}
+
+	if envTLSKeyFile := getenv(envVarTLSKeyFile); envTLSKeyFile != "" {
+		cfg.TLSKeyFile = envTLSKeyFile
+	}
+
+	if envClientCAFile := getenv(envVarClientCAFile); envClientCAFile != "" {
+		cfg.ClientCAFile = envClientCAFile
+	}
+
+	envAuthBearerToken, err := envSecret(getenv, envVarAuthBearerToken)
+	if err != nil {
+		return err
+	}
+	if envAuthBearerToken != "" {
+		cfg.AuthBearerToken = envAuthBearerToken
+	}
+
+	if envPushgatewayURL := getenv(envVarPushgatewayURL); envPushgatewayURL != "" {
+		cfg.PushgatewayURL = envPushgatewayURL
+	}
+
+	if envVaultURL := getenv(envVarVaultURL); envVaultURL != "" {
+		cfg.VaultURL = envVaultURL
+	}
+
+	envVaultToken, err := envSecret(getenv, envVarVaultToken)
+	if err != nil {
+		return err
+	}
+	if envVaultToken != "" {
+		cfg.VaultToken = envVaultToken
+	}
+
+	if envVaultMount := getenv(envVarVaultMount); envVaultMount != "" {
+		cfg.VaultMount = envVaultMount
+	}
+
+	if envVaultSecretPath := getenv(envVarVaultSecretPath); envVaultSecretPath != "" {
+		cfg.VaultSecretPath = envVaultSecretPath
+	}
+
+	if envDryRun := getenv(envVarDryRun); envDryRun != "" {
+		cfg.DryRun = true
+	}
+
+	if envOnce := getenv(envVarOnce); envOnce != "" {
+		cfg.Once = true
+	}
+
+	if envDisableRuntimeMetrics := getenv(envVarDisableRuntimeMetrics); envDisableRuntimeMetrics != "" {
+		cfg.DisableRuntimeMetrics = true
+	}
+
+	if envEnableHomeCoach := getenv(envVarEnableHomeCoach); envEnableHomeCoach != "" {
+		cfg.EnableHomeCoach = true
+	}
+
+	if envEnableInflux := getenv(envVarEnableInflux); envEnableInflux != "" {
+		cfg.EnableInflux = true
+	}
+
+	if envEnableEnergy := getenv(envVarEnableEnergy); envEnableEnergy != "" {
+		cfg.EnableEnergy = true
+	}
+
+	if envAPIURL := getenv(envVarAPIURL); envAPIURL != "" {
+		cfg.APIURL = envAPIURL
+	}
+
+	if envReadRetries := getenv(envVarReadRetries); envReadRetries != "" {
+		retries, err := strconv.Atoi(envReadRetries)
+		if err != nil {
+			return err
+		}
+
+		cfg.ReadRetries = retries
+	}
+
+	if envReadRetryDelay := getenv(envVarReadRetryDelay); envReadRetryDelay != "" {
+		duration, err := time.ParseDuration(envReadRetryDelay)
+		if err != nil {
+			return err
+		}
+
+		cfg.ReadRetryDelay = duration
+	}
+
+	if envHistoryHours := getenv(envVarHistoryHours); envHistoryHours != "" {
+		hours, err := strconv.Atoi(envHistoryHours)
+		if err != nil {
+			return err
+		}
+
+		cfg.HistoryHours = hours
+	}
+
+	if envTemperatureMin := getenv(envVarTemperatureMin); envTemperatureMin != "" {
+		value, err := strconv.ParseFloat(envTemperatureMin, 64)
+		if err != nil {
+			return err
+		}
+
+		cfg.TemperatureMin = value
+	}
+
+	if envTemperatureMax := getenv(envVarTemperatureMax); envTemperatureMax != "" {
+		value, err := strconv.ParseFloat(envTemperatureMax, 64)
+		if err != nil {
+			return err
+		}
+
+		cfg.TemperatureMax = value
+	}
+
+	if envHumidityMin := getenv(envVarHumidityMin); envHumidityMin != "" {
+		value, err := strconv.ParseFloat(envHumidityMin, 64)
+		if err != nil {
+			return err
+		}
+
+		cfg.HumidityMin = value
+	}
+
+	if envHumidityMax := getenv(envVarHumidityMax); envHumidityMax != "" {
+		value, err := strconv.ParseFloat(envHumidityMax, 64)
+		if err != nil {
+			return err
+		}
+
+		cfg.HumidityMax = value
+	}
+
+	if envRoundTemperature := getenv(envVarRoundTemperature); envRoundTemperature != "" {
+		decimals, err := strconv.Atoi(envRoundTemperature)
+		if err != nil {
+			return err
+		}
+
+		cfg.RoundTemperature = decimals
+	}
+
+	if envRoundPressure := getenv(envVarRoundPressure); envRoundPressure != "" {
+		decimals, err := strconv.Atoi(envRoundPressure)
+		if err != nil {
+			return err
+		}
+
+		cfg.RoundPressure = decimals
+	}
+
+	if envStationIDs := getenv(envVarStationIDs); envStationIDs != "" {
+		cfg.StationIDs = splitList(envStationIDs)
+	}
+
+	if envFromFile := getenv(envVarFromFile); envFromFile != "" {
+		cfg.FromFile = envFromFile
+	}
+
+	if envSharedCacheFile := getenv(envVarSharedCacheFile); envSharedCacheFile != "" {
+		cfg.SharedCacheFile = envSharedCacheFile
+	}
+
+	if envDisableCompression := getenv(envVarDisableCompression); envDisableCompression != "" {
+		cfg.DisableCompression = true
+	}
+
+	if envMaxRequestsInFlight := getenv(envVarMaxRequestsInFlight); envMaxRequestsInFlight != "" {
+		maxRequests, err := strconv.Atoi(envMaxRequestsInFlight)
+		if err != nil {
+			return err
+		}
+
+		cfg.MaxRequestsInFlight = maxRequests
+	}
+
+	if envScrapeTimeout := getenv(envVarScrapeTimeout); envScrapeTimeout != "" {
+		duration, err := time.ParseDuration(envScrapeTimeout)
+		if err != nil {
+			return err
+		}
+
+		cfg.ScrapeTimeout = duration
+	}
+
+	if envPeakWindow := getenv(envVarPeakWindow); envPeakWindow != "" {
+		duration, err := time.ParseDuration(envPeakWindow)
+		if err != nil {
+			return err
+		}
+
+		cfg.PeakWindow = duration
+	}
+
+	if envStartupGrace := getenv(envVarStartupGrace); envStartupGrace != "" {
+		duration, err := time.ParseDuration(envStartupGrace)
+		if err != nil {
+			return err
+		}
+
+		cfg.StartupGrace = duration
+	}
+
+	if envFailScrapeOnError := getenv(envVarFailScrapeOnError); envFailScrapeOnError != "" {
+		cfg.FailScrapeOnError = true
+	}
+
+	if envSkipUnreachable := getenv(envVarSkipUnreachable); envSkipUnreachable != "" {
+		cfg.SkipUnreachable = true
+	}
+
+	if envIncludeStations := getenv(envVarIncludeStations); envIncludeStations != "" {
+		cfg.IncludeStations = splitList(envIncludeStations)
+	}
+
+	if envExcludeStations := getenv(envVarExcludeStations); envExcludeStations != "" {
+		cfg.ExcludeStations = splitList(envExcludeStations)
+	}
+
+	if envStationLabelTemplate := getenv(envVarStationLabelTemplate); envStationLabelTemplate != "" {
+		cfg.StationLabelTemplate = envStationLabelTemplate
+	}
+
+	if envExtraLabels := getenv(envVarExtraLabels); envExtraLabels != "" {
+		labels, err := parseLabels(envExtraLabels)
+		if err != nil {
+			return err
+		}
+
+		cfg.ExtraLabels = labels
+	}
+
+	if envCO2AlertThreshold := getenv(envVarCO2AlertThreshold); envCO2AlertThreshold != "" {
+		threshold, err := strconv.Atoi(envCO2AlertThreshold)
+		if err != nil {
+			return err
+		}
+
+		cfg.CO2AlertThreshold = threshold
+	}
+
+	if envHumidityComfort := getenv(envVarHumidityComfort); envHumidityComfort != "" {
+		comfort, err := parseIntList(envHumidityComfort)
+		if err != nil {
+			return err
+		}
+
+		cfg.HumidityComfort = comfort
+	}
+
+	if envWifiThresholds := getenv(envVarWifiThresholds); envWifiThresholds != "" {
+		thresholds, err := parseIntList(envWifiThresholds)
+		if err != nil {
+			return err
+		}
+
+		cfg.WifiThresholds = thresholds
+	}
+
+	if envRFThresholds := getenv(envVarRFThresholds); envRFThresholds != "" {
+		thresholds, err := parseIntList(envRFThresholds)
+		if err != nil {
+			return err
+		}
+
+		cfg.RFThresholds = thresholds
+	}
+
 	return nil
 }
@@ -1,15 +1,25 @@
 package config
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	netatmo "github.com/exzz/netatmo-api-go"
 	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+	"github.com/xperimental/netatmo-exporter/v2/internal/transport"
+	"github.com/xperimental/netatmo-exporter/v2/internal/vault"
 )
 
 func TestParseConfig(t *testing.T) {
+	clientIDFile := writeSecretFile(t, "id")
+	clientSecretFile := writeSecretFile(t, "secret\n")
+
 	tests := []struct {
 		name       string
 		args       []string
@@ -37,12 +47,69 @@ func TestParseConfig(t *testing.T) {
 			},
 			env: map[string]string{},
 			wantConfig: Config{
-				Addr:            defaultConfig.Addr,
-				ExternalURL:     "http://127.0.0.1:9210",
-				TokenFile:       "token-file",
-				LogLevel:        logLevel(logrus.InfoLevel),
-				RefreshInterval: defaultRefreshInterval,
-				StaleDuration:   defaultStaleDuration,
+				Addrs:              defaultConfig.Addrs,
+				ExternalURL:        "http://127.0.0.1:9210",
+				TokenFile:          "token-file",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+				Netatmo: netatmo.Config{
+					ClientID:     "id",
+					ClientSecret: "secret",
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "secrets from files",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+			},
+			env: map[string]string{
+				envVarNetatmoClientID + "_FILE":     clientIDFile,
+				envVarNetatmoClientSecret + "_FILE": clientSecretFile,
+			},
+			wantConfig: Config{
+				Addrs:              defaultConfig.Addrs,
+				ExternalURL:        "http://127.0.0.1:9210",
+				TokenFile:          "token-file",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
 				Netatmo: netatmo.Config{
 					ClientID:     "id",
 					ClientSecret: "secret",
@@ -56,22 +123,123 @@ func TestParseConfig(t *testing.T) {
 				"test-cmd",
 			},
 			env: map[string]string{
-				envVarListenAddress:       ":8080",
-				envVarExternalURL:         "http://example.com",
-				envVarTokenFile:           "token.json",
-				envVarLogLevel:            "debug",
-				envVarRefreshInterval:     "5m",
-				envVarStaleDuration:       "10m",
-				envVarNetatmoClientID:     "id",
-				envVarNetatmoClientSecret: "secret",
+				envVarListenAddress:         ":8080,127.0.0.1:9090",
+				envVarExternalURL:           "http://example.com",
+				envVarRoutePrefix:           "/netatmo",
+				envVarTokenFile:             "token.json",
+				envVarLogLevel:              "debug",
+				envVarTimezone:              "Europe/Berlin",
+				envVarRefreshInterval:       "5m",
+				envVarRefreshJitter:         "30s",
+				envVarAdaptiveRefresh:       "true",
+				envVarAdaptiveRefreshMin:    "2m",
+				envVarAdaptiveRefreshMax:    "20m",
+				envVarStaleDuration:         "10m",
+				envVarNetatmoClientID:       "id",
+				envVarNetatmoClientSecret:   "secret",
+				envVarProxyURL:              "socks5://proxy:1080",
+				envVarUserAgent:             "test-agent",
+				envVarCACertFile:            "ca.pem",
+				envVarEnableInflux:          "true",
+				envVarTLSCertFile:           "server.pem",
+				envVarTLSKeyFile:            "server-key.pem",
+				envVarClientCAFile:          "client-ca.pem",
+				envVarAuthBearerToken:       "bearer-token",
+				envVarVaultURL:              "https://vault:8200",
+				envVarVaultToken:            "vault-token",
+				envVarVaultMount:            "kv",
+				envVarVaultSecretPath:       "netatmo-exporter",
+				envVarPushgatewayURL:        "http://pushgateway:9091",
+				envVarTemperatureMin:        "-40",
+				envVarTemperatureMax:        "65",
+				envVarHumidityMin:           "1",
+				envVarHumidityMax:           "99.5",
+				envVarRoundTemperature:      "1",
+				envVarRoundPressure:         "0",
+				envVarCO2AlertThreshold:     "1400",
+				envVarHumidityComfort:       "30, 50",
+				envVarDisableRuntimeMetrics: "true",
+				envVarDryRun:                "true",
+				envVarOnce:                  "true",
+				envVarEnableHomeCoach:       "true",
+				envVarEnableEnergy:          "true",
+				envVarAPIURL:                "http://localhost:8080/netatmo/",
+				envVarHistoryHours:          "24",
+				envVarIncludeStations:       "Home, 70:ee:50:00:00:01",
+				envVarStationIDs:            "70:ee:50:00:00:01,70:ee:50:00:00:02",
+				envVarSharedCacheFile:       "/shared/cache.json",
+				envVarDisableCompression:    "true",
+				envVarMaxRequestsInFlight:   "5",
+				envVarScrapeTimeout:         "10s",
+				envVarFailScrapeOnError:     "true",
+				envVarSkipUnreachable:       "true",
+				envVarStartupGrace:          "2m",
+				envVarPeakWindow:            "6h",
+				envVarReadRetries:           "2",
+				envVarReadRetryDelay:        "30s",
+				envVarStationLabelTemplate:  "{{ .ID }}",
+				envVarWifiThresholds:        "80,60",
+				envVarRFThresholds:          "85, 65",
+				envVarExtraLabels:           "environment=prod, site=hq",
+				envVarExcludeStations:       "Office,",
 			},
 			wantConfig: Config{
-				Addr:            ":8080",
-				ExternalURL:     "http://example.com",
-				TokenFile:       "token.json",
-				LogLevel:        logLevel(logrus.DebugLevel),
-				RefreshInterval: 5 * time.Minute,
-				StaleDuration:   10 * time.Minute,
+				Addrs:                 []string{":8080", "127.0.0.1:9090"},
+				ExternalURL:           "http://example.com",
+				RoutePrefix:           "/netatmo",
+				TokenFile:             "token.json",
+				LogLevel:              logLevel(logrus.DebugLevel),
+				Timezone:              "Europe/Berlin",
+				RefreshInterval:       5 * time.Minute,
+				RefreshJitter:         30 * time.Second,
+				AdaptiveRefresh:       true,
+				AdaptiveRefreshMin:    2 * time.Minute,
+				AdaptiveRefreshMax:    20 * time.Minute,
+				StaleDuration:         10 * time.Minute,
+				ProxyURL:              "socks5://proxy:1080",
+				UserAgent:             "test-agent",
+				CACertFile:            "ca.pem",
+				EnableInflux:          true,
+				TLSCertFile:           "server.pem",
+				TLSKeyFile:            "server-key.pem",
+				ClientCAFile:          "client-ca.pem",
+				AuthBearerToken:       "bearer-token",
+				PushgatewayURL:        "http://pushgateway:9091",
+				VaultURL:              "https://vault:8200",
+				VaultToken:            "vault-token",
+				VaultMount:            "kv",
+				VaultSecretPath:       "netatmo-exporter",
+				TemperatureMin:        -40,
+				TemperatureMax:        65,
+				HumidityMin:           1,
+				HumidityMax:           99.5,
+				RoundTemperature:      1,
+				CO2AlertThreshold:     1400,
+				HumidityComfort:       []int{30, 50},
+				DisableRuntimeMetrics: true,
+				DryRun:                true,
+				Once:                  true,
+				EnableHomeCoach:       true,
+				EnableEnergy:          true,
+				APIURL:                "http://localhost:8080/netatmo/",
+				HistoryHours:          24,
+				IncludeStations:       []string{"Home", "70:ee:50:00:00:01"},
+				StationIDs:            []string{"70:ee:50:00:00:01", "70:ee:50:00:00:02"},
+				SharedCacheFile:       "/shared/cache.json",
+				DisableCompression:    true,
+				MaxRequestsInFlight:   5,
+				ScrapeTimeout:         10 * time.Second,
+				FailScrapeOnError:     true,
+				SkipUnreachable:       true,
+				StartupGrace:          2 * time.Minute,
+				PeakWindow:            6 * time.Hour,
+				ReadRetries:           2,
+				ReadRetryDelay:        30 * time.Second,
+				StationLabelTemplate:  "{{ .ID }}",
+				WifiThresholds:        []int{80, 60},
+				RFThresholds:          []int{85, 65},
+				ExtraLabels:           map[string]string{"environment": "prod", "site": "hq"},
+				ExcludeStations:       []string{"Office"},
 				Netatmo: netatmo.Config{
 					ClientID:     "id",
 					ClientSecret: "secret",
@@ -92,7 +260,7 @@ func TestParseConfig(t *testing.T) {
 			},
 			env: map[string]string{},
 			wantConfig: Config{
-				Addr: defaultConfig.Addr,
+				Addrs: defaultConfig.Addrs,
 				Netatmo: netatmo.Config{
 					ClientID:     "id",
 					ClientSecret: "secret",
@@ -100,6 +268,333 @@ func TestParseConfig(t *testing.T) {
 			},
 			wantErr: errNoListenAddress,
 		},
+		{
+			name: "invalid addr",
+			args: []string{
+				"test-cmd",
+				"--" + flagListenAddress,
+				":9210,localhost",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidListenAddress,
+		},
+		{
+			name: "unix socket",
+			args: []string{
+				"test-cmd",
+				"--" + flagListenAddress,
+				"unix:/run/netatmo-exporter.sock,[::1]:9210",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env: map[string]string{},
+			wantConfig: Config{
+				Addrs:              []string{"unix:/run/netatmo-exporter.sock", "[::1]:9210"},
+				ExternalURL:        "http://[::1]:9210",
+				TokenFile:          "token-file",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+				Netatmo: netatmo.Config{
+					ClientID:     "id",
+					ClientSecret: "secret",
+				},
+			},
+		},
+		{
+			name: "from file",
+			args: []string{
+				"test-cmd",
+			},
+			env: map[string]string{
+				envVarFromFile: "/data/capture.json",
+			},
+			wantConfig: Config{
+				Addrs:              []string{":9210"},
+				ExternalURL:        "http://127.0.0.1:9210",
+				FromFile:           "/data/capture.json",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+			},
+		},
+		{
+			name: "from file with credentials",
+			args: []string{
+				"test-cmd",
+				"--" + flagFromFile,
+				"/data/capture.json",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env:     map[string]string{},
+			wantErr: errFromFileCredentials,
+		},
+		{
+			name: "from file with home coach",
+			args: []string{
+				"test-cmd",
+				"--" + flagFromFile,
+				"/data/capture.json",
+				"--" + flagEnableHomeCoach,
+			},
+			env:     map[string]string{},
+			wantErr: errFromFileAPIOptions,
+		},
+		{
+			name: "route prefix",
+			args: []string{
+				"test-cmd",
+				"--" + flagRoutePrefix,
+				"/netatmo/",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env: map[string]string{},
+			wantConfig: Config{
+				Addrs:              defaultConfig.Addrs,
+				ExternalURL:        "http://127.0.0.1:9210/netatmo",
+				RoutePrefix:        "/netatmo",
+				TokenFile:          "token-file",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+				Netatmo: netatmo.Config{
+					ClientID:     "id",
+					ClientSecret: "secret",
+				},
+			},
+		},
+		{
+			name: "route prefix from external url",
+			args: []string{
+				"test-cmd",
+				"--" + flagExternalURL,
+				"https://example.com/netatmo/",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env: map[string]string{},
+			wantConfig: Config{
+				Addrs:              defaultConfig.Addrs,
+				ExternalURL:        "https://example.com/netatmo",
+				RoutePrefix:        "/netatmo",
+				TokenFile:          "token-file",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+				Netatmo: netatmo.Config{
+					ClientID:     "id",
+					ClientSecret: "secret",
+				},
+			},
+		},
+		{
+			name: "root route prefix with external url path",
+			args: []string{
+				"test-cmd",
+				"--" + flagExternalURL,
+				"https://example.com/netatmo",
+				"--" + flagRoutePrefix,
+				"/",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env: map[string]string{},
+			wantConfig: Config{
+				Addrs:              defaultConfig.Addrs,
+				ExternalURL:        "https://example.com/netatmo",
+				RoutePrefix:        "",
+				TokenFile:          "token-file",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+				Netatmo: netatmo.Config{
+					ClientID:     "id",
+					ClientSecret: "secret",
+				},
+			},
+		},
+		{
+			name: "relative route prefix",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagRoutePrefix,
+				"netatmo",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidRoutePrefix,
+		},
+		{
+			name: "unclean route prefix",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagRoutePrefix,
+				"/netatmo/../metrics",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidRoutePrefix,
+		},
+		{
+			name: "relative external url",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagExternalURL,
+				"example.com/netatmo",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidExternalURL,
+		},
+		{
+			name: "only unix socket",
+			args: []string{
+				"test-cmd",
+				"--" + flagListenAddress,
+				"unix:/run/netatmo-exporter.sock",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env:     map[string]string{},
+			wantErr: errNoExternalURL,
+		},
+		{
+			name: "unix socket without path",
+			args: []string{
+				"test-cmd",
+				"--" + flagListenAddress,
+				"unix:",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidListenAddress,
+		},
 		{
 			name: "no token file",
 			args: []string{
@@ -107,7 +602,7 @@ func TestParseConfig(t *testing.T) {
 			},
 			env: map[string]string{},
 			wantConfig: Config{
-				Addr: defaultConfig.Addr,
+				Addrs: defaultConfig.Addrs,
 				Netatmo: netatmo.Config{
 					ClientID:     "id",
 					ClientSecret: "secret",
@@ -126,7 +621,7 @@ func TestParseConfig(t *testing.T) {
 			},
 			env: map[string]string{},
 			wantConfig: Config{
-				Addr: defaultConfig.Addr,
+				Addrs: defaultConfig.Addrs,
 				Netatmo: netatmo.Config{
 					ClientID:     "id",
 					ClientSecret: "secret",
@@ -145,7 +640,7 @@ func TestParseConfig(t *testing.T) {
 			},
 			env: map[string]string{},
 			wantConfig: Config{
-				Addr: defaultConfig.Addr,
+				Addrs: defaultConfig.Addrs,
 				Netatmo: netatmo.Config{
 					ClientID:     "id",
 					ClientSecret: "secret",
@@ -153,31 +648,597 @@ func TestParseConfig(t *testing.T) {
 			},
 			wantErr: errNoNetatmoClientSecret,
 		},
-	}
-
-	for _, tt := range tests {
-		tt := tt
-
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-
-			getenv := func(key string) string {
-				return tt.env[key]
-			}
-
-			config, err := Parse(tt.args, getenv)
-
-			if err != tt.wantErr {
-				t.Errorf("got error %q, want %q", err, tt.wantErr)
-			}
-
-			if err != nil {
-				return
-			}
-
-			if !reflect.DeepEqual(config, tt.wantConfig) {
-				t.Errorf("got config %v, want %v", config, tt.wantConfig)
-			}
-		})
-	}
+		{
+			name: "invalid proxy scheme",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagProxyURL,
+				"ftp://proxy:21",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidProxyScheme,
+		},
+		{
+			name: "proxy without host",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagProxyURL,
+				"http://",
+			},
+			env:     map[string]string{},
+			wantErr: errNoProxyHost,
+		},
+		{
+			name: "relative api url",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagAPIURL,
+				"/netatmo",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidAPIURL,
+		},
+		{
+			name: "negative history hours",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagHistoryHours,
+				"-1",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeHistoryHours,
+		},
+		{
+			name: "invalid timezone",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagTimezone,
+				"Mars/Olympus_Mons",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidTimezone,
+		},
+		{
+			name: "negative refresh jitter",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagRefreshJitter,
+				"-1m",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeRefreshJitter,
+		},
+		{
+			name: "adaptive refresh minimum larger than maximum",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagAdaptiveRefreshMin,
+				"20m",
+				"--" + flagAdaptiveRefreshMax,
+				"10m",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidAdaptiveRefresh,
+		},
+		{
+			name: "tls without key",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagTLSCertFile,
+				"server.pem",
+			},
+			env:     map[string]string{},
+			wantErr: errIncompleteTLS,
+		},
+		{
+			name: "client ca without tls",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagClientCAFile,
+				"client-ca.pem",
+			},
+			env:     map[string]string{},
+			wantErr: errClientCAWithoutTLS,
+		},
+		{
+			name: "relative pushgateway url",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagPushgatewayURL,
+				"pushgateway:9091",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidPushgatewayURL,
+		},
+		{
+			name: "credentials and token from vault",
+			args: []string{
+				"test-cmd",
+				"--" + flagVaultURL,
+				"https://vault:8200",
+				"--" + flagVaultToken,
+				"vault-token",
+				"--" + flagVaultSecretPath,
+				"netatmo-exporter",
+			},
+			env: map[string]string{},
+			wantConfig: Config{
+				Addrs:              defaultConfig.Addrs,
+				ExternalURL:        "http://127.0.0.1:9210",
+				LogLevel:           logLevel(logrus.InfoLevel),
+				RefreshInterval:    defaultRefreshInterval,
+				StaleDuration:      defaultStaleDuration,
+				APIURL:             transport.DefaultAPIURL,
+				TemperatureMin:     defaultTemperatureMin,
+				TemperatureMax:     defaultTemperatureMax,
+				HumidityMin:        defaultHumidityMin,
+				HumidityMax:        defaultHumidityMax,
+				PeakWindow:         defaultPeakWindow,
+				ReadRetryDelay:     defaultReadRetryDelay,
+				AdaptiveRefreshMin: defaultAdaptiveRefreshMin,
+				AdaptiveRefreshMax: defaultAdaptiveRefreshMax,
+				RoundTemperature:   int(collector.NoRounding),
+				RoundPressure:      int(collector.NoRounding),
+				CO2AlertThreshold:  collector.DefaultCO2AlertThreshold,
+				HumidityComfort:    defaultConfig.HumidityComfort,
+				VaultMount:         vault.DefaultMount,
+				WifiThresholds:     defaultConfig.WifiThresholds,
+				RFThresholds:       defaultConfig.RFThresholds,
+				VaultURL:           "https://vault:8200",
+				VaultToken:         "vault-token",
+				VaultSecretPath:    "netatmo-exporter",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "relative vault url",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagVaultURL,
+				"vault:8200",
+				"--" + flagVaultToken,
+				"vault-token",
+				"--" + flagVaultSecretPath,
+				"netatmo-exporter",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidVaultURL,
+		},
+		{
+			name: "vault without token",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagVaultURL,
+				"https://vault:8200",
+				"--" + flagVaultSecretPath,
+				"netatmo-exporter",
+			},
+			env:     map[string]string{},
+			wantErr: errNoVaultToken,
+		},
+		{
+			name: "vault without secret path",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagVaultURL,
+				"https://vault:8200",
+				"--" + flagVaultToken,
+				"vault-token",
+			},
+			env:     map[string]string{},
+			wantErr: errNoVaultSecretPath,
+		},
+		{
+			name: "invalid temperature range",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagTemperatureMin,
+				"30",
+				"--" + flagTemperatureMax,
+				"20",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidTemperatureRange,
+		},
+		{
+			name: "invalid humidity range",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagHumidityMin,
+				"101",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidHumidityRange,
+		},
+		{
+			name: "negative max requests in flight",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagMaxRequestsInFlight,
+				"-1",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeMaxRequests,
+		},
+		{
+			name: "negative scrape timeout",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagScrapeTimeout,
+				"-1s",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeScrapeTimeout,
+		},
+		{
+			name: "negative peak window",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagPeakWindow,
+				"-1h",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativePeakWindow,
+		},
+		{
+			name: "too many decimals for rounding",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagRoundPressure,
+				"7",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidRounding,
+		},
+		{
+			name: "negative read retries",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagReadRetries,
+				"-1",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeReadRetries,
+		},
+		{
+			name: "negative read retry delay",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagReadRetryDelay,
+				"-1s",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeReadRetryDelay,
+		},
+		{
+			name: "zero co2 alert threshold",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagCO2AlertThreshold,
+				"0",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidCO2Threshold,
+		},
+		{
+			name: "swapped humidity comfort range",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagHumidityComfort,
+				"60,40",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidHumidityComfort,
+		},
+		{
+			name: "humidity comfort above 100",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagHumidityComfort,
+				"40,120",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidHumidityComfort,
+		},
+		{
+			name: "wrong number of wifi thresholds",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagWifiThresholds,
+				"86",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidWifiThresholds,
+		},
+		{
+			name: "negative startup grace",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagStartupGrace,
+				"-1m",
+			},
+			env:     map[string]string{},
+			wantErr: errNegativeStartupGrace,
+		},
+		{
+			name: "invalid extra label name",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+				"--" + flagExtraLabels,
+				"data-center=hq",
+			},
+			env:     map[string]string{},
+			wantErr: errInvalidExtraLabelName,
+		},
+		{
+			name: "swapped RF thresholds",
+			args: []string{
+				"test-cmd",
+				"--" + flagTokenFile,
+				"token-file",
+				"--" + flagNetatmoClientID,
+				"id",
+				"--" + flagNetatmoClientSecret,
+				"secret",
+			},
+			env: map[string]string{
+				envVarRFThresholds: "60,90",
+			},
+			wantErr: errInvalidRFThresholds,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			getenv := func(key string) string {
+				return tt.env[key]
+			}
+
+			config, err := Parse(tt.args, getenv)
+
+			if err != tt.wantErr {
+				t.Errorf("got error %q, want %q", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(config, tt.wantConfig) {
+				t.Errorf("got config %v, want %v", config, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestEnvSecret(t *testing.T) {
+	secretFile := writeSecretFile(t, "file-secret\r\n")
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantValue string
+		wantErr   error
+	}{
+		{
+			name:      "not set",
+			env:       map[string]string{},
+			wantValue: "",
+		},
+		{
+			name: "value",
+			env: map[string]string{
+				envVarNetatmoClientSecret: "secret",
+			},
+			wantValue: "secret",
+		},
+		{
+			name: "file",
+			env: map[string]string{
+				envVarNetatmoClientSecret + "_FILE": secretFile,
+			},
+			wantValue: "file-secret",
+		},
+		{
+			name: "value and file",
+			env: map[string]string{
+				envVarNetatmoClientSecret:           "secret",
+				envVarNetatmoClientSecret + "_FILE": secretFile,
+			},
+			wantErr: errSecretFileConflict,
+		},
+		{
+			name: "missing file",
+			env: map[string]string{
+				envVarNetatmoClientSecret + "_FILE": filepath.Join(t.TempDir(), "missing"),
+			},
+			wantErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			getenv := func(key string) string {
+				return tt.env[key]
+			}
+
+			value, err := envSecret(getenv, envVarNetatmoClientSecret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %q, want %q", err, tt.wantErr)
+			}
+
+			if value != tt.wantValue {
+				t.Errorf("got value %q, want %q", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func writeSecretFile(t *testing.T, content string) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(fileName, []byte(content), 0o600); err != nil {
+		t.Fatalf("error writing secret file: %s", err)
+	}
+
+	return fileName
 }
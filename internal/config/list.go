@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// listValue is used for flags containing a list of values. Each value can contain several comma-separated elements and
+// the flag can be repeated to add more elements. The first use of the flag replaces the default value.
+type listValue[T any] struct {
+	list     *[]T
+	parse    func(string) ([]T, error)
+	typeName string
+	changed  bool
+}
+
+func newListValue(list *[]string) *listValue[string] {
+	return &listValue[string]{
+		list: list,
+		parse: func(value string) ([]string, error) {
+			return splitList(value), nil
+		},
+		typeName: "stringSlice",
+	}
+}
+
+func newIntListValue(list *[]int) *listValue[int] {
+	return &listValue[int]{
+		list:     list,
+		parse:    parseIntList,
+		typeName: "intSlice",
+	}
+}
+
+func (v *listValue[T]) Set(value string) error {
+	items, err := v.parse(value)
+	if err != nil {
+		return err
+	}
+
+	if !v.changed {
+		*v.list = items
+		v.changed = true
+		return nil
+	}
+
+	*v.list = append(*v.list, items...)
+	return nil
+}
+
+// Type uses the same names as the slices of pflag, so that the usage is formatted in the same way.
+func (v *listValue[T]) Type() string {
+	return v.typeName
+}
+
+// String returns an empty string for an empty list, so that pflag does not show it as the default value.
+func (v *listValue[T]) String() string {
+	if len(*v.list) == 0 {
+		return ""
+	}
+
+	items := make([]string, 0, len(*v.list))
+	for _, item := range *v.list {
+		items = append(items, fmt.Sprint(item))
+	}
+
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+// parseIntList parses a comma-separated list of integers. Empty elements are removed.
+func parseIntList(value string) ([]int, error) {
+	var result []int
+	for _, item := range splitList(value) {
+		i, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, i)
+	}
+
+	return result, nil
+}
+
+// parseLabels parses a comma-separated list of name=value pairs.
+func parseLabels(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, item := range splitList(value) {
+		name, labelValue, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("label needs to have the form name=value: %q", item)
+		}
+
+		result[strings.TrimSpace(name)] = strings.TrimSpace(labelValue)
+	}
+
+	return result, nil
+}
+
+// splitList splits a comma-separated list and removes empty elements.
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitList(t *testing.T) {
+	tt := []struct {
+		value string
+		want  []string
+	}{
+		{value: "", want: nil},
+		{value: ",", want: nil},
+		{value: " ", want: nil},
+		{value: "Home", want: []string{"Home"}},
+		{value: "Home, Office,", want: []string{"Home", "Office"}},
+	}
+
+	for _, tc := range tt {
+		got := splitList(tc.value)
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("for %q lists differ: -got+want\n%s", tc.value, diff)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	tt := []struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{value: "", want: map[string]string{}},
+		{value: "site=hq", want: map[string]string{"site": "hq"}},
+		{value: "site = hq, environment=prod,", want: map[string]string{"site": "hq", "environment": "prod"}},
+		{value: "site=", want: map[string]string{"site": ""}},
+		{value: "site", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		got, err := parseLabels(tc.value)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("for %q got error %v, want error %v", tc.value, err, tc.wantErr)
+		}
+
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("for %q labels differ: -got+want\n%s", tc.value, diff)
+		}
+	}
+}
+
+func TestListPrecedence(t *testing.T) {
+	requiredArgs := []string{
+		"test-cmd",
+		"--" + flagTokenFile,
+		"token-file",
+		"--" + flagNetatmoClientID,
+		"id",
+		"--" + flagNetatmoClientSecret,
+		"secret",
+	}
+
+	tt := []struct {
+		desc         string
+		args         []string
+		env          map[string]string
+		wantAddrs    []string
+		wantStations []string
+	}{
+		{
+			desc:      "default",
+			wantAddrs: []string{":9210"},
+		},
+		{
+			desc: "flag",
+			args: []string{
+				"--" + flagListenAddress, ":8080, :8081,",
+				"--" + flagExcludeStations, "Office",
+			},
+			wantAddrs:    []string{":8080", ":8081"},
+			wantStations: []string{"Office"},
+		},
+		{
+			desc: "repeated flag",
+			args: []string{
+				"-a", ":8080",
+				"-a", ":8081",
+				"--" + flagExcludeStations, "Office",
+				"--" + flagExcludeStations, "Garage,Attic",
+			},
+			wantAddrs:    []string{":8080", ":8081"},
+			wantStations: []string{"Office", "Garage", "Attic"},
+		},
+		{
+			desc: "environment",
+			env: map[string]string{
+				envVarListenAddress:   ":8080,:8081",
+				envVarExcludeStations: "Office, Garage",
+			},
+			wantAddrs:    []string{":8080", ":8081"},
+			wantStations: []string{"Office", "Garage"},
+		},
+		{
+			desc: "environment overrides flag",
+			args: []string{
+				"-a", ":8080",
+				"--" + flagExcludeStations, "Office",
+			},
+			env: map[string]string{
+				envVarListenAddress:   ":9090",
+				envVarExcludeStations: "Garage",
+			},
+			wantAddrs:    []string{":9090"},
+			wantStations: []string{"Garage"},
+		},
+		{
+			desc: "empty environment keeps flag",
+			args: []string{
+				"--" + flagExcludeStations, "Office",
+			},
+			env: map[string]string{
+				envVarExcludeStations: "",
+			},
+			wantAddrs:    []string{":9210"},
+			wantStations: []string{"Office"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			args := append(append([]string{}, requiredArgs...), tc.args...)
+			cfg, err := Parse(args, func(key string) string {
+				return tc.env[key]
+			})
+			if err != nil {
+				t.Fatalf("got error: %s", err)
+			}
+
+			if diff := cmp.Diff(cfg.Addrs, tc.wantAddrs); diff != "" {
+				t.Errorf("addresses differ: -got+want\n%s", diff)
+			}
+
+			if diff := cmp.Diff(cfg.ExcludeStations, tc.wantStations); diff != "" {
+				t.Errorf("excluded stations differ: -got+want\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIntListFlag(t *testing.T) {
+	requiredArgs := []string{
+		"test-cmd",
+		"--" + flagTokenFile,
+		"token-file",
+		"--" + flagNetatmoClientID,
+		"id",
+		"--" + flagNetatmoClientSecret,
+		"secret",
+	}
+
+	tt := []struct {
+		desc        string
+		args        []string
+		wantComfort []int
+		wantErr     bool
+	}{
+		{
+			desc:        "default",
+			wantComfort: []int{40, 60},
+		},
+		{
+			desc:        "whitespace and empty elements",
+			args:        []string{"--" + flagHumidityComfort, " 30, 70,"},
+			wantComfort: []int{30, 70},
+		},
+		{
+			desc:        "repeated flag",
+			args:        []string{"--" + flagHumidityComfort, "30", "--" + flagHumidityComfort, "70"},
+			wantComfort: []int{30, 70},
+		},
+		{
+			desc:    "invalid number",
+			args:    []string{"--" + flagHumidityComfort, "30,high"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			args := append(append([]string{}, requiredArgs...), tc.args...)
+			cfg, err := Parse(args, func(string) string {
+				return ""
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(cfg.HumidityComfort, tc.wantComfort); diff != "" {
+				t.Errorf("humidity comfort differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
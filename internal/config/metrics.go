@@ -0,0 +1,72 @@
+package config
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsPrefix = "netatmo_config_"
+)
+
+var (
+	validDesc = prometheus.NewDesc(
+		metricsPrefix+"valid",
+		"One if the configuration contains no settings, which probably do not work as intended. Details are logged on startup.",
+		nil, nil)
+
+	refreshIntervalDesc = prometheus.NewDesc(
+		metricsPrefix+"refresh_interval_seconds",
+		"Contains the refresh interval the exporter was started with in seconds.",
+		nil, nil)
+
+	staleThresholdDesc = prometheus.NewDesc(
+		metricsPrefix+"stale_threshold_seconds",
+		"Contains the threshold in seconds after which the data of a module is considered stale.",
+		nil, nil)
+
+	timezoneDesc = prometheus.NewDesc(
+		metricsPrefix+"timezone_info",
+		"Contains the timezone used for presenting times in logs. Timestamps in metrics are not affected by the timezone.",
+		[]string{"timezone"}, nil)
+)
+
+// Units contains the unit of every metric of the package which has one, by metric name.
+var Units = map[string]string{
+	metricsPrefix + "refresh_interval_seconds": "seconds",
+	metricsPrefix + "stale_threshold_seconds":  "seconds",
+}
+
+// Metric returns a prometheus.Collector exposing the effective values of cfg.
+func Metric(cfg Config) prometheus.Collector {
+	return &configMetric{
+		cfg: cfg,
+	}
+}
+
+type configMetric struct {
+	cfg Config
+}
+
+func (c configMetric) Describe(dChan chan<- *prometheus.Desc) {
+	dChan <- validDesc
+	dChan <- refreshIntervalDesc
+	dChan <- staleThresholdDesc
+	dChan <- timezoneDesc
+}
+
+func (c configMetric) Collect(mChan chan<- prometheus.Metric) {
+	valid := 1.0
+	if len(c.cfg.Warnings()) > 0 {
+		valid = 0
+	}
+
+	mChan <- prometheus.MustNewConstMetric(validDesc, prometheus.GaugeValue, valid)
+	mChan <- prometheus.MustNewConstMetric(refreshIntervalDesc, prometheus.GaugeValue, c.cfg.RefreshInterval.Seconds())
+	mChan <- prometheus.MustNewConstMetric(staleThresholdDesc, prometheus.GaugeValue, c.cfg.StaleDuration.Seconds())
+
+	timezone := c.cfg.Timezone
+	if timezone == "" {
+		timezone = "Local"
+	}
+	mChan <- prometheus.MustNewConstMetric(timezoneDesc, prometheus.GaugeValue, 1, timezone)
+}
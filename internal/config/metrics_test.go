@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetric(t *testing.T) {
+	cfg := Config{
+		RefreshInterval: 8 * time.Minute,
+		StaleDuration:   time.Hour,
+		Timezone:        "Europe/Berlin",
+	}
+
+	want := `# HELP netatmo_config_refresh_interval_seconds Contains the refresh interval the exporter was started with in seconds.
+# TYPE netatmo_config_refresh_interval_seconds gauge
+netatmo_config_refresh_interval_seconds 480
+# HELP netatmo_config_stale_threshold_seconds Contains the threshold in seconds after which the data of a module is considered stale.
+# TYPE netatmo_config_stale_threshold_seconds gauge
+netatmo_config_stale_threshold_seconds 3600
+# HELP netatmo_config_timezone_info Contains the timezone used for presenting times in logs. Timestamps in metrics are not affected by the timezone.
+# TYPE netatmo_config_timezone_info gauge
+netatmo_config_timezone_info{timezone="Europe/Berlin"} 1
+# HELP netatmo_config_valid One if the configuration contains no settings, which probably do not work as intended. Details are logged on startup.
+# TYPE netatmo_config_valid gauge
+netatmo_config_valid 1
+`
+
+	if err := testutil.CollectAndCompare(Metric(cfg), strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
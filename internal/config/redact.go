@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	redactedValue = "<redacted>"
+
+	redactTag       = "redact"
+	redactTagSecret = "true"
+	redactTagURL    = "url"
+)
+
+// Redacted returns the configuration in a form suitable for encoding as JSON. Fields tagged as secret are replaced
+// and passwords contained in URLs are removed. Durations and the log level are formatted as strings.
+func (c Config) Redacted() map[string]interface{} {
+	return plainStruct(reflect.ValueOf(c))
+}
+
+// redactURL removes the password from the URL. The whole value is replaced, if it can not be parsed, because it
+// could still contain credentials.
+func redactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return redactedValue
+	}
+
+	return u.Redacted()
+}
+
+func redactString(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return redactedValue
+}
+
+func plainStruct(value reflect.Value) map[string]interface{} {
+	result := make(map[string]interface{}, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		switch field.Tag.Get(redactTag) {
+		case redactTagSecret:
+			result[field.Name] = redactString(value.Field(i).String())
+		case redactTagURL:
+			result[field.Name] = redactURL(value.Field(i).String())
+		default:
+			result[field.Name] = plainValue(value.Field(i))
+		}
+	}
+
+	return result
+}
+
+func plainValue(value reflect.Value) interface{} {
+	switch v := value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case logLevel:
+		return logrus.Level(v).String()
+	case netatmo.Config:
+		// The configuration of the library can not be tagged, so only the known fields are included.
+		return map[string]interface{}{
+			"ClientID":     v.ClientID,
+			"ClientSecret": redactString(v.ClientSecret),
+		}
+	}
+
+	if value.Kind() == reflect.Struct {
+		return plainStruct(value)
+	}
+
+	return value.Interface()
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Features returns the names of the optional features enabled in the configuration.
+func (c Config) Features() []string {
+	var features []string
+	add := func(enabled bool, name string) {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+
+	add(c.EnableHomeCoach, "homecoach")
+	add(c.EnableEnergy, "energy")
+	add(c.EnableInflux, "influx")
+	add(c.HistoryHours > 0, "history")
+	add(c.DebugHandlers, "debug-handlers")
+	add(c.ProxyURL != "", "proxy")
+	add(c.CACertFile != "", "ca-cert")
+	add(c.TLSCertFile != "", "tls")
+	add(c.ClientCAFile != "", "client-certificates")
+	add(c.AuthBearerToken != "", "bearer-auth")
+	add(len(c.StationIDs) > 0, "station-ids")
+	add(len(c.IncludeStations) > 0 || len(c.ExcludeStations) > 0, "station-filter")
+	add(c.SharedCacheFile != "", "shared-cache")
+	add(c.FromFile != "", "from-file")
+	add(c.StationLabelTemplate != "", "station-label-template")
+	add(c.PushgatewayURL != "", "pushgateway")
+	add(c.VaultURL != "", "vault")
+	add(c.PeakWindow > 0, "peaks")
+	add(c.RoundTemperature >= 0 || c.RoundPressure >= 0, "rounding")
+	add(c.AdaptiveRefresh, "adaptive-refresh")
+	add(c.FailScrapeOnError, "fail-scrape-on-error")
+	add(c.SkipUnreachable, "skip-unreachable")
+	add(c.DisableRuntimeMetrics, "no-runtime-metrics")
+
+	return features
+}
+
+// Warnings returns descriptions of settings, which are valid on their own, but probably do not work as intended.
+func (c Config) Warnings() []string {
+	var warnings []string
+
+	if c.StaleDuration < c.RefreshInterval+c.RefreshJitter {
+		warnings = append(warnings, fmt.Sprintf("stale duration %s is smaller than refresh interval plus jitter %s, data can become stale before the next refresh", c.StaleDuration, c.RefreshInterval+c.RefreshJitter))
+	}
+
+	if c.AdaptiveRefresh && c.StaleDuration < c.AdaptiveRefreshMax+c.RefreshJitter {
+		warnings = append(warnings, fmt.Sprintf("stale duration %s is smaller than maximum adaptive refresh interval plus jitter %s, data can become stale before the next refresh", c.StaleDuration, c.AdaptiveRefreshMax+c.RefreshJitter))
+	}
+
+	retryTime := time.Duration(c.ReadRetries) * c.ReadRetryDelay
+	if c.ReadRetries > 0 && retryTime >= c.RefreshInterval {
+		warnings = append(warnings, fmt.Sprintf("read retries take %s, which is not shorter than the refresh interval %s, not all retries will be used", retryTime, c.RefreshInterval))
+	}
+
+	if c.DryRun && c.Once {
+		warnings = append(warnings, "once is ignored in dry-run mode")
+	}
+
+	if (c.DryRun || c.Once) && c.PushgatewayURL != "" {
+		warnings = append(warnings, "pushgateway is not used in dry-run or once mode")
+	}
+
+	return warnings
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFeatures(t *testing.T) {
+	cfg := defaultConfig
+	cfg.EnableEnergy = true
+	cfg.ExcludeStations = []string{"Office"}
+
+	got := cfg.Features()
+	want := []string{"energy", "station-filter", "peaks"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("features differ: -got+want\n%s", diff)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tt := []struct {
+		desc string
+		cfg  func(cfg *Config)
+		want []string
+	}{
+		{
+			desc: "default",
+			cfg:  func(*Config) {},
+		},
+		{
+			desc: "jitter exceeds stale duration",
+			cfg: func(cfg *Config) {
+				cfg.RefreshJitter = time.Hour
+			},
+			want: []string{
+				"stale duration 1h0m0s is smaller than refresh interval plus jitter 1h8m0s, data can become stale before the next refresh",
+			},
+		},
+		{
+			desc: "adaptive refresh exceeds stale duration",
+			cfg: func(cfg *Config) {
+				cfg.AdaptiveRefresh = true
+				cfg.AdaptiveRefreshMax = 2 * time.Hour
+			},
+			want: []string{
+				"stale duration 1h0m0s is smaller than maximum adaptive refresh interval plus jitter 2h0m0s, data can become stale before the next refresh",
+			},
+		},
+		{
+			desc: "read retries exceed refresh interval",
+			cfg: func(cfg *Config) {
+				cfg.ReadRetries = 60
+			},
+			want: []string{
+				"read retries take 10m0s, which is not shorter than the refresh interval 8m0s, not all retries will be used",
+			},
+		},
+		{
+			desc: "once with pushgateway",
+			cfg: func(cfg *Config) {
+				cfg.Once = true
+				cfg.PushgatewayURL = "http://pushgateway:9091"
+			},
+			want: []string{
+				"pushgateway is not used in dry-run or once mode",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := defaultConfig
+			tc.cfg(&cfg)
+
+			got := cfg.Warnings()
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("warnings differ: -got+want\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -18,11 +19,42 @@ func NewLogger() *logrus.Logger {
 
 	return &logrus.Logger{
 		Out: os.Stderr,
-		Formatter: &logrus.TextFormatter{
-			DisableTimestamp: true,
+		Formatter: &Formatter{
+			TextFormatter: logrus.TextFormatter{
+				DisableTimestamp: true,
+			},
+			Location: time.Local,
 		},
 		Level:        logLevel,
 		ExitFunc:     os.Exit,
 		ReportCaller: false,
 	}
 }
+
+// Formatter formats log entries using the TextFormatter, with their time converted to Location. This only changes
+// how the time is presented and does not require changing time.Local.
+type Formatter struct {
+	logrus.TextFormatter
+	Location *time.Location
+}
+
+// Format implements logrus.Formatter.
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	converted := *entry
+	converted.Time = entry.Time.In(f.Location)
+	return f.TextFormatter.Format(&converted)
+}
+
+// SetTimezone makes the logger show a timestamp including the UTC offset in location on every log line. The logger
+// needs to use the Formatter created by NewLogger.
+func SetTimezone(log *logrus.Logger, location *time.Location) {
+	formatter, ok := log.Formatter.(*Formatter)
+	if !ok {
+		return
+	}
+
+	formatter.Location = location
+	formatter.DisableTimestamp = false
+	formatter.FullTimestamp = true
+	formatter.TimestampFormat = time.RFC3339
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetTimezone(t *testing.T) {
+	entryTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		want     string
+	}{
+		{
+			name: "default",
+			want: "level=info msg=test\n",
+		},
+		{
+			name:     "timezone",
+			location: time.FixedZone("Test", 2*60*60),
+			want:     "time=\"2024-07-01T12:00:00+02:00\" level=info msg=test\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			log := NewLogger()
+			log.Out = buf
+			log.Formatter.(*Formatter).DisableColors = true
+			if tt.location != nil {
+				SetTimezone(log, tt.location)
+			}
+
+			log.WithTime(entryTime).Info("test")
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsEntry(t *testing.T) {
+	formatter := &Formatter{Location: time.FixedZone("Test", 60*60)}
+	entry := logrus.NewEntry(logrus.New())
+	entry.Time = time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	if _, err := formatter.Format(entry); err != nil {
+		t.Fatalf("error formatting entry: %s", err)
+	}
+
+	if entry.Time.Location() != time.UTC {
+		t.Errorf("got location %s, want entry to be unchanged", entry.Time.Location())
+	}
+}
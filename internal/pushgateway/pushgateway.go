@@ -0,0 +1,160 @@
+package pushgateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Job is the job name used for the metrics pushed to the Pushgateway.
+	Job = "netatmo_exporter"
+
+	stationLabel = "station"
+)
+
+var pushErrorsDesc = prometheus.NewDesc(
+	"netatmo_push_errors_total",
+	"Counts the number of errors while pushing metrics to the Pushgateway.",
+	nil, nil)
+
+// Pusher periodically pushes the metrics of a prometheus.Gatherer to a Pushgateway. The metrics of each station are
+// pushed using the station name as grouping key, all other metrics are only grouped by the job.
+// Pusher also implements prometheus.Collector to expose the number of failed pushes.
+type Pusher struct {
+	Log      logrus.FieldLogger
+	URL      string
+	Gatherer prometheus.Gatherer
+	Client   *http.Client
+
+	errors atomic.Uint64
+}
+
+// New creates a new Pusher for the Pushgateway at url.
+func New(log logrus.FieldLogger, url string, gatherer prometheus.Gatherer) *Pusher {
+	return &Pusher{
+		Log:      log,
+		URL:      url,
+		Gatherer: gatherer,
+		Client:   http.DefaultClient,
+	}
+}
+
+// Run pushes the metrics every interval until ctx is cancelled.
+func (p *Pusher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Push(ctx); err != nil {
+			p.errors.Add(1)
+			p.Log.WithError(err).Error("Error pushing metrics.")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Push gathers the current metrics and pushes them to the Pushgateway.
+func (p *Pusher) Push(ctx context.Context) error {
+	families, err := p.Gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("error gathering metrics: %w", err)
+	}
+
+	groups := groupByStation(families)
+	stations := make([]string, 0, len(groups))
+	for station := range groups {
+		stations = append(stations, station)
+	}
+	sort.Strings(stations)
+
+	for _, station := range stations {
+		group := groups[station]
+		pusher := push.New(p.URL, Job).
+			Client(p.Client).
+			Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				return group, nil
+			}))
+		if station != "" {
+			pusher = pusher.Grouping(stationLabel, station)
+		}
+
+		if err := pusher.PushContext(ctx); err != nil {
+			return fmt.Errorf("error pushing metrics of group %q: %w", station, err)
+		}
+	}
+
+	return nil
+}
+
+// groupByStation splits the metric families by the value of the station label. The station label is removed from the
+// metrics, because the Pushgateway adds it from the grouping key. Metrics without station label are contained in the
+// group with an empty name.
+func groupByStation(families []*dto.MetricFamily) map[string][]*dto.MetricFamily {
+	result := make(map[string][]*dto.MetricFamily)
+	for _, family := range families {
+		split := make(map[string]*dto.MetricFamily)
+		var order []string
+		for _, metric := range family.Metric {
+			station := ""
+			labels := make([]*dto.LabelPair, 0, len(metric.Label))
+			for _, label := range metric.Label {
+				if label.GetName() == stationLabel {
+					station = label.GetValue()
+					continue
+				}
+				labels = append(labels, label)
+			}
+
+			group, ok := split[station]
+			if !ok {
+				group = &dto.MetricFamily{
+					Name: family.Name,
+					Help: family.Help,
+					Type: family.Type,
+					Unit: family.Unit,
+				}
+				split[station] = group
+				order = append(order, station)
+			}
+			group.Metric = append(group.Metric, &dto.Metric{
+				Label:       labels,
+				Gauge:       metric.Gauge,
+				Counter:     metric.Counter,
+				Summary:     metric.Summary,
+				Untyped:     metric.Untyped,
+				Histogram:   metric.Histogram,
+				TimestampMs: metric.TimestampMs,
+			})
+		}
+
+		for _, station := range order {
+			result[station] = append(result[station], split[station])
+		}
+	}
+
+	return result
+}
+
+// Describe implements prometheus.Collector
+func (p *Pusher) Describe(dChan chan<- *prometheus.Desc) {
+	dChan <- pushErrorsDesc
+}
+
+// Collect implements prometheus.Collector
+func (p *Pusher) Collect(mChan chan<- prometheus.Metric) {
+	mChan <- prometheus.MustNewConstMetric(pushErrorsDesc, prometheus.CounterValue, float64(p.errors.Load()))
+}
@@ -0,0 +1,120 @@
+package pushgateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func testRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "netatmo_up", Help: "Up."})
+	up.Set(1)
+	registry.MustRegister(up)
+
+	temperature := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "netatmo_sensor_temperature_celsius",
+		Help: "Temperature.",
+	}, []string{"module", "station"})
+	temperature.WithLabelValues("Indoor", "Home").Set(21)
+	temperature.WithLabelValues("Outdoor", "Home").Set(10)
+	temperature.WithLabelValues("Indoor", "Office").Set(22)
+	registry.MustRegister(temperature)
+
+	return registry
+}
+
+func TestPush(t *testing.T) {
+	var lock sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.Method != http.MethodPut {
+			t.Errorf("got method %q, want %q", r.Method, http.MethodPut)
+		}
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pusher := New(logrus.New(), server.URL, testRegistry())
+	if err := pusher.Push(context.Background()); err != nil {
+		t.Fatalf("error pushing: %s", err)
+	}
+
+	sort.Strings(paths)
+	wantPaths := []string{
+		"/metrics/job/netatmo_exporter",
+		"/metrics/job/netatmo_exporter/station/Home",
+		"/metrics/job/netatmo_exporter/station/Office",
+	}
+	if diff := cmp.Diff(paths, wantPaths); diff != "" {
+		t.Errorf("paths differ: -got+want\n%s", diff)
+	}
+}
+
+func TestGroupByStation(t *testing.T) {
+	families, err := testRegistry().Gather()
+	if err != nil {
+		t.Fatalf("error gathering: %s", err)
+	}
+
+	groups := groupByStation(families)
+
+	got := make(map[string][]int)
+	for station, group := range groups {
+		for _, family := range group {
+			got[station] = append(got[station], len(family.Metric))
+			for _, metric := range family.Metric {
+				for _, label := range metric.Label {
+					if label.GetName() == stationLabel {
+						t.Errorf("metric %s in group %q still has station label", family.GetName(), station)
+					}
+				}
+			}
+		}
+	}
+
+	want := map[string][]int{
+		"":       {1},
+		"Home":   {2},
+		"Office": {1},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("groups differ: -got+want\n%s", diff)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pusher := New(logrus.New(), server.URL, testRegistry())
+	pusher.Run(ctx, time.Minute)
+
+	want := `# HELP netatmo_push_errors_total Counts the number of errors while pushing metrics to the Pushgateway.
+# TYPE netatmo_push_errors_total counter
+netatmo_push_errors_total 1
+`
+	if err := testutil.CollectAndCompare(pusher, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,31 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/exzz/netatmo-api-go"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+// ReadFunction returns a function reading the station data from fileName instead of the NetAtmo API. The file uses
+// the format of the /debug/data endpoint, so it also contains the details of the stations and modules. It is read
+// again on every call, so that changes are used on the next refresh.
+func ReadFunction(fileName string) api.StationsFunction {
+	return func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+
+		var result api.StationsFile
+		if err := json.NewDecoder(file).Decode(&result); err != nil {
+			return nil, nil, fmt.Errorf("error decoding %s: %w", fileName, err)
+		}
+
+		return &result.DeviceCollection, result.Details, nil
+	}
+}
@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+func testStations(name string) *netatmo.DeviceCollection {
+	temperature := float32(21.5)
+	lastMeasure := int64(1700000000)
+
+	stations := &netatmo.DeviceCollection{}
+	stations.Body.Devices = []*netatmo.Device{
+		{
+			ID:          "70:ee:50:00:00:01",
+			StationName: name, //nolint: staticcheck
+			DashboardData: netatmo.DashboardData{
+				Temperature: &temperature,
+				LastMeasure: &lastMeasure,
+			},
+		},
+	}
+	return stations
+}
+
+func writeStations(t *testing.T, fileName string, stations *netatmo.DeviceCollection) {
+	t.Helper()
+
+	data, err := json.Marshal(stations)
+	if err != nil {
+		t.Fatalf("error encoding stations: %s", err)
+	}
+
+	if err := os.WriteFile(fileName, data, 0o600); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+}
+
+func TestReadFunction(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	read := ReadFunction(fileName)
+
+	writeStations(t, fileName, testStations("first"))
+	got, _, err := read()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+
+	if diff := cmp.Diff(got, testStations("first")); diff != "" {
+		t.Errorf("stations differ: -got+want\n%s", diff)
+	}
+
+	writeStations(t, fileName, testStations("second"))
+	got, _, err = read()
+	if err != nil {
+		t.Fatalf("error reading after change: %s", err)
+	}
+
+	if diff := cmp.Diff(got, testStations("second")); diff != "" {
+		t.Errorf("stations differ after change: -got+want\n%s", diff)
+	}
+}
+
+func TestReadFunctionDetails(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(fileName, []byte(`{
+  "Body": {"devices": [{"_id": "70:ee:50:00:00:01", "modules": [{"_id": "02:00:00:00:00:01"}]}]},
+  "details": {"02:00:00:00:00:01": {"battery_vp": 5120, "reachable": false}}
+}`), 0o600); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+
+	_, details, err := ReadFunction(fileName)()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+
+	batteryVoltage := int32(5120)
+	reachable := false
+	want := map[string]api.DeviceDetails{
+		"02:00:00:00:00:01": {
+			BatteryVoltage: &batteryVoltage,
+			Reachable:      &reachable,
+		},
+	}
+	if diff := cmp.Diff(details, want); diff != "" {
+		t.Errorf("details differ: -got+want\n%s", diff)
+	}
+}
+
+func TestReadFunctionErrors(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	read := ReadFunction(fileName)
+
+	if _, _, err := read(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got error %v, want %v", err, os.ErrNotExist)
+	}
+
+	if err := os.WriteFile(fileName, []byte("invalid"), 0o600); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+
+	if _, _, err := read(); err == nil {
+		t.Error("expected error for invalid file")
+	}
+}
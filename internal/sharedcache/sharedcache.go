@@ -0,0 +1,120 @@
+package sharedcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+// cacheFile contains the details together with the station data, so that exporters using the cached data export the
+// same metrics as the exporter which read it.
+type cacheFile struct {
+	Timestamp time.Time                    `json:"timestamp"`
+	Data      *netatmo.DeviceCollection    `json:"data"`
+	Details   map[string]api.DeviceDetails `json:"details,omitempty"`
+}
+
+// SharedCache shares the station data between several exporters using the same account. The data read from the
+// NetAtmo API is written to a file, which is used by all exporters instead of reading the data again, as long as it is
+// younger than the maximum age.
+//
+// There is no locking between the exporters. When two exporters refresh at the same time, both read the data from the
+// API and the file is overwritten by the last one, which only results in an additional request.
+//
+// Only the station data is shared, not the OAuth token. Each exporter needs its own app credentials, because
+// refreshing the token of an app invalidates the refresh token used by the other exporters.
+type SharedCache struct {
+	Log      logrus.FieldLogger
+	FileName string
+	MaxAge   time.Duration
+	Read     api.StationsFunction
+
+	clock func() time.Time
+}
+
+// New creates a new SharedCache using the file fileName. Data in the file is used when it is younger than maxAge,
+// otherwise the data is read using readFunc.
+func New(log logrus.FieldLogger, fileName string, maxAge time.Duration, readFunc api.StationsFunction) *SharedCache {
+	return &SharedCache{
+		Log:      log,
+		FileName: fileName,
+		MaxAge:   maxAge,
+		Read:     readFunc,
+		clock:    time.Now,
+	}
+}
+
+// ReadStations returns the data from the shared file, if it is recent enough. Otherwise, the data is read from the
+// NetAtmo API and written to the file.
+func (c *SharedCache) ReadStations() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+	now := c.clock()
+
+	cached, err := c.load()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		c.Log.WithError(err).Warn("Error reading shared cache file.")
+	case now.Sub(cached.Timestamp) < c.MaxAge:
+		c.Log.Debugf("Using data from shared cache written at %s.", cached.Timestamp)
+		return cached.Data, cached.Details, nil
+	}
+
+	data, details, err := c.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.store(cacheFile{Timestamp: now, Data: data, Details: details}); err != nil {
+		c.Log.WithError(err).Warn("Error writing shared cache file.")
+	}
+
+	return data, details, nil
+}
+
+func (c *SharedCache) load() (cacheFile, error) {
+	file, err := os.Open(c.FileName)
+	if err != nil {
+		return cacheFile{}, err
+	}
+	defer file.Close()
+
+	var result cacheFile
+	if err := json.NewDecoder(file).Decode(&result); err != nil {
+		return cacheFile{}, fmt.Errorf("error decoding shared cache: %w", err)
+	}
+
+	if result.Data == nil {
+		return cacheFile{}, errors.New("shared cache contains no data")
+	}
+
+	return result, nil
+}
+
+// store writes the data to a temporary file first, so that other exporters never read a partially written file.
+func (c *SharedCache) store(data cacheFile) error {
+	file, err := os.CreateTemp(filepath.Dir(c.FileName), filepath.Base(c.FileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := json.NewEncoder(file).Encode(data); err != nil {
+		file.Close()
+		return fmt.Errorf("error encoding shared cache: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), c.FileName)
+}
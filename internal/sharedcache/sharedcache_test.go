@@ -0,0 +1,138 @@
+package sharedcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+func testStations(name string) *netatmo.DeviceCollection {
+	temperature := float32(21.5)
+	lastMeasure := int64(1700000000)
+
+	stations := &netatmo.DeviceCollection{}
+	stations.Body.Devices = []*netatmo.Device{
+		{
+			ID:          "70:ee:50:00:00:01",
+			StationName: name, //nolint: staticcheck
+			DashboardData: netatmo.DashboardData{
+				Temperature: &temperature,
+				LastMeasure: &lastMeasure,
+			},
+		},
+	}
+	return stations
+}
+
+func testDetails() map[string]api.DeviceDetails {
+	batteryVoltage := int32(5120)
+	reachable := true
+
+	return map[string]api.DeviceDetails{
+		"70:ee:50:00:00:01": {
+			Place: &api.Place{Country: "DE", Timezone: "Europe/Berlin"},
+		},
+		"02:00:00:00:00:01": {
+			BatteryVoltage: &batteryVoltage,
+			Reachable:      &reachable,
+		},
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "cache.json")
+
+	reads := 0
+	readFunc := func(name string) api.StationsFunction {
+		return func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+			reads++
+			return testStations(name), testDetails(), nil
+		}
+	}
+
+	now := time.Unix(1700000000, 0)
+	first := New(logrus.New(), fileName, time.Minute, readFunc("first"))
+	first.clock = func() time.Time { return now }
+	second := New(logrus.New(), fileName, time.Minute, readFunc("second"))
+	second.clock = func() time.Time { return now.Add(30 * time.Second) }
+
+	if _, _, err := first.ReadStations(); err != nil {
+		t.Fatalf("error reading first: %s", err)
+	}
+
+	got, details, err := second.ReadStations()
+	if err != nil {
+		t.Fatalf("error reading second: %s", err)
+	}
+
+	if reads != 1 {
+		t.Errorf("got %d reads, want 1", reads)
+	}
+
+	if diff := cmp.Diff(got, testStations("first")); diff != "" {
+		t.Errorf("stations differ: -got+want\n%s", diff)
+	}
+
+	if diff := cmp.Diff(details, testDetails()); diff != "" {
+		t.Errorf("details differ: -got+want\n%s", diff)
+	}
+
+	second.clock = func() time.Time { return now.Add(time.Minute) }
+	got, _, err = second.ReadStations()
+	if err != nil {
+		t.Fatalf("error reading second: %s", err)
+	}
+
+	if reads != 2 {
+		t.Errorf("got %d reads after expiry, want 2", reads)
+	}
+
+	if diff := cmp.Diff(got, testStations("second")); diff != "" {
+		t.Errorf("stations differ after expiry: -got+want\n%s", diff)
+	}
+}
+
+func TestSharedCacheInvalidFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(fileName, []byte("invalid"), 0o600); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+
+	cache := New(logrus.New(), fileName, time.Minute, func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+		return testStations("fresh"), nil, nil
+	})
+
+	got, _, err := cache.ReadStations()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+
+	if diff := cmp.Diff(got, testStations("fresh")); diff != "" {
+		t.Errorf("stations differ: -got+want\n%s", diff)
+	}
+}
+
+func TestSharedCacheReadError(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "cache.json")
+	testErr := errors.New("test error")
+
+	cache := New(logrus.New(), fileName, time.Minute, func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+		return nil, nil, testErr
+	})
+
+	if _, _, err := cache.ReadStations(); !errors.Is(err, testErr) {
+		t.Errorf("got error %v, want %v", err, testErr)
+	}
+
+	if _, err := os.Stat(fileName); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("shared cache file should not be written on error: %v", err)
+	}
+}
@@ -21,6 +21,11 @@ var (
 		nil, nil)
 )
 
+// Units contains the unit of every metric of the package which has one, by metric name.
+var Units = map[string]string{
+	prefix + "expiry_time": "unix timestamp",
+}
+
 func Metric(tokenFunc func() (*oauth2.Token, error)) prometheus.Collector {
 	return &tokenMetric{
 		tokenFunc: tokenFunc,
@@ -0,0 +1,50 @@
+package token
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/oauth2"
+)
+
+var refreshesDesc = prometheus.NewDesc(
+	"netatmo_token_refreshes_total",
+	"Total number of times the access token has been refreshed.",
+	nil, nil)
+
+// RefreshCounter counts the refreshes of the access token reported by the NetAtmo client.
+type RefreshCounter struct {
+	lock        sync.Mutex
+	refreshes   uint64
+	accessToken string
+}
+
+// Refreshed records a refresh. Only a changed access token is counted, so a token which is reported again, for
+// example after restoring it, does not increase the counter.
+func (c *RefreshCounter) Refreshed(token *oauth2.Token) {
+	if token == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if token.AccessToken == c.accessToken {
+		return
+	}
+
+	c.accessToken = token.AccessToken
+	c.refreshes++
+}
+
+func (c *RefreshCounter) Describe(dChan chan<- *prometheus.Desc) {
+	dChan <- refreshesDesc
+}
+
+func (c *RefreshCounter) Collect(mChan chan<- prometheus.Metric) {
+	c.lock.Lock()
+	refreshes := c.refreshes
+	c.lock.Unlock()
+
+	mChan <- prometheus.MustNewConstMetric(refreshesDesc, prometheus.CounterValue, float64(refreshes))
+}
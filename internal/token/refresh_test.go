@@ -0,0 +1,66 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/oauth2"
+)
+
+func TestRefreshCounter(t *testing.T) {
+	tt := []struct {
+		desc          string
+		tokens        []*oauth2.Token
+		wantRefreshes string
+	}{
+		{
+			desc:          "no refresh",
+			wantRefreshes: "0",
+		},
+		{
+			desc: "changed token",
+			tokens: []*oauth2.Token{
+				{AccessToken: "first"},
+				{AccessToken: "second"},
+			},
+			wantRefreshes: "2",
+		},
+		{
+			desc: "reused token",
+			tokens: []*oauth2.Token{
+				{AccessToken: "first"},
+				{AccessToken: "first"},
+			},
+			wantRefreshes: "1",
+		},
+		{
+			desc: "no token",
+			tokens: []*oauth2.Token{
+				nil,
+			},
+			wantRefreshes: "0",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			counter := &RefreshCounter{}
+			for _, token := range tc.tokens {
+				counter.Refreshed(token)
+			}
+
+			expected := strings.NewReader(`# HELP netatmo_token_refreshes_total Total number of times the access token has been refreshed.
+# TYPE netatmo_token_refreshes_total counter
+netatmo_token_refreshes_total ` + tc.wantRefreshes + `
+`)
+
+			if err := testutil.CollectAndCompare(counter, expected); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
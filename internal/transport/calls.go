@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var apiCallsOpts = prometheus.CounterOpts{
+	Name: "netatmo_api_calls_total",
+	Help: "Total number of requests made to the NetAtmo API, including failed requests.",
+}
+
+// CallCounter is a http.RoundTripper which counts the requests made to the NetAtmo API by endpoint.
+// It also implements prometheus.Collector to expose the counts.
+type CallCounter struct {
+	next  http.RoundTripper
+	calls *prometheus.CounterVec
+}
+
+// NewCallCounter creates a new CallCounter which uses next for making the requests.
+func NewCallCounter(next http.RoundTripper) *CallCounter {
+	return &CallCounter{
+		next:  next,
+		calls: prometheus.NewCounterVec(apiCallsOpts, []string{"endpoint"}),
+	}
+}
+
+func (c *CallCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.calls.WithLabelValues(endpoint(req)).Inc()
+	return c.next.RoundTrip(req)
+}
+
+// endpoint returns the last element of the request path, for example "getstationsdata" or "token".
+func endpoint(req *http.Request) string {
+	return path.Base(req.URL.Path)
+}
+
+// Describe implements prometheus.Collector
+func (c *CallCounter) Describe(dChan chan<- *prometheus.Desc) {
+	c.calls.Describe(dChan)
+}
+
+// Collect implements prometheus.Collector
+func (c *CallCounter) Collect(mChan chan<- prometheus.Metric) {
+	c.calls.Collect(mChan)
+}
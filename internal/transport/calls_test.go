@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCallCounter(t *testing.T) {
+	counter := NewCallCounter(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/api/gethomedata" {
+			return nil, errors.New("test error")
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	client := &http.Client{Transport: counter}
+	for _, url := range []string{
+		"https://api.netatmo.net/api/getstationsdata",
+		"https://api.netatmo.net/api/getstationsdata?device_id=70:ee:50:00:00:01",
+		"https://api.netatmo.net/api/gethomedata",
+		"https://api.netatmo.net/oauth2/token",
+	} {
+		res, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		res.Body.Close()
+	}
+
+	want := `# HELP netatmo_api_calls_total Total number of requests made to the NetAtmo API, including failed requests.
+# TYPE netatmo_api_calls_total counter
+netatmo_api_calls_total{endpoint="gethomedata"} 1
+netatmo_api_calls_total{endpoint="getstationsdata"} 2
+netatmo_api_calls_total{endpoint="token"} 1
+`
+	if err := testutil.CollectAndCompare(counter, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
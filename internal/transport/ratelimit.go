@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+
+	// Values of the reset header below this are interpreted as seconds until the reset instead of a timestamp.
+	maxResetSeconds = 1_000_000_000
+
+	rateLimitResetName = "netatmo_api_rate_limit_reset_time"
+)
+
+var (
+	rateLimitRemainingDesc = prometheus.NewDesc(
+		"netatmo_api_rate_limit_remaining",
+		"Number of requests remaining in the current rate-limit window of the NetAtmo API.",
+		nil, nil)
+
+	rateLimitResetDesc = prometheus.NewDesc(
+		rateLimitResetName,
+		"Unix timestamp when the current rate-limit window of the NetAtmo API resets.",
+		nil, nil)
+)
+
+// Units contains the unit of every metric of the package which has one, by metric name.
+var Units = map[string]string{
+	rateLimitResetName: "unix timestamp",
+}
+
+// RateLimitTracker is a http.RoundTripper which records the rate-limit headers returned by the NetAtmo API.
+// It also implements prometheus.Collector to expose the recorded values. Values are only exposed once they have
+// been returned by the API.
+type RateLimitTracker struct {
+	next  http.RoundTripper
+	clock func() time.Time
+
+	lock      sync.RWMutex
+	remaining *float64
+	reset     *time.Time
+}
+
+// NewRateLimitTracker creates a new RateLimitTracker which uses next for making the requests.
+func NewRateLimitTracker(next http.RoundTripper) *RateLimitTracker {
+	return &RateLimitTracker{
+		next:  next,
+		clock: time.Now,
+	}
+}
+
+func (t *RateLimitTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.update(res.Header)
+	return res, nil
+}
+
+func (t *RateLimitTracker) update(header http.Header) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if value, err := strconv.ParseFloat(header.Get(headerRateLimitRemaining), 64); err == nil {
+		t.remaining = &value
+	}
+
+	if value, err := strconv.ParseInt(header.Get(headerRateLimitReset), 10, 64); err == nil {
+		reset := time.Unix(value, 0)
+		if value < maxResetSeconds {
+			reset = t.clock().Add(time.Duration(value) * time.Second)
+		}
+		t.reset = &reset
+	}
+}
+
+// Describe implements prometheus.Collector
+func (t *RateLimitTracker) Describe(dChan chan<- *prometheus.Desc) {
+	dChan <- rateLimitRemainingDesc
+	dChan <- rateLimitResetDesc
+}
+
+// Collect implements prometheus.Collector
+func (t *RateLimitTracker) Collect(mChan chan<- prometheus.Metric) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.remaining != nil {
+		mChan <- prometheus.MustNewConstMetric(rateLimitRemainingDesc, prometheus.GaugeValue, *t.remaining)
+	}
+
+	if t.reset != nil {
+		mChan <- prometheus.MustNewConstMetric(rateLimitResetDesc, prometheus.GaugeValue, float64(t.reset.Unix()))
+	}
+}
@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRateLimitTracker(t *testing.T) {
+	tt := []struct {
+		desc    string
+		headers map[string]string
+		want    string
+	}{
+		{
+			desc:    "no headers",
+			headers: map[string]string{},
+			want:    "",
+		},
+		{
+			desc: "reset timestamp",
+			headers: map[string]string{
+				headerRateLimitRemaining: "42",
+				headerRateLimitReset:     "1700003600",
+			},
+			want: `# HELP netatmo_api_rate_limit_remaining Number of requests remaining in the current rate-limit window of the NetAtmo API.
+# TYPE netatmo_api_rate_limit_remaining gauge
+netatmo_api_rate_limit_remaining 42
+# HELP netatmo_api_rate_limit_reset_time Unix timestamp when the current rate-limit window of the NetAtmo API resets.
+# TYPE netatmo_api_rate_limit_reset_time gauge
+netatmo_api_rate_limit_reset_time 1.7000036e+09
+`,
+		},
+		{
+			desc: "reset seconds",
+			headers: map[string]string{
+				headerRateLimitRemaining: "0",
+				headerRateLimitReset:     "60",
+			},
+			want: `# HELP netatmo_api_rate_limit_remaining Number of requests remaining in the current rate-limit window of the NetAtmo API.
+# TYPE netatmo_api_rate_limit_remaining gauge
+netatmo_api_rate_limit_remaining 0
+# HELP netatmo_api_rate_limit_reset_time Unix timestamp when the current rate-limit window of the NetAtmo API resets.
+# TYPE netatmo_api_rate_limit_reset_time gauge
+netatmo_api_rate_limit_reset_time 1.70000006e+09
+`,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tracker := NewRateLimitTracker(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				header := http.Header{}
+				for key, value := range tc.headers {
+					header.Set(key, value)
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody}, nil
+			}))
+			tracker.clock = func() time.Time {
+				return time.Unix(1700000000, 0)
+			}
+
+			client := &http.Client{Transport: tracker}
+			res, err := client.Get("https://api.netatmo.net/api/getstationsdata")
+			if err != nil {
+				t.Fatalf("error during request: %s", err)
+			}
+			res.Body.Close()
+
+			if err := testutil.CollectAndCompare(tracker, strings.NewReader(tc.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+)
+
+// DefaultAPIURL is the base URL of the NetAtmo API used by the client libraries.
+const DefaultAPIURL = "https://api.netatmo.net/"
+
+// Options contains the settings used for creating the transport for talking to the NetAtmo API.
+type Options struct {
+	// ProxyURL is an explicit proxy to use for all requests. If it is empty, the proxy is taken from
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+
+	// UserAgent is set as the User-Agent header on all requests, if it is not empty.
+	UserAgent string
+
+	// APIURL replaces the base URL of all requests to the NetAtmo API, if it is not empty.
+	APIURL string
+
+	// CACertFile is a PEM file containing additional certificate authorities trusted for TLS connections.
+	// They are added to the certificates of the system.
+	CACertFile string
+
+	// Timeout limits the duration of each request, including reading the response body. Disabled when zero.
+	Timeout time.Duration
+}
+
+// New creates a new http.RoundTripper using the provided options.
+func New(opts Options) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing proxy URL: %w", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CACertFile != "" {
+		rootCAs, err := loadCACerts(opts.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: rootCAs,
+		}
+	}
+
+	var result http.RoundTripper = transport
+	if opts.APIURL != "" && opts.APIURL != DefaultAPIURL {
+		apiURL, err := url.Parse(opts.APIURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing API URL: %w", err)
+		}
+
+		defaultURL, _ := url.Parse(DefaultAPIURL)
+		result = &rewriteTransport{
+			from: defaultURL,
+			to:   apiURL,
+			next: result,
+		}
+	}
+
+	if opts.Timeout > 0 {
+		result = &timeoutTransport{
+			timeout: opts.Timeout,
+			next:    result,
+		}
+	}
+
+	if opts.UserAgent != "" {
+		result = &userAgentTransport{
+			userAgent: opts.UserAgent,
+			next:      result,
+		}
+	}
+
+	return result, nil
+}
+
+func loadCACerts(fileName string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA certificate file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", fileName)
+	}
+
+	return pool, nil
+}
+
+type userAgentTransport struct {
+	userAgent string
+	next      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+
+	return t.next.RoundTrip(req)
+}
+
+type rewriteTransport struct {
+	from *url.URL
+	to   *url.URL
+	next http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != t.from.Scheme || req.URL.Host != t.from.Host {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Host = ""
+	req.URL.Scheme = t.to.Scheme
+	req.URL.Host = t.to.Host
+	req.URL.Path = path.Join("/", t.to.Path, req.URL.Path)
+	req.URL.RawPath = ""
+
+	return t.next.RoundTrip(req)
+}
+
+// timeoutTransport sets a deadline on each request. The timeout of an http.Client can not be used, because the OAuth2
+// client used by the netatmo library only takes the transport from the client contained in the context.
+type timeoutTransport struct {
+	timeout time.Duration
+	next    http.RoundTripper
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The deadline also applies while the body is read, so the context is only released once it has been closed.
+	res.Body = &cancelBody{
+		ReadCloser: res.Body,
+		cancel:     cancel,
+	}
+	return res, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
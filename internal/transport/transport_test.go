@@ -0,0 +1,246 @@
+package transport
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/exzz/netatmo-api-go"
+)
+
+func TestUserAgent(t *testing.T) {
+	tt := []struct {
+		desc          string
+		userAgent     string
+		wantUserAgent string
+	}{
+		{
+			desc:          "default",
+			userAgent:     "",
+			wantUserAgent: "Go-http-client/1.1",
+		},
+		{
+			desc:          "custom",
+			userAgent:     "netatmo-exporter/test",
+			wantUserAgent: "netatmo-exporter/test",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var gotUserAgent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserAgent = r.UserAgent()
+			}))
+			defer server.Close()
+
+			transport, err := New(Options{
+				UserAgent: tc.userAgent,
+			})
+			if err != nil {
+				t.Fatalf("error creating transport: %s", err)
+			}
+
+			client := &http.Client{Transport: transport}
+			res, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("error during request: %s", err)
+			}
+			res.Body.Close()
+
+			if gotUserAgent != tc.wantUserAgent {
+				t.Errorf("got user-agent %q, want %q", gotUserAgent, tc.wantUserAgent)
+			}
+		})
+	}
+}
+
+func TestAPIURL(t *testing.T) {
+	tt := []struct {
+		desc        string
+		apiPath     string
+		requestURL  string
+		wantPath    string
+		wantRewrite bool
+	}{
+		{
+			desc:        "api request",
+			apiPath:     "",
+			requestURL:  "https://api.netatmo.net//api/getstationsdata",
+			wantPath:    "/api/getstationsdata",
+			wantRewrite: true,
+		},
+		{
+			desc:        "with base path",
+			apiPath:     "/netatmo/",
+			requestURL:  "https://api.netatmo.net/oauth2/token",
+			wantPath:    "/netatmo/oauth2/token",
+			wantRewrite: true,
+		},
+		{
+			desc:        "other host",
+			apiPath:     "",
+			requestURL:  "https://example.com/api/getstationsdata",
+			wantRewrite: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+			}))
+			defer server.Close()
+
+			transport, err := New(Options{
+				APIURL: server.URL + tc.apiPath,
+			})
+			if err != nil {
+				t.Fatalf("error creating transport: %s", err)
+			}
+
+			rewrite, ok := transport.(*rewriteTransport)
+			if !ok {
+				t.Fatalf("got transport %T, want rewriteTransport", transport)
+			}
+
+			// Replace the actual transport, so that requests which are not rewritten do not leave the test.
+			rewrite.next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Host != rewrite.to.Host {
+					return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+				}
+
+				return http.DefaultTransport.RoundTrip(req)
+			})
+
+			client := &http.Client{Transport: transport}
+			res, err := client.Get(tc.requestURL)
+			if err != nil {
+				t.Fatalf("error during request: %s", err)
+			}
+			res.Body.Close()
+
+			rewritten := res.StatusCode != http.StatusTeapot
+			if rewritten != tc.wantRewrite {
+				t.Errorf("got rewrite %v, want %v", rewritten, tc.wantRewrite)
+			}
+
+			if gotPath != tc.wantPath {
+				t.Errorf("got path %q, want %q", gotPath, tc.wantPath)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCACertFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("error writing certificate: %s", err)
+	}
+
+	defaultTransport, err := New(Options{})
+	if err != nil {
+		t.Fatalf("error creating transport: %s", err)
+	}
+
+	if _, err := (&http.Client{Transport: defaultTransport}).Get(server.URL); err == nil {
+		t.Error("expected error without CA certificate")
+	}
+
+	caTransport, err := New(Options{
+		CACertFile: certFile,
+	})
+	if err != nil {
+		t.Fatalf("error creating transport: %s", err)
+	}
+
+	res, err := (&http.Client{Transport: caTransport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("error during request: %s", err)
+	}
+	res.Body.Close()
+}
+
+func TestCACertFileInvalid(t *testing.T) {
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("error writing certificate: %s", err)
+	}
+
+	if _, err := New(Options{CACertFile: certFile}); err == nil {
+		t.Error("expected error for invalid certificate file")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The request hangs until the test is finished.
+		<-done
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(done) })
+
+	transport, err := New(Options{
+		APIURL:  server.URL,
+		Timeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("error creating transport: %s", err)
+	}
+
+	// The netatmo client only uses the transport of the HTTP client contained in the context.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	client := netatmo.NewClient(netatmo.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+	}, nil)
+	client.InitWithToken(ctx, &oauth2.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(time.Hour),
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Read()
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %v, want deadline exceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read did not return")
+	}
+}
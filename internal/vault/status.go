@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const tokenExpiryName = "netatmo_vault_token_expiry_time"
+
+var (
+	tokenExpiryDesc = prometheus.NewDesc(
+		tokenExpiryName,
+		"Set to the unix timestamp when the lease of the Vault token ends. 0 if the token does not expire.",
+		nil, nil)
+
+	tokenRenewableDesc = prometheus.NewDesc(
+		"netatmo_vault_token_renewable",
+		"Set to 1 if the Vault token is renewed before it expires, 0 if it can not be renewed anymore.",
+		nil, nil)
+
+	writeErrorsDesc = prometheus.NewDesc(
+		"netatmo_vault_write_errors_total",
+		"Total number of times writing the refresh token to Vault failed.",
+		nil, nil)
+)
+
+// Units contains the unit of every metric of the package which has one, by metric name.
+var Units = map[string]string{
+	tokenExpiryName: "unix timestamp",
+}
+
+// Status records the state of the Vault token and of writing refresh tokens to Vault. It is shared by all clients
+// created while the exporter is running and implements prometheus.Collector. The token metrics are only reported
+// after the first renewal.
+type Status struct {
+	known       atomic.Bool
+	expiry      atomic.Int64
+	renewable   atomic.Bool
+	writeErrors atomic.Uint64
+}
+
+// tokenRenewed records the lease of the Vault token. A zero expiry means that the token does not expire.
+func (s *Status) tokenRenewed(expiry time.Time, renewable bool) {
+	if s == nil {
+		return
+	}
+
+	var expiryValue int64
+	if !expiry.IsZero() {
+		expiryValue = expiry.Unix()
+	}
+
+	s.expiry.Store(expiryValue)
+	s.renewable.Store(renewable)
+	s.known.Store(true)
+}
+
+// WriteFailed records that writing a refresh token to Vault failed.
+func (s *Status) WriteFailed() {
+	if s == nil {
+		return
+	}
+
+	s.writeErrors.Add(1)
+}
+
+func (s *Status) Describe(dChan chan<- *prometheus.Desc) {
+	dChan <- tokenExpiryDesc
+	dChan <- tokenRenewableDesc
+	dChan <- writeErrorsDesc
+}
+
+func (s *Status) Collect(mChan chan<- prometheus.Metric) {
+	if s.known.Load() {
+		renewableValue := 0.0
+		if s.renewable.Load() {
+			renewableValue = 1.0
+		}
+
+		mChan <- prometheus.MustNewConstMetric(tokenExpiryDesc, prometheus.GaugeValue, float64(s.expiry.Load()))
+		mChan <- prometheus.MustNewConstMetric(tokenRenewableDesc, prometheus.GaugeValue, renewableValue)
+	}
+
+	mChan <- prometheus.MustNewConstMetric(writeErrorsDesc, prometheus.CounterValue, float64(s.writeErrors.Load()))
+}
@@ -0,0 +1,213 @@
+// Package vault reads the NetAtmo credentials from the KV version 2 secrets engine of HashiCorp Vault.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMount is the mount path of the KV version 2 secrets engine, which is enabled by default in Vault.
+const DefaultMount = "secret"
+
+const (
+	tokenHeader = "X-Vault-Token"
+
+	// renewRetryDelay is the time waited before trying again after renewing the token failed.
+	renewRetryDelay = time.Minute
+
+	// requestTimeout limits the duration of each request to Vault.
+	requestTimeout = 30 * time.Second
+)
+
+// Credentials contains the values stored in the secret. Values missing in the secret are empty.
+type Credentials struct {
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// secretData is the format used by the KV engine for the data of a secret in requests and responses.
+type secretData struct {
+	Data Credentials `json:"data"`
+}
+
+// Client accesses a single secret stored in Vault using a Vault token.
+type Client struct {
+	Log    logrus.FieldLogger
+	URL    string
+	Token  string
+	Mount  string
+	Path   string
+	Client *http.Client
+
+	// Status records the lease of the Vault token. It is optional.
+	Status *Status
+}
+
+// New creates a new Client for the secret at secretPath in the KV engine mounted at mount. The requests are sent
+// using transport, or http.DefaultTransport if it is nil.
+func New(log logrus.FieldLogger, vaultURL, token, mount, secretPath string, transport http.RoundTripper) *Client {
+	return &Client{
+		Log:   log,
+		URL:   strings.TrimSuffix(vaultURL, "/"),
+		Token: token,
+		Mount: strings.Trim(mount, "/"),
+		Path:  strings.Trim(secretPath, "/"),
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+		},
+	}
+}
+
+// ReadCredentials reads the current version of the secret.
+func (c *Client) ReadCredentials(ctx context.Context) (Credentials, error) {
+	var response struct {
+		Data secretData `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.dataPath(), "", nil, &response); err != nil {
+		return Credentials{}, fmt.Errorf("error reading secret: %w", err)
+	}
+
+	return response.Data.Data, nil
+}
+
+// UpdateRefreshToken stores refreshToken as a new version of the secret. The other values of the secret are kept.
+func (c *Client) UpdateRefreshToken(ctx context.Context, refreshToken string) error {
+	request := secretData{
+		Data: Credentials{
+			RefreshToken: refreshToken,
+		},
+	}
+	if err := c.do(ctx, http.MethodPatch, c.dataPath(), "application/merge-patch+json", request, nil); err != nil {
+		return fmt.Errorf("error updating secret: %w", err)
+	}
+
+	return nil
+}
+
+// RenewToken extends the lease of the Vault token. It returns the new lease duration and whether the token can be
+// renewed again.
+func (c *Client) RenewToken(ctx context.Context) (time.Duration, bool, error) {
+	var response struct {
+		Auth struct {
+			LeaseDuration int  `json:"lease_duration"`
+			Renewable     bool `json:"renewable"`
+		} `json:"auth"`
+	}
+	if err := c.do(ctx, http.MethodPost, "auth/token/renew-self", "application/json", struct{}{}, &response); err != nil {
+		return 0, false, fmt.Errorf("error renewing token: %w", err)
+	}
+
+	return time.Duration(response.Auth.LeaseDuration) * time.Second, response.Auth.Renewable, nil
+}
+
+// KeepTokenAlive renews the Vault token when half of its lease has passed, until ctx is cancelled or the token can
+// not be renewed anymore. Tokens without expiry are not renewed. A token reaching its maximum TTL is logged as a
+// warning, because writing refresh tokens to Vault fails once it has expired.
+func (c *Client) KeepTokenAlive(ctx context.Context) {
+	var lastLease time.Duration
+	for {
+		delay := renewRetryDelay
+		leaseDuration, renewable, err := c.RenewToken(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+
+			c.Log.WithError(err).Error("Error renewing Vault token.")
+		case leaseDuration == 0:
+			c.Log.Debug("Vault token does not expire, not renewing it.")
+			c.Status.tokenRenewed(time.Time{}, false)
+			return
+		case !renewable || leaseDuration < lastLease:
+			// Vault shortens the lease instead of failing when a renewal would exceed the maximum TTL.
+			expiry := time.Now().Add(leaseDuration)
+			c.Log.Warnf("Vault token can not be renewed anymore and expires at %s. Provide a new token before it expires.", expiry.Format(time.RFC3339))
+			c.Status.tokenRenewed(expiry, false)
+			return
+		default:
+			c.Log.Debugf("Renewed Vault token. Lease duration: %s", leaseDuration)
+			c.Status.tokenRenewed(time.Now().Add(leaseDuration), true)
+			lastLease = leaseDuration
+			delay = leaseDuration / 2
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) dataPath() string {
+	return escapePath(c.Mount) + "/data/" + escapePath(c.Path)
+}
+
+func (c *Client) do(ctx context.Context, method, apiPath, contentType string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+"/v1/"+apiPath, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set(tokenHeader, c.Token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var response struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&response); err == nil && len(response.Errors) > 0 {
+			return fmt.Errorf("got status %q: %s", res.Status, strings.Join(response.Errors, ", "))
+		}
+
+		return fmt.Errorf("got status %q", res.Status)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return nil
+}
+
+// escapePath escapes the elements of a secret path for use in a URL.
+func escapePath(secretPath string) string {
+	elements := strings.Split(secretPath, "/")
+	for i, element := range elements {
+		elements[i] = url.PathEscape(element)
+	}
+
+	return strings.Join(elements, "/")
+}
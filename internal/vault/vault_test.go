@@ -0,0 +1,221 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+const testToken = "vault-token"
+
+func testServer(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get(tokenHeader); token != testToken {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return New(logrus.New(), server.URL+"/", testToken, "secret/", "/netatmo/exporter", nil)
+}
+
+func TestReadCredentials(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("got method %q, want %q", r.Method, http.MethodGet)
+		}
+
+		if r.URL.Path != "/v1/secret/data/netatmo/exporter" {
+			t.Errorf("got path %q, want secret path", r.URL.Path)
+		}
+
+		fmt.Fprint(w, `{"data":{"data":{"client_id":"id","client_secret":"secret","refresh_token":"refresh","other":"value"},"metadata":{"version":3}}}`)
+	})
+
+	credentials, err := client.ReadCredentials(context.Background())
+	if err != nil {
+		t.Fatalf("error reading credentials: %s", err)
+	}
+
+	want := Credentials{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		RefreshToken: "refresh",
+	}
+	if diff := cmp.Diff(credentials, want); diff != "" {
+		t.Errorf("credentials differ: -got+want\n%s", diff)
+	}
+}
+
+func TestReadCredentialsError(t *testing.T) {
+	client := testServer(t, nil)
+	client.Token = "wrong"
+
+	_, err := client.ReadCredentials(context.Background())
+	if err == nil {
+		t.Fatal("wanted error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("got error %q, want error from Vault", err)
+	}
+}
+
+func TestUpdateRefreshToken(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("got method %q, want %q", r.Method, http.MethodPatch)
+		}
+
+		if contentType := r.Header.Get("Content-Type"); contentType != "application/merge-patch+json" {
+			t.Errorf("got content type %q, want merge patch", contentType)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("error reading body: %s", err)
+		}
+
+		if diff := cmp.Diff(string(body), `{"data":{"refresh_token":"new-refresh"}}`); diff != "" {
+			t.Errorf("body differs: -got+want\n%s", diff)
+		}
+
+		fmt.Fprint(w, `{"data":{"version":4}}`)
+	})
+
+	if err := client.UpdateRefreshToken(context.Background(), "new-refresh"); err != nil {
+		t.Fatalf("error updating refresh token: %s", err)
+	}
+}
+
+func TestRenewToken(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/auth/token/renew-self" {
+			t.Errorf("got %s %s, want renewal", r.Method, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{
+				"lease_duration": 3600,
+				"renewable":      true,
+			},
+		})
+	})
+
+	leaseDuration, renewable, err := client.RenewToken(context.Background())
+	if err != nil {
+		t.Fatalf("error renewing token: %s", err)
+	}
+
+	if leaseDuration != time.Hour {
+		t.Errorf("got lease duration %s, want %s", leaseDuration, time.Hour)
+	}
+
+	if !renewable {
+		t.Error("got not renewable, want renewable")
+	}
+}
+
+func TestKeepTokenAliveNotRenewable(t *testing.T) {
+	requests := 0
+	client := testServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"auth":{"lease_duration":0,"renewable":false}}`)
+	})
+
+	client.Status = &Status{}
+
+	// Returns immediately, because a token without expiry does not need to be renewed.
+	client.KeepTokenAlive(context.Background())
+
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+
+	wantMetrics := `# HELP netatmo_vault_token_expiry_time Set to the unix timestamp when the lease of the Vault token ends. 0 if the token does not expire.
+# TYPE netatmo_vault_token_expiry_time gauge
+netatmo_vault_token_expiry_time 0
+`
+	if err := testutil.CollectAndCompare(client.Status, strings.NewReader(wantMetrics), "netatmo_vault_token_expiry_time"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeepTokenAliveMaxTTL(t *testing.T) {
+	client := testServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"auth":{"lease_duration":600,"renewable":false}}`)
+	})
+	logger, hook := test.NewNullLogger()
+	client.Log = logger
+	client.Status = &Status{}
+
+	client.KeepTokenAlive(context.Background())
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Level != logrus.WarnLevel {
+		t.Errorf("got log entry %v, want warning", entry)
+	}
+
+	wantMetrics := `# HELP netatmo_vault_token_renewable Set to 1 if the Vault token is renewed before it expires, 0 if it can not be renewed anymore.
+# TYPE netatmo_vault_token_renewable gauge
+netatmo_vault_token_renewable 0
+`
+	if err := testutil.CollectAndCompare(client.Status, strings.NewReader(wantMetrics), "netatmo_vault_token_renewable"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	client := testServer(t, func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	client.Client.Timeout = 100 * time.Millisecond
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ReadCredentials(context.Background())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("wanted error, got nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading credentials did not time out")
+	}
+}
+
+func TestStatusWriteErrors(t *testing.T) {
+	status := &Status{}
+	status.WriteFailed()
+	status.WriteFailed()
+
+	// The token metrics are missing until the token has been renewed.
+	wantMetrics := `# HELP netatmo_vault_write_errors_total Total number of times writing the refresh token to Vault failed.
+# TYPE netatmo_vault_write_errors_total counter
+netatmo_vault_write_errors_total 2
+`
+	if err := testutil.CollectAndCompare(status, strings.NewReader(wantMetrics)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// BearerAuth creates a handler which only passes requests to next, when they contain the token in the Authorization
+// header. Other requests are rejected with status 401.
+func BearerAuth(token string, next http.Handler) http.Handler {
+	// Comparing hashes does not leak the length of the token.
+	wantHash := sha256.Sum256([]byte(token))
+
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			unauthorized(wr)
+			return
+		}
+
+		gotHash := sha256.Sum256([]byte(header[len(bearerPrefix):]))
+		if subtle.ConstantTimeCompare(gotHash[:], wantHash[:]) != 1 {
+			unauthorized(wr)
+			return
+		}
+
+		next.ServeHTTP(wr, r)
+	})
+}
+
+func unauthorized(wr http.ResponseWriter) {
+	wr.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(wr, "Unauthorized.", http.StatusUnauthorized)
+}
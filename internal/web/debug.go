@@ -9,25 +9,54 @@ import (
 	"github.com/exzz/netatmo-api-go"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
 )
 
-// DebugDataHandler creates a handler which outputs the raw JSON data.
-func DebugDataHandler(log logrus.FieldLogger, readFunc func() (*netatmo.DeviceCollection, error)) http.Handler {
+// DebugDataHandler creates a handler which outputs the raw JSON data together with the details of the stations and
+// modules. The output can be read again using --from-file.
+func DebugDataHandler(log logrus.FieldLogger, readFunc api.StationsFunction) http.Handler {
 	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
-		devices, err := readFunc()
+		devices, details, err := readFunc()
 		if err != nil {
 			http.Error(wr, fmt.Sprintf("Error retrieving data: %s", err), http.StatusBadGateway)
 			return
 		}
 
 		wr.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(wr).Encode(devices); err != nil {
+		if err := json.NewEncoder(wr).Encode(api.StationsFile{DeviceCollection: *devices, Details: details}); err != nil {
 			log.Errorf("Can not encode data debug response: %s", err)
 			return
 		}
 	})
 }
 
+// DebugDeviceHandler creates a handler which outputs the raw JSON data of the device with the ID given in the "id"
+// query parameter. The data contains the location of the station, so it is only available with the debug handlers.
+func DebugDeviceHandler(log logrus.FieldLogger, deviceFunc func(id string) (*netatmo.Device, bool)) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(wr, "The id parameter is required.", http.StatusBadRequest)
+			return
+		}
+
+		device, ok := deviceFunc(id)
+		if !ok {
+			http.Error(wr, fmt.Sprintf("No device with ID %q.", id), http.StatusNotFound)
+			return
+		}
+
+		wr.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(wr)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(device); err != nil {
+			log.Errorf("Can not encode device debug response: %s", err)
+			return
+		}
+	})
+}
+
 // DebugTokenHandler creates a handler which returns information about the currently-used token.
 // For security reasons, the actual token data is not returned.
 func DebugTokenHandler(log logrus.FieldLogger, tokenFunc func() (*oauth2.Token, error)) http.Handler {
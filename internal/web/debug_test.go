@@ -1,6 +1,7 @@
 package web
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -11,8 +12,83 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
 )
 
+func TestDebugDeviceHandler(t *testing.T) {
+	deviceFunc := func(id string) (*netatmo.Device, bool) {
+		if id != "70:ee:50:00:00:01" {
+			return nil, false
+		}
+
+		return &netatmo.Device{
+			ID:         id,
+			ModuleName: "Indoor",
+		}, true
+	}
+
+	tt := []struct {
+		desc       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "success",
+			query:      "?id=70:ee:50:00:00:01",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "missing id",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+			wantBody: `The id parameter is required.
+`,
+		},
+		{
+			desc:       "unknown id",
+			query:      "?id=unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody: `No device with ID "unknown".
+`,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/debug/device"+tc.query, nil)
+
+			h := DebugDeviceHandler(logrus.New(), deviceFunc)
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got code %d, want %d", rec.Code, tc.wantStatus)
+			}
+
+			if tc.wantStatus != http.StatusOK {
+				if diff := cmp.Diff(rec.Body.String(), tc.wantBody); diff != "" {
+					t.Errorf("body differs: -got+want\n%s", diff)
+				}
+				return
+			}
+
+			var device netatmo.Device
+			if err := json.Unmarshal(rec.Body.Bytes(), &device); err != nil {
+				t.Fatalf("error decoding body: %s", err)
+			}
+
+			if device.ModuleName != "Indoor" {
+				t.Errorf("got module %q, want %q", device.ModuleName, "Indoor")
+			}
+		})
+	}
+}
+
 func TestDebugDataHandler(t *testing.T) {
 	createCollection := func(devices []*netatmo.Device) *netatmo.DeviceCollection {
 		dc := &netatmo.DeviceCollection{}
@@ -21,23 +97,35 @@ func TestDebugDataHandler(t *testing.T) {
 	}
 	tt := []struct {
 		desc       string
-		readFunc   func() (*netatmo.DeviceCollection, error)
+		readFunc   api.StationsFunction
 		wantStatus int
 		wantBody   string
 	}{
 		{
 			desc: "success",
-			readFunc: func() (*netatmo.DeviceCollection, error) {
-				return createCollection([]*netatmo.Device{}), nil
+			readFunc: func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+				return createCollection([]*netatmo.Device{}), nil, nil
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"Body":{"devices":[]}}
+`,
+		},
+		{
+			desc: "with details",
+			readFunc: func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+				batteryVoltage := int32(5120)
+				return createCollection([]*netatmo.Device{}), map[string]api.DeviceDetails{
+					"02:00:00:00:00:01": {BatteryVoltage: &batteryVoltage},
+				}, nil
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"Body":{"devices":[]},"details":{"02:00:00:00:00:01":{"battery_vp":5120}}}
 `,
 		},
 		{
 			desc: "error retrieving data",
-			readFunc: func() (*netatmo.DeviceCollection, error) {
-				return nil, errors.New("test error")
+			readFunc: func() (*netatmo.DeviceCollection, map[string]api.DeviceDetails, error) {
+				return nil, nil, errors.New("test error")
 			},
 			wantStatus: http.StatusBadGateway,
 			wantBody: `Error retrieving data: test error
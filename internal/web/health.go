@@ -0,0 +1,25 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// LivenessHandler creates a handler which always reports the exporter as alive, as long as it can serve requests.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(wr, "ok")
+	})
+}
+
+// ReadyHandler creates a handler which reports the exporter as ready once readyFunc returns true.
+func ReadyHandler(readyFunc func() bool) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		if !readyFunc() {
+			http.Error(wr, "No data has been read from the NetAtmo API yet.", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintln(wr, "ok")
+	})
+}
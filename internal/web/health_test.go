@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyHandler(t *testing.T) {
+	tt := []struct {
+		desc       string
+		ready      bool
+		wantStatus int
+	}{
+		{
+			desc:       "not ready",
+			ready:      false,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:       "ready",
+			ready:      true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler := ReadyHandler(func() bool {
+				return tc.ready
+			})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+// MeasurementFunc reads the measurements of a module in the provided time range.
+type MeasurementFunc func(deviceID, moduleID string, types []string, begin, end time.Time) ([]api.Measurement, error)
+
+type moduleHistory struct {
+	Station      string            `json:"station"`
+	Module       string            `json:"module"`
+	ID           string            `json:"id"`
+	Types        []string          `json:"types"`
+	Measurements []api.Measurement `json:"measurements"`
+}
+
+// HistoryHandler creates a handler which returns the historical measurements of all modules for the provided duration
+// before the first request. Once the history has been read successfully, it is cached and not read again.
+func HistoryHandler(log logrus.FieldLogger, readFunc func() (*netatmo.DeviceCollection, error), measurementFunc MeasurementFunc, duration time.Duration) http.Handler {
+	var (
+		lock    sync.Mutex
+		history []moduleHistory
+	)
+
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if history == nil {
+			result, err := readHistory(readFunc, measurementFunc, time.Now(), duration)
+			if err != nil {
+				http.Error(wr, fmt.Sprintf("Error retrieving history: %s", err), http.StatusBadGateway)
+				return
+			}
+
+			log.Infof("Read history of %d modules for the last %s.", len(result), duration)
+			history = result
+		}
+
+		wr.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(wr).Encode(history); err != nil {
+			log.Errorf("Can not encode history response: %s", err)
+			return
+		}
+	})
+}
+
+func readHistory(readFunc func() (*netatmo.DeviceCollection, error), measurementFunc MeasurementFunc, end time.Time, duration time.Duration) ([]moduleHistory, error) {
+	devices, err := readFunc()
+	if err != nil {
+		return nil, fmt.Errorf("error reading devices: %w", err)
+	}
+
+	result := []moduleHistory{}
+	for _, station := range devices.Devices() {
+		stationName := station.StationName //nolint: staticcheck
+		for _, module := range station.Modules() {
+			types := api.MeasureTypes(module.Type)
+			if len(types) == 0 {
+				continue
+			}
+
+			moduleID := module.ID
+			if moduleID == station.ID {
+				moduleID = ""
+			}
+
+			measurements, err := measurementFunc(station.ID, moduleID, types, end.Add(-duration), end)
+			if err != nil {
+				return nil, fmt.Errorf("error reading measurements of %s: %w", module.ID, err)
+			}
+
+			result = append(result, moduleHistory{
+				Station:      stationName,
+				Module:       module.ModuleName,
+				ID:           module.ID,
+				Types:        types,
+				Measurements: measurements,
+			})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	testDevices := &netatmo.DeviceCollection{}
+	testDevices.Body.Devices = []*netatmo.Device{
+		{
+			ID:          "station",
+			ModuleName:  "Indoor",
+			StationName: "Home",
+			Type:        "NAMain",
+			LinkedModules: []*netatmo.Device{
+				{
+					ID:         "outdoor",
+					ModuleName: "Outdoor",
+					Type:       "NAModule1",
+				},
+			},
+		},
+	}
+	readFunc := func() (*netatmo.DeviceCollection, error) {
+		return testDevices, nil
+	}
+	value := 21.5
+
+	tt := []struct {
+		desc            string
+		readFunc        func() (*netatmo.DeviceCollection, error)
+		measurementFunc MeasurementFunc
+		wantStatus      int
+		wantBody        string
+	}{
+		{
+			desc:     "success",
+			readFunc: readFunc,
+			measurementFunc: func(deviceID, moduleID string, types []string, begin, end time.Time) ([]api.Measurement, error) {
+				if deviceID != "station" {
+					return nil, errors.New("wrong device ID")
+				}
+
+				if end.Sub(begin) != time.Hour {
+					return nil, errors.New("wrong duration")
+				}
+
+				return []api.Measurement{
+					{
+						Time:   time.Unix(3600, 0).UTC(),
+						Values: []*float64{&value, nil},
+					},
+				}, nil
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `[{"station":"Home","module":"Outdoor","id":"outdoor","types":["Temperature","Humidity"],"measurements":[{"time":"1970-01-01T01:00:00Z","values":[21.5,null]}]},{"station":"Home","module":"Indoor","id":"station","types":["Temperature","CO2","Humidity","Noise","Pressure"],"measurements":[{"time":"1970-01-01T01:00:00Z","values":[21.5,null]}]}]
+`,
+		},
+		{
+			desc: "error reading devices",
+			readFunc: func() (*netatmo.DeviceCollection, error) {
+				return nil, errors.New("test error")
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "Error retrieving history: error reading devices: test error\n",
+		},
+		{
+			desc:     "error reading measurements",
+			readFunc: readFunc,
+			measurementFunc: func(deviceID, moduleID string, types []string, begin, end time.Time) ([]api.Measurement, error) {
+				return nil, errors.New("test error")
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "Error retrieving history: error reading measurements of outdoor: test error\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/history", nil)
+
+			log := logrus.New()
+			h := HistoryHandler(log, tc.readFunc, tc.measurementFunc, time.Hour)
+
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got code %d, want %d", rec.Code, tc.wantStatus)
+			}
+
+			body := rec.Body.String()
+			if diff := cmp.Diff(body, tc.wantBody); diff != "" {
+				t.Errorf("body differs: -got+want\n%s", diff)
+			}
+		})
+	}
+}
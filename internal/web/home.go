@@ -21,13 +21,19 @@ type homeContext struct {
 	Valid          bool
 	Token          *oauth2.Token
 	NetAtmoDevSite string
+	Scopes         []string
+	BasePath       string
 }
 
 // HomeHandler produces a simple website showing the exporter's status in a human-readable form.
-// It provides links to other information and help for authentication as well.
-func HomeHandler(tokenFunc func() (*oauth2.Token, error)) http.Handler {
+// It provides links to other information and help for authentication as well. The links are relative to basePath,
+// which is the path of the exporter as seen by the browser. Times are shown in location.
+func HomeHandler(tokenFunc func() (*oauth2.Token, error), scopes []string, basePath string, location *time.Location) http.Handler {
 	homeTemplate, err := template.New("home.html").Funcs(map[string]any{
 		"remaining": remaining,
+		"local": func(t time.Time) time.Time {
+			return t.In(location)
+		},
 	}).Parse(homeHtml)
 	if err != nil {
 		panic(err)
@@ -47,6 +53,8 @@ func HomeHandler(tokenFunc func() (*oauth2.Token, error)) http.Handler {
 			Valid:          token.Valid(),
 			Token:          token,
 			NetAtmoDevSite: netatmoDevSite,
+			Scopes:         scopes,
+			BasePath:       basePath,
 		}
 
 		wr.Header().Set("Content-Type", "text/html")
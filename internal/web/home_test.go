@@ -0,0 +1,86 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"golang.org/x/oauth2"
+)
+
+func TestHomeHandlerLinks(t *testing.T) {
+	tt := []struct {
+		desc      string
+		basePath  string
+		wantLinks []string
+	}{
+		{
+			desc:     "root",
+			basePath: "",
+			wantLinks: []string{
+				`href="/auth/authorize"`,
+				`action="/auth/settoken"`,
+				`href="/version"`,
+			},
+		},
+		{
+			desc:     "prefix",
+			basePath: "/netatmo",
+			wantLinks: []string{
+				`href="/netatmo/auth/authorize"`,
+				`action="/netatmo/auth/settoken"`,
+				`href="/netatmo/version"`,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tokenFunc := func() (*oauth2.Token, error) {
+				return nil, netatmo.ErrNotAuthenticated
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			h := HomeHandler(tokenFunc, []string{"read_station"}, tc.basePath, time.UTC)
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("got code %d, want %d", rec.Code, http.StatusOK)
+			}
+
+			body := rec.Body.String()
+			for _, link := range tc.wantLinks {
+				if !strings.Contains(body, link) {
+					t.Errorf("body does not contain %s:\n%s", link, body)
+				}
+			}
+		})
+	}
+}
+
+func TestHomeHandlerTimezone(t *testing.T) {
+	location := time.FixedZone("Test", 2*60*60)
+	tokenFunc := func() (*oauth2.Token, error) {
+		return &oauth2.Token{
+			AccessToken: "access",
+			Expiry:      time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+		}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	HomeHandler(tokenFunc, []string{"read_station"}, "", location).ServeHTTP(rec, req)
+
+	if body := rec.Body.String(); !strings.Contains(body, "2030-01-01 12:00:00 &#43;0200 Test") {
+		t.Errorf("body does not contain expiry in timezone:\n%s", body)
+	}
+}
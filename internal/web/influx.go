@@ -0,0 +1,26 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// InfluxHandler creates a handler which returns the data written by writeFunc in InfluxDB line protocol.
+func InfluxHandler(log logrus.FieldLogger, writeFunc func(io.Writer) error) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if err := writeFunc(&buf); err != nil {
+			http.Error(wr, fmt.Sprintf("Error writing data: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		wr.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := buf.WriteTo(wr); err != nil {
+			log.Errorf("Error writing influx response: %s", err)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestInfluxHandler(t *testing.T) {
+	tt := []struct {
+		desc       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "success",
+			wantStatus: http.StatusOK,
+			wantBody:   "netatmo,module=Indoor temperature=21.5 1700000000000000000\n",
+		},
+		{
+			desc:       "error",
+			err:        errors.New("test error"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Error writing data: test error\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler := InfluxHandler(logrus.New(), func(w io.Writer) error {
+				if tc.err != nil {
+					io.WriteString(w, "partial")
+					return tc.err
+				}
+
+				_, err := io.WriteString(w, "netatmo,module=Indoor temperature=21.5 1700000000000000000\n")
+				return err
+			})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/influx", nil))
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+
+			if rec.Body.String() != tc.wantBody {
+				t.Errorf("got body %q, want %q", rec.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevelHandler creates a handler for reading and changing the log level at runtime.
+// GET returns the current level, POST sets a new level, which is either provided in the "level" parameter or as the
+// request body.
+func LogLevelHandler(log *logrus.Logger) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			value := r.URL.Query().Get("level")
+			if value == "" {
+				body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+				if err != nil {
+					http.Error(wr, fmt.Sprintf("Error reading request: %s", err), http.StatusBadRequest)
+					return
+				}
+				value = strings.TrimSpace(string(body))
+			}
+
+			level, err := logrus.ParseLevel(value)
+			if err != nil {
+				http.Error(wr, fmt.Sprintf("Invalid log level: %s", err), http.StatusBadRequest)
+				return
+			}
+
+			if level != log.GetLevel() {
+				log.Infof("Changing log level from %s to %s.", log.GetLevel(), level)
+				log.SetLevel(level)
+			}
+		default:
+			wr.Header().Set("Allow", "GET, POST")
+			http.Error(wr, "Method not allowed.", http.StatusMethodNotAllowed)
+			return
+		}
+
+		fmt.Fprintln(wr, log.GetLevel())
+	})
+}
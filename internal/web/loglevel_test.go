@@ -0,0 +1,104 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	tt := []struct {
+		desc       string
+		method     string
+		target     string
+		body       string
+		form       bool
+		wantStatus int
+		wantLevel  logrus.Level
+	}{
+		{
+			desc:       "get",
+			method:     http.MethodGet,
+			target:     "/loglevel",
+			wantStatus: http.StatusOK,
+			wantLevel:  logrus.InfoLevel,
+		},
+		{
+			desc:       "post body",
+			method:     http.MethodPost,
+			target:     "/loglevel",
+			body:       "debug\n",
+			wantStatus: http.StatusOK,
+			wantLevel:  logrus.DebugLevel,
+		},
+		{
+			desc:       "post form body",
+			method:     http.MethodPost,
+			target:     "/loglevel",
+			body:       "debug",
+			form:       true,
+			wantStatus: http.StatusOK,
+			wantLevel:  logrus.DebugLevel,
+		},
+		{
+			desc:       "post parameter",
+			method:     http.MethodPost,
+			target:     "/loglevel?level=warn",
+			wantStatus: http.StatusOK,
+			wantLevel:  logrus.WarnLevel,
+		},
+		{
+			desc:       "invalid level",
+			method:     http.MethodPost,
+			target:     "/loglevel",
+			body:       "verbose",
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  logrus.InfoLevel,
+		},
+		{
+			desc:       "put",
+			method:     http.MethodPut,
+			target:     "/loglevel",
+			body:       "debug",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantLevel:  logrus.InfoLevel,
+		},
+		{
+			desc:       "invalid method",
+			method:     http.MethodDelete,
+			target:     "/loglevel",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantLevel:  logrus.InfoLevel,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			log := logrus.New()
+			log.SetLevel(logrus.InfoLevel)
+			handler := LogLevelHandler(log)
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.form {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+
+			if log.GetLevel() != tc.wantLevel {
+				t.Errorf("got level %s, want %s", log.GetLevel(), tc.wantLevel)
+			}
+		})
+	}
+}
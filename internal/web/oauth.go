@@ -6,21 +6,54 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/exzz/netatmo-api-go"
 	"golang.org/x/oauth2"
 )
 
-func AuthorizeHandler(externalURL string, client *netatmo.Client) http.HandlerFunc {
+// OAuthClient contains the methods of the NetAtmo client used during authentication.
+type OAuthClient interface {
+	AuthCodeURL(redirectURL, state string) string
+	Exchange(ctx context.Context, code, state string) error
+	InitWithToken(ctx context.Context, token *oauth2.Token)
+}
+
+var _ OAuthClient = (*netatmo.Client)(nil)
+
+func AuthorizeHandler(externalURL string, scopes []string, client OAuthClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		redirectURL := externalURL + "/auth/callback"
-		authURL := client.AuthCodeURL(redirectURL, "definitelyrandom")
+		authURL, err := AuthCodeURL(client, externalURL, "definitelyrandom", scopes)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error creating authorization URL: %s", err), http.StatusInternalServerError)
+			return
+		}
 
 		http.Redirect(w, r, authURL, http.StatusFound)
 	}
 }
 
-func CallbackHandler(ctx context.Context, client *netatmo.Client) http.HandlerFunc {
+// AuthCodeURL returns the URL of the NetAtmo authorization page, which redirects back to the callback below externalURL.
+func AuthCodeURL(client OAuthClient, externalURL, state string, scopes []string) (string, error) {
+	return withScopes(client.AuthCodeURL(externalURL+"/auth/callback", state), scopes)
+}
+
+// withScopes replaces the scopes requested by the netatmo client, which only requests access to weather stations.
+func withScopes(authURL string, scopes []string) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("scope", strings.Join(scopes, " "))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// CallbackHandler finishes the authorization and redirects to the home page below basePath.
+func CallbackHandler(ctx context.Context, client OAuthClient, basePath string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		values := r.URL.Query()
 		if err := doCallback(ctx, client, values); err != nil {
@@ -29,22 +62,54 @@ func CallbackHandler(ctx context.Context, client *netatmo.Client) http.HandlerFu
 			return
 		}
 
-		http.Redirect(w, r, "/", http.StatusFound)
+		http.Redirect(w, r, basePath+"/", http.StatusFound)
 	}
 }
 
-func doCallback(ctx context.Context, client *netatmo.Client, query url.Values) error {
+func doCallback(ctx context.Context, client OAuthClient, query url.Values) error {
+	code, state, err := parseCallbackQuery(query)
+	if err != nil {
+		return err
+	}
+
+	return client.Exchange(ctx, code, state)
+}
+
+// ParseCallback returns the code and state from input, which is either the URL of the callback or only the code.
+func ParseCallback(input string) (code, state string, err error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", "", errors.New("input is empty")
+	}
+
+	if !strings.Contains(input, "?") {
+		return input, "", nil
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", "", fmt.Errorf("error parsing URL: %w", err)
+	}
+
+	return parseCallbackQuery(u.Query())
+}
+
+// parseCallbackQuery returns the code and state from the query of the callback URL.
+func parseCallbackQuery(query url.Values) (code, state string, err error) {
 	if err := query.Get("error"); err != "" {
-		return errors.New("user did not accept")
+		return "", "", errors.New("user did not accept")
 	}
 
-	state := query.Get("state")
-	code := query.Get("code")
+	code = query.Get("code")
+	if code == "" {
+		return "", "", errors.New("URL contains no code")
+	}
 
-	return client.Exchange(ctx, code, state)
+	return code, query.Get("state"), nil
 }
 
-func SetTokenHandler(ctx context.Context, client *netatmo.Client) http.HandlerFunc {
+// SetTokenHandler uses the refresh token from the form and redirects to the home page below basePath.
+func SetTokenHandler(ctx context.Context, client OAuthClient, basePath string) http.HandlerFunc {
 	return func(wr http.ResponseWriter, r *http.Request) {
 		refreshToken := r.FormValue("refresh_token")
 		if refreshToken == "" {
@@ -57,6 +122,6 @@ func SetTokenHandler(ctx context.Context, client *netatmo.Client) http.HandlerFu
 		}
 		client.InitWithToken(ctx, token)
 
-		http.Redirect(wr, r, "/", http.StatusFound)
+		http.Redirect(wr, r, basePath+"/", http.StatusFound)
 	}
 }
@@ -0,0 +1,171 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+
+	"github.com/exzz/netatmo-api-go"
+)
+
+func TestAuthorizeHandler(t *testing.T) {
+	tt := []struct {
+		desc      string
+		scopes    []string
+		wantScope string
+	}{
+		{
+			desc:      "station only",
+			scopes:    []string{"read_station"},
+			wantScope: "read_station",
+		},
+		{
+			desc:      "multiple scopes",
+			scopes:    []string{"read_station", "read_homecoach"},
+			wantScope: "read_station read_homecoach",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := netatmo.NewClient(netatmo.Config{
+				ClientID:     "id",
+				ClientSecret: "secret",
+			}, nil)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/auth/authorize", nil)
+
+			h := AuthorizeHandler("http://127.0.0.1:9210", tc.scopes, client)
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusFound {
+				t.Errorf("got code %d, want %d", rec.Code, http.StatusFound)
+			}
+
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+
+			query := location.Query()
+			if scope := query.Get("scope"); scope != tc.wantScope {
+				t.Errorf("got scope %q, want %q", scope, tc.wantScope)
+			}
+
+			if redirect := query.Get("redirect_uri"); redirect != "http://127.0.0.1:9210/auth/callback" {
+				t.Errorf("got redirect_uri %q, want callback URL", redirect)
+			}
+		})
+	}
+}
+
+type testOAuthClient struct {
+	OAuthClient
+	token *oauth2.Token
+}
+
+func (c *testOAuthClient) InitWithToken(_ context.Context, token *oauth2.Token) {
+	c.token = token
+}
+
+func TestSetTokenHandlerRedirect(t *testing.T) {
+	client := &testOAuthClient{}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/netatmo/auth/settoken", strings.NewReader("refresh_token=refresh"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	h := SetTokenHandler(context.Background(), client, "/netatmo")
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Errorf("got code %d, want %d", rec.Code, http.StatusFound)
+	}
+
+	if location := rec.Header().Get("Location"); location != "/netatmo/" {
+		t.Errorf("got location %q, want %q", location, "/netatmo/")
+	}
+
+	if client.token == nil || client.token.RefreshToken != "refresh" {
+		t.Errorf("got token %v, want refresh token", client.token)
+	}
+}
+
+func TestCallbackHandlerNoCode(t *testing.T) {
+	// The client panics when Exchange is called.
+	client := &testOAuthClient{}
+
+	rec := httptest.NewRecorder()
+	h := CallbackHandler(context.Background(), client, "/netatmo")
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/netatmo/auth/callback?state=state", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got code %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseCallback(t *testing.T) {
+	tt := []struct {
+		desc      string
+		input     string
+		wantCode  string
+		wantState string
+		wantErr   bool
+	}{
+		{
+			desc:     "only code",
+			input:    " code \n",
+			wantCode: "code",
+		},
+		{
+			desc:      "callback URL",
+			input:     "http://127.0.0.1:9210/auth/callback?state=state&code=code",
+			wantCode:  "code",
+			wantState: "state",
+		},
+		{
+			desc:    "empty",
+			input:   "\n",
+			wantErr: true,
+		},
+		{
+			desc:    "not accepted",
+			input:   "http://127.0.0.1:9210/auth/callback?state=state&error=access_denied",
+			wantErr: true,
+		},
+		{
+			desc:    "no code",
+			input:   "http://127.0.0.1:9210/auth/callback?state=state",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			code, state, err := ParseCallback(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+
+			if code != tc.wantCode {
+				t.Errorf("got code %q, want %q", code, tc.wantCode)
+			}
+
+			if state != tc.wantState {
+				t.Errorf("got state %q, want %q", state, tc.wantState)
+			}
+		})
+	}
+}
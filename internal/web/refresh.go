@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+)
+
+// RefreshHandler creates a handler which refreshes the data immediately on POST requests using refreshFunc. To protect
+// the rate limits of the NetAtmo API, only one refresh is started per minInterval. Further requests are rejected.
+// Requests made while another refresh is running are rejected as well, but do not count towards the limit.
+func RefreshHandler(log logrus.FieldLogger, refreshFunc func() (time.Duration, error), minInterval time.Duration) http.Handler {
+	var (
+		lock        sync.Mutex
+		lastRefresh time.Time
+	)
+
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			wr.Header().Set("Allow", "POST")
+			http.Error(wr, "Method not allowed.", http.StatusMethodNotAllowed)
+			return
+		}
+
+		lock.Lock()
+		now := time.Now()
+		if wait := lastRefresh.Add(minInterval).Sub(now); wait > 0 {
+			lock.Unlock()
+			wr.Header().Set("Retry-After", retryAfter(wait))
+			http.Error(wr, fmt.Sprintf("Too many refreshes, try again in %s.", wait.Round(time.Second)), http.StatusTooManyRequests)
+			return
+		}
+		previousRefresh := lastRefresh
+		lastRefresh = now
+		lock.Unlock()
+
+		log.Info("Refresh requested.")
+		duration, err := refreshFunc()
+		if errors.Is(err, collector.ErrRefreshRunning) {
+			// No refresh has been started, so the next request does not need to wait.
+			lock.Lock()
+			if lastRefresh.Equal(now) {
+				lastRefresh = previousRefresh
+			}
+			lock.Unlock()
+		}
+
+		result := struct {
+			Success  bool   `json:"success"`
+			Duration string `json:"duration"`
+			Error    string `json:"error,omitempty"`
+		}{
+			Success:  err == nil,
+			Duration: duration.String(),
+		}
+
+		wr.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			result.Error = err.Error()
+			switch {
+			case errors.Is(err, collector.ErrRefreshRunning):
+				wr.WriteHeader(http.StatusConflict)
+			case errors.Is(err, api.ErrRateLimited):
+				wr.Header().Set("Retry-After", retryAfter(minInterval))
+				wr.WriteHeader(http.StatusTooManyRequests)
+			default:
+				wr.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+
+		if err := json.NewEncoder(wr).Encode(result); err != nil {
+			log.Errorf("Error encoding refresh result: %s", err)
+		}
+	})
+}
+
+// retryAfter returns the value of the Retry-After header for waiting at least wait.
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(wait.Seconds()) + 1)
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+)
+
+func TestRefreshHandler(t *testing.T) {
+	tt := []struct {
+		desc       string
+		method     string
+		err        error
+		wantStatus int
+		wantRetry  string
+		wantBody   string
+	}{
+		{
+			desc:       "success",
+			method:     http.MethodPost,
+			wantStatus: http.StatusOK,
+			wantBody:   "{\"success\":true,\"duration\":\"1.5s\"}\n",
+		},
+		{
+			desc:       "error",
+			method:     http.MethodPost,
+			err:        errors.New("test error"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "{\"success\":false,\"duration\":\"1.5s\",\"error\":\"test error\"}\n",
+		},
+		{
+			desc:       "refresh running",
+			method:     http.MethodPost,
+			err:        collector.ErrRefreshRunning,
+			wantStatus: http.StatusConflict,
+			wantBody:   "{\"success\":false,\"duration\":\"1.5s\",\"error\":\"another refresh is still running\"}\n",
+		},
+		{
+			desc:       "rate limited",
+			method:     http.MethodPost,
+			err:        api.ErrRateLimited,
+			wantStatus: http.StatusTooManyRequests,
+			wantRetry:  "61",
+			wantBody:   "{\"success\":false,\"duration\":\"1.5s\",\"error\":\"rate limit of the NetAtmo API reached\"}\n",
+		},
+		{
+			desc:       "wrong method",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   "Method not allowed.\n",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler := RefreshHandler(logrus.New(), func() (time.Duration, error) {
+				return 1500 * time.Millisecond, tc.err
+			}, time.Minute)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, "/refresh", nil))
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+
+			if retry := rec.Header().Get("Retry-After"); retry != tc.wantRetry {
+				t.Errorf("got Retry-After %q, want %q", retry, tc.wantRetry)
+			}
+
+			if rec.Body.String() != tc.wantBody {
+				t.Errorf("got body %q, want %q", rec.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestRefreshHandlerRateLimit(t *testing.T) {
+	refreshes := 0
+	handler := RefreshHandler(logrus.New(), func() (time.Duration, error) {
+		refreshes++
+		return 0, nil
+	}, time.Minute)
+
+	for _, wantStatus := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/refresh", nil))
+
+		if rec.Code != wantStatus {
+			t.Errorf("got status %d, want %d", rec.Code, wantStatus)
+		}
+	}
+
+	if refreshes != 1 {
+		t.Errorf("got %d refreshes, want 1", refreshes)
+	}
+}
+
+func TestRefreshHandlerRunning(t *testing.T) {
+	refreshErrs := []error{collector.ErrRefreshRunning, nil, nil}
+	handler := RefreshHandler(logrus.New(), func() (time.Duration, error) {
+		err := refreshErrs[0]
+		refreshErrs = refreshErrs[1:]
+		return 0, err
+	}, time.Minute)
+
+	// Only the rejected request does not use up the limit.
+	for _, wantStatus := range []int{http.StatusConflict, http.StatusOK, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/refresh", nil))
+
+		if rec.Code != wantStatus {
+			t.Errorf("got status %d, want %d", rec.Code, wantStatus)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func (c testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	result, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("error creating key pair: %s", err)
+	}
+
+	return result
+}
+
+// createCert creates a certificate signed by parent. The certificate is self-signed, if parent is nil.
+func createCert(t *testing.T, template *x509.Certificate, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %s", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error encoding key: %s", err)
+	}
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func createCA(t *testing.T, name string) testCert {
+	t.Helper()
+
+	return createCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(fileName, data, 0o600); err != nil {
+		t.Fatalf("error writing %s: %s", name, err)
+	}
+
+	return fileName
+}
+
+func TestTLSConfigClientCertificate(t *testing.T) {
+	ca := createCA(t, "test-ca")
+	otherCA := createCA(t, "other-ca")
+	serverCert := createCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	clientTemplate := func() *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(3),
+			Subject:      pkix.Name{CommonName: "prometheus"},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+	}
+	clientCert := createCert(t, clientTemplate(), &ca)
+	untrustedCert := createCert(t, clientTemplate(), &otherCA)
+
+	tlsConfig, err := TLSConfig(
+		writeFile(t, "server.pem", serverCert.certPEM),
+		writeFile(t, "server-key.pem", serverCert.keyPEM),
+		writeFile(t, "ca.pem", ca.certPEM),
+	)
+	if err != nil {
+		t.Fatalf("error creating TLS config: %s", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.cert)
+
+	tt := []struct {
+		desc    string
+		certs   []tls.Certificate
+		wantErr bool
+	}{
+		{
+			desc:  "valid certificate",
+			certs: []tls.Certificate{clientCert.tlsCertificate(t)},
+		},
+		{
+			desc:    "no certificate",
+			wantErr: true,
+		},
+		{
+			desc:    "untrusted certificate",
+			certs:   []tls.Certificate{untrustedCert.tlsCertificate(t)},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						RootCAs:      rootCAs,
+						Certificates: tc.certs,
+					},
+				},
+			}
+
+			res, err := client.Get(server.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+
+			if err == nil && res.StatusCode != http.StatusNoContent {
+				t.Errorf("got status %d, want %d", res.StatusCode, http.StatusNoContent)
+			}
+		})
+	}
+}
+
+func TestTLSConfigWithoutClientCA(t *testing.T) {
+	ca := createCA(t, "test-ca")
+
+	tlsConfig, err := TLSConfig(writeFile(t, "cert.pem", ca.certPEM), writeFile(t, "key.pem", ca.keyPEM), "")
+	if err != nil {
+		t.Fatalf("error creating TLS config: %s", err)
+	}
+
+	if tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Errorf("got client auth %s, want %s", tlsConfig.ClientAuth, tls.NoClientCert)
+	}
+}
+
+func TestTLSConfigInvalidClientCA(t *testing.T) {
+	ca := createCA(t, "test-ca")
+
+	_, err := TLSConfig(writeFile(t, "cert.pem", ca.certPEM), writeFile(t, "key.pem", ca.keyPEM), writeFile(t, "ca.pem", []byte("invalid")))
+	if err == nil {
+		t.Error("expected error for invalid client CA file")
+	}
+}
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
+)
+
+// UnitsHandler creates a handler which returns the unit of all metrics described by the collectors. The units are
+// looked up by metric name in units. Metrics without a unit have an empty string as unit.
+func UnitsHandler(log logrus.FieldLogger, units map[string]string, collectors ...prometheus.Collector) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		wr.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(wr).Encode(metricUnits(units, collectors)); err != nil {
+			log.Errorf("Can not encode units response: %s", err)
+			return
+		}
+	})
+}
+
+func metricUnits(units map[string]string, collectors []prometheus.Collector) map[string]string {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		defer close(descs)
+		for _, c := range collectors {
+			c.Describe(descs)
+		}
+	}()
+
+	result := make(map[string]string)
+	for desc := range descs {
+		name := collector.MetricName(desc)
+		result[name] = units[name]
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+func TestUnitsHandler(t *testing.T) {
+	temperature := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "netatmo_sensor_temperature_celsius",
+		Help: "Temperature measurement in celsius",
+	})
+	updated := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "netatmo_sensor_updated",
+		Help: "Timestamp of last update",
+	}, []string{"module"})
+	up := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "netatmo_up",
+		Help: "Zero if there was an error during the last refresh try.",
+	})
+
+	units := map[string]string{
+		"netatmo_sensor_temperature_celsius": "celsius",
+		"netatmo_sensor_updated":             "unix timestamp",
+	}
+	handler := UnitsHandler(logrus.New(), units, temperature, updated, up)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/units", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var got map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("error decoding response: %s", err)
+	}
+
+	want := map[string]string{
+		"netatmo_sensor_temperature_celsius": "celsius",
+		"netatmo_sensor_updated":             "unix timestamp",
+		"netatmo_up":                         "",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("units differ: -got+want\n%s", diff)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/config"
+)
+
+var (
+	exitLock    sync.Mutex
+	exitClosers []io.Closer
+)
+
+// listen creates a listener for addr. Addresses starting with "unix:" create a Unix domain socket at the following
+// path, all other addresses are used for TCP. A socket file left over from a previous run is removed first.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, config.UnixSocketPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closing the listener removes the socket file.
+	closeOnExit(listener)
+	return listener, nil
+}
+
+// closeOnExit registers closer to be closed when the exporter exits.
+func closeOnExit(closer io.Closer) {
+	exitLock.Lock()
+	defer exitLock.Unlock()
+
+	exitClosers = append(exitClosers, closer)
+}
+
+// closeAll closes everything registered using closeOnExit.
+func closeAll() {
+	exitLock.Lock()
+	defer exitLock.Unlock()
+
+	for _, closer := range exitClosers {
+		if err := closer.Close(); err != nil {
+			log.Errorf("Error during shutdown: %s", err)
+		}
+	}
+	exitClosers = nil
+}
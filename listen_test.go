@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.sock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("error creating stale socket file: %s", err)
+	}
+
+	listener, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+
+	if got := listener.Addr().Network(); got != "unix" {
+		t.Errorf("got network %q, want %q", got, "unix")
+	}
+
+	closeAll()
+
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("socket file should be removed on exit: %v", err)
+	}
+}
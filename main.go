@@ -2,28 +2,45 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	// The container image does not contain the timezone database.
+	_ "time/tzdata"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"golang.org/x/oauth2"
 
 	"github.com/exzz/netatmo-api-go"
+	"github.com/xperimental/netatmo-exporter/v2/internal/api"
 	"github.com/xperimental/netatmo-exporter/v2/internal/collector"
 	"github.com/xperimental/netatmo-exporter/v2/internal/config"
 	"github.com/xperimental/netatmo-exporter/v2/internal/logger"
+	"github.com/xperimental/netatmo-exporter/v2/internal/pushgateway"
+	"github.com/xperimental/netatmo-exporter/v2/internal/replay"
+	"github.com/xperimental/netatmo-exporter/v2/internal/sharedcache"
 	"github.com/xperimental/netatmo-exporter/v2/internal/token"
+	"github.com/xperimental/netatmo-exporter/v2/internal/transport"
+	"github.com/xperimental/netatmo-exporter/v2/internal/vault"
 	"github.com/xperimental/netatmo-exporter/v2/internal/web"
 )
 
+// minForceRefreshInterval limits how often a refresh can be requested using the /refresh endpoint.
+const minForceRefreshInterval = time.Minute
+
 var (
 	signals = []os.Signal{
 		syscall.SIGINT,
@@ -31,10 +48,19 @@ var (
 	}
 
 	log = logger.NewLogger()
+
+	tokenRefreshes = &token.RefreshCounter{}
+	vaultStatus    = &vault.Status{}
 )
 
 func main() {
-	cfg, err := config.Parse(os.Args, os.Getenv)
+	args := os.Args
+	runAuthorize := len(args) > 1 && args[1] == authorizeCommand
+	if runAuthorize {
+		args = append([]string{args[0]}, args[2:]...)
+	}
+
+	cfg, err := config.Parse(args, os.Getenv)
 	switch {
 	case err == pflag.ErrHelp:
 		return
@@ -43,58 +69,354 @@ func main() {
 	default:
 	}
 	log.SetLevel(logrus.Level(cfg.LogLevel))
+	// The timezone only changes how times are presented. The timestamps in the metrics do not depend on it.
+	location := time.Local
+	if cfg.Timezone != "" {
+		location, err = time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			log.Fatalf("Error loading timezone: %s", err)
+		}
+
+		logger.SetTimezone(log, location)
+	}
 
 	log.Infof("netatmo-exporter %s (commit: %s)", Version, GitCommit)
-	client := netatmo.NewClient(cfg.Netatmo, tokenUpdated(cfg.TokenFile))
+	if features := cfg.Features(); len(features) > 0 {
+		log.Infof("Enabled features: %s", strings.Join(features, ", "))
+	}
+	for _, warning := range cfg.Warnings() {
+		log.Warnf("Configuration: %s", warning)
+	}
 
-	if cfg.TokenFile != "" {
-		token, err := loadToken(cfg.TokenFile)
-		switch {
-		case os.IsNotExist(err):
-		case err != nil:
-			log.Fatalf("Error loading token: %s", err)
-		case !token.Expiry.IsZero() && token.Expiry.Before(time.Now()):
-			log.Warn("Restored token has expired! Token has been ignored.")
-		default:
-			if token.RefreshToken == "" {
-				log.Warn("Restored token has no refresh-token! Exporter will need to be re-authenticated manually.")
-			} else if token.Expiry.IsZero() {
-				log.Warn("Restored token has no expiry time! Token will be renewed immediately.")
-				token.Expiry = time.Now().Add(time.Second)
-			}
+	userAgent := userAgentFor(cfg)
+	apiTransport, err := transport.New(transport.Options{
+		ProxyURL:   cfg.ProxyURL,
+		UserAgent:  userAgent,
+		APIURL:     cfg.APIURL,
+		CACertFile: cfg.CACertFile,
+		// Requests are limited to the refresh interval, so that a hanging request does not block further refreshes.
+		Timeout: cfg.RefreshInterval,
+	})
+	if err != nil {
+		log.Fatalf("Error creating transport: %s", err)
+	}
+	rateLimits := transport.NewRateLimitTracker(apiTransport)
+	apiCalls := transport.NewCallCounter(rateLimits)
+	apiTransport = apiCalls
 
-			log.Infof("Loaded token from %s.", cfg.TokenFile)
-			client.InitWithToken(context.Background(), token)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The netatmo client uses the transport of the HTTP client contained in the context for all requests.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{
+		Transport: apiTransport,
+	})
+
+	updateToken := tokenUpdated(cfg.TokenFile)
+	var vaultSession *vaultSession
+	if cfg.VaultURL != "" {
+		vaultSession, err = startVault(ctx, &cfg, userAgent, vaultStatus, updateToken)
+		if err != nil {
+			log.Fatalf("Error reading credentials from Vault: %s", err)
+		}
+		log.Infof("Read credentials from Vault secret %s/%s.", cfg.VaultMount, cfg.VaultSecretPath)
+
+		updateToken = vaultSession.updateToken
+	}
+
+	netatmoClient := netatmo.NewClient(cfg.Netatmo, updateToken)
+	client := newReloadableClient(netatmoClient, vaultSession)
+	apiClient := api.New(client.CurrentToken, apiTransport, cfg.RefreshInterval)
+
+	scopes := []string{api.ScopeReadStation}
+	// The station data is decoded by the exporter, because the library does not decode all values of the devices.
+	readStationData := apiClient.ReadStationsFunction(cfg.StationIDs)
+	if cfg.FromFile != "" {
+		log.Infof("Reading station data from %s instead of the NetAtmo API.", cfg.FromFile)
+		readStationData = replay.ReadFunction(cfg.FromFile)
+	}
+	if len(cfg.StationIDs) > 0 {
+		log.Infof("Only reading stations: %s", strings.Join(cfg.StationIDs, ", "))
+	}
+	if cfg.SharedCacheFile != "" {
+		log.Infof("Sharing station data using %s.", cfg.SharedCacheFile)
+		readStationData = sharedcache.New(log, cfg.SharedCacheFile, cfg.RefreshInterval, readStationData).ReadStations
+	}
+	stationReader := api.NewStationReader(readStationData)
+	readStations := collector.ReadFunction(stationReader.Read)
+	var readHomeCoaches collector.HomeCoachReadFunction
+	if cfg.EnableHomeCoach {
+		scopes = append(scopes, api.ScopeReadHomeCoach)
+		readHomeCoaches = apiClient.ReadHomeCoaches
+	}
+	var readHomes collector.HomesReadFunction
+	if cfg.EnableEnergy {
+		scopes = append(scopes, api.ScopeReadThermostat)
+		readHomes = apiClient.ReadHomes
+	}
+
+	if runAuthorize {
+		if cfg.FromFile != "" {
+			log.Fatal("Authorization is not possible when reading data from a file.")
 		}
 
-		registerSignalHandler(client, cfg.TokenFile)
-	} else {
+		if err := authorize(ctx, netatmoClient, netatmoClient.CurrentToken, cfg.ExternalURL, scopes, cfg.TokenFile, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Authorization failed: %s", err)
+		}
+
+		return
+	}
+
+	switch {
+	case cfg.FromFile != "":
+	case cfg.TokenFile != "":
+		restored, err := restoreToken(ctx, netatmoClient, cfg.TokenFile)
+		if err != nil {
+			log.Fatalf("Error loading token: %s", err)
+		}
+
+		if !restored && vaultSession != nil {
+			restoreVaultToken(ctx, netatmoClient, vaultSession.refreshToken)
+		}
+	case vaultSession != nil:
+		restoreVaultToken(ctx, netatmoClient, vaultSession.refreshToken)
+	default:
 		log.Warn("No token-file set! Authentication will be lost on restart.")
 	}
+	registerSignalHandler(client.CurrentToken, cfg.TokenFile)
+
+	if cfg.DryRun {
+		if err := dryRun(os.Stdout, readStations, readHomeCoaches); err != nil {
+			log.Fatalf("Dry-run failed: %s", err)
+		}
+
+		return
+	}
+
+	registerReloadHandler(ctx, client)
 
-	metrics := collector.New(log, client.Read, cfg.RefreshInterval, cfg.StaleDuration)
-	prometheus.MustRegister(metrics)
+	metrics := collector.New(log, readStations, cfg.RefreshInterval, cfg.StaleDuration)
+	metrics.ReadHomeCoachFunction = readHomeCoaches
+	metrics.ReadHomesFunction = readHomes
+	metrics.DetailsFunction = stationReader.Details
+	metrics.RefreshJitter = cfg.RefreshJitter
+	metrics.AdaptiveRefresh = cfg.AdaptiveRefresh
+	metrics.AdaptiveRefreshMin = cfg.AdaptiveRefreshMin
+	metrics.AdaptiveRefreshMax = cfg.AdaptiveRefreshMax
+	metrics.IncludeStations = cfg.IncludeStations
+	metrics.ExcludeStations = cfg.ExcludeStations
+	metrics.TemperatureLimits = collector.Limits{Min: cfg.TemperatureMin, Max: cfg.TemperatureMax}
+	metrics.HumidityLimits = collector.Limits{Min: cfg.HumidityMin, Max: cfg.HumidityMax}
+	metrics.TemperaturePrecision = collector.Precision(cfg.RoundTemperature)
+	metrics.PressurePrecision = collector.Precision(cfg.RoundPressure)
+	metrics.CO2AlertThreshold = int32(cfg.CO2AlertThreshold)
+	metrics.HumidityComfort = collector.Limits{Min: float64(cfg.HumidityComfort[0]), Max: float64(cfg.HumidityComfort[1])}
+	metrics.WifiThresholds = collector.SignalThresholds{Bad: int32(cfg.WifiThresholds[0]), Good: int32(cfg.WifiThresholds[1])}
+	metrics.RFThresholds = collector.SignalThresholds{Bad: int32(cfg.RFThresholds[0]), Good: int32(cfg.RFThresholds[1])}
+	metrics.FailOnError = cfg.FailScrapeOnError
+	metrics.SkipUnreachable = cfg.SkipUnreachable
+	metrics.StartupGrace = cfg.StartupGrace
+	metrics.PeakWindow = cfg.PeakWindow
+	metrics.ReadRetries = cfg.ReadRetries
+	metrics.ReadRetryDelay = cfg.ReadRetryDelay
+	if cfg.StationLabelTemplate != "" {
+		stationLabel, err := collector.ParseStationLabelTemplate(cfg.StationLabelTemplate)
+		if err != nil {
+			log.Fatalf("Error in station label template: %s", err)
+		}
+		metrics.StationLabelTemplate = stationLabel
+	}
+	if len(cfg.IncludeStations) > 0 {
+		log.Infof("Only exporting stations: %s", strings.Join(cfg.IncludeStations, ", "))
+	}
+	if len(cfg.ExcludeStations) > 0 {
+		log.Infof("Not exporting stations: %s", strings.Join(cfg.ExcludeStations, ", "))
+	}
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if cfg.DisableRuntimeMetrics {
+		// The default registry contains the Go runtime and process collectors.
+		registry := prometheus.NewRegistry()
+		registerer, gatherer = registry, registry
+	}
+	registerer.MustRegister(collectors.NewBuildInfoCollector())
 
 	tokenMetric := token.Metric(client.CurrentToken)
-	prometheus.MustRegister(tokenMetric)
+	exporterCollectors := []prometheus.Collector{
+		metrics,
+		tokenMetric,
+		tokenRefreshes,
+		rateLimits,
+		apiCalls,
+		config.Metric(cfg),
+	}
+	if cfg.VaultURL != "" {
+		exporterCollectors = append(exporterCollectors, vaultStatus)
+	}
+	if len(cfg.ExtraLabels) > 0 {
+		registerer = prometheus.WrapRegistererWith(cfg.ExtraLabels, registerer)
+	}
+	for _, c := range exporterCollectors {
+		// Registration fails if an extra label has the same name as a label of the metrics.
+		if err := registerer.Register(c); err != nil {
+			log.Fatalf("Error registering metrics: %s", err)
+		}
+	}
+
+	if cfg.Once {
+		metrics.RefreshData(time.Now())
+		if err := printMetrics(os.Stdout, gatherer); err != nil {
+			log.Fatalf("Error printing metrics: %s", err)
+		}
+
+		return
+	}
+
+	if cfg.PushgatewayURL != "" {
+		pusher := pushgateway.New(log, cfg.PushgatewayURL, gatherer)
+		registerer.MustRegister(pusher)
+
+		log.Infof("Pushing metrics to %s every %s.", cfg.PushgatewayURL, cfg.RefreshInterval)
+		go pusher.Run(ctx, cfg.RefreshInterval)
+	}
+
+	metrics.Start()
+
+	externalURL, err := url.Parse(cfg.ExternalURL)
+	if err != nil {
+		log.Fatalf("Error parsing external URL: %s", err)
+	}
+
+	// All handlers are registered below the route prefix, which is empty when serving from the root path.
+	r := newRouter(cfg.RoutePrefix, cfg.AuthBearerToken)
 
 	if cfg.DebugHandlers {
-		http.Handle("/debug/data", web.DebugDataHandler(log, client.Read))
-		http.Handle("/debug/token", web.DebugTokenHandler(log, client.CurrentToken))
+		r.handle("/debug/data", web.DebugDataHandler(log, readStationData))
+		r.handle("/debug/device", web.DebugDeviceHandler(log, metrics.CachedDevice))
+		r.handle("/debug/token", web.DebugTokenHandler(log, client.CurrentToken))
+		r.handle("/loglevel", web.LogLevelHandler(log))
+		r.handle("/config", configHandler(log, cfg))
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if cfg.HistoryHours > 0 {
+		r.handle("/history", web.HistoryHandler(log, readStations, apiClient.ReadMeasurements, time.Duration(cfg.HistoryHours)*time.Hour))
+	}
 
-	http.Handle("/auth/authorize", web.AuthorizeHandler(cfg.ExternalURL, client))
-	http.Handle("/auth/callback", web.CallbackHandler(ctx, client))
-	http.Handle("/auth/settoken", web.SetTokenHandler(ctx, client))
-	http.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}))
-	http.Handle("/version", versionHandler(log))
-	http.Handle("/", web.HomeHandler(client.CurrentToken))
+	registerAuthHandlers(ctx, r, client, cfg.ExternalURL, scopes, externalURL.Path)
+	r.handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics:   true,
+		DisableCompression:  cfg.DisableCompression,
+		MaxRequestsInFlight: cfg.MaxRequestsInFlight,
+		Timeout:             cfg.ScrapeTimeout,
+	}))
+	r.handle("/version", versionHandler(log))
+	// Every package exports the units of its own metrics.
+	units := make(map[string]string)
+	for _, packageUnits := range []map[string]string{collector.Units, config.Units, token.Units, transport.Units, vault.Units} {
+		maps.Copy(units, packageUnits)
+	}
+	r.handle("/units", web.UnitsHandler(log, units, exporterCollectors...))
+	if cfg.EnableInflux {
+		r.handle("/influx", web.InfluxHandler(log, metrics.WriteInflux))
+	}
+	r.handle("/healthz", web.LivenessHandler())
+	r.handle("/ready", web.ReadyHandler(metrics.Ready))
+	r.handle("/refresh", web.RefreshHandler(log, metrics.ForceRefresh, minForceRefreshInterval))
+	r.handle("/", web.HomeHandler(client.CurrentToken, scopes, externalURL.Path, location))
+
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" {
+		tlsConfig, err = web.TLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.ClientCAFile)
+		if err != nil {
+			log.Fatalf("Error in TLS configuration: %s", err)
+		}
+	}
+
+	errCh := make(chan error, len(cfg.Addrs))
+	for _, addr := range cfg.Addrs {
+		listener, err := listen(addr)
+		if err != nil {
+			closeAll()
+			log.Fatalf("Error listening on %s: %s", addr, err)
+		}
+
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+
+		log.Infof("Listen on %s...", addr)
+		go func() {
+			errCh <- http.Serve(listener, r)
+		}()
+	}
 
-	log.Infof("Listen on %s...", cfg.Addr)
-	log.Fatal(http.ListenAndServe(cfg.Addr, nil))
+	// Closing the listeners removes the socket files, which log.Fatal would leave behind.
+	err = <-errCh
+	closeAll()
+	log.Fatal(err)
+}
+
+// configHandler shows the effective configuration with all secrets removed.
+func configHandler(log logrus.FieldLogger, cfg config.Config) http.Handler {
+	redacted := cfg.Redacted()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(redacted); err != nil {
+			log.Errorf("Error encoding configuration: %s", err)
+		}
+	})
+}
+
+// restoreToken initializes the client with the token from the token file. It returns false if there was no usable token.
+func restoreToken(ctx context.Context, client *netatmo.Client, fileName string) (bool, error) {
+	token, err := loadToken(fileName)
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	case !token.Expiry.IsZero() && token.Expiry.Before(time.Now()):
+		log.Warn("Restored token has expired! Token has been ignored.")
+		return false, nil
+	default:
+	}
+
+	if token.RefreshToken == "" {
+		log.Warn("Restored token has no refresh-token! Exporter will need to be re-authenticated manually.")
+	} else if token.Expiry.IsZero() {
+		log.Warn("Restored token has no expiry time! Token will be renewed immediately.")
+		token.Expiry = time.Now().Add(time.Second)
+	}
+
+	log.Infof("Loaded token from %s.", fileName)
+	client.InitWithToken(ctx, token)
+	return true, nil
+}
+
+// userAgentFor returns the User-Agent header sent with all requests.
+func userAgentFor(cfg config.Config) string {
+	if cfg.UserAgent != "" {
+		return cfg.UserAgent
+	}
+
+	return "netatmo-exporter/" + Version
+}
+
+// restoreVaultToken initializes the client with the refresh token stored in Vault, if there is one.
+func restoreVaultToken(ctx context.Context, client *netatmo.Client, refreshToken string) {
+	if refreshToken == "" {
+		return
+	}
+
+	log.Info("Using refresh token from Vault.")
+	client.InitWithToken(ctx, &oauth2.Token{
+		RefreshToken: refreshToken,
+		Expiry:       time.Now().Add(time.Second),
+	})
 }
 
 func loadToken(fileName string) (*oauth2.Token, error) {
@@ -112,7 +434,9 @@ func loadToken(fileName string) (*oauth2.Token, error) {
 	return &token, nil
 }
 
-func registerSignalHandler(client *netatmo.Client, fileName string) {
+// registerSignalHandler stops the exporter on SIGINT and SIGTERM. Before exiting, the token is saved to fileName, if
+// one is set, and everything registered using closeOnExit is closed.
+func registerSignalHandler(tokenFunc func() (*oauth2.Token, error), fileName string) {
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, signals...)
 	go func() {
@@ -120,36 +444,37 @@ func registerSignalHandler(client *netatmo.Client, fileName string) {
 		signal.Reset(signals...)
 		log.Debugf("Got signal: %s", sig)
 
-		if err := saveToken(client, fileName); err != nil {
-			log.Errorf("Error persisting token: %s", err)
+		if fileName != "" {
+			// There is no token to save, when the exporter has not been authorized yet.
+			if err := saveToken(tokenFunc, fileName); err != nil && !errors.Is(err, netatmo.ErrNotAuthenticated) {
+				log.Errorf("Error persisting token: %s", err)
+			}
 		}
 
+		closeAll()
 		os.Exit(0)
 	}()
 }
 
 func tokenUpdated(fileName string) netatmo.TokenUpdateFunc {
-	if fileName == "" {
-		return nil
-	}
-
 	return func(token *oauth2.Token) {
+		tokenRefreshes.Refreshed(token)
 		log.Debugf("Token updated. Expires: %s", token.Expiry)
 
+		if fileName == "" {
+			return
+		}
+
 		if err := saveTokenFile(fileName, token); err != nil {
 			log.Errorf("Error saving token: %s", err)
 		}
 	}
 }
 
-func saveToken(client *netatmo.Client, fileName string) error {
-	token, err := client.CurrentToken()
-	switch {
-	case err == netatmo.ErrNotAuthenticated:
-		return nil
-	case err != nil:
+func saveToken(tokenFunc func() (*oauth2.Token, error), fileName string) error {
+	token, err := tokenFunc()
+	if err != nil {
 		return fmt.Errorf("error retrieving token: %w", err)
-	default:
 	}
 
 	log.Infof("Saving token to %s ...", fileName)
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/exzz/netatmo-api-go"
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/config"
+)
+
+// reloadableClient wraps a netatmo.Client, which can be replaced while the exporter is running.
+type reloadableClient struct {
+	lock   sync.RWMutex
+	client *netatmo.Client
+	vault  *vaultSession
+}
+
+// newReloadableClient creates a reloadableClient. vault is the Vault session used by client, it can be nil.
+func newReloadableClient(client *netatmo.Client, vault *vaultSession) *reloadableClient {
+	return &reloadableClient{
+		client: client,
+		vault:  vault,
+	}
+}
+
+func (c *reloadableClient) current() *netatmo.Client {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.client
+}
+
+// swap replaces the client and its Vault session. The previous Vault session is stopped.
+func (c *reloadableClient) swap(client *netatmo.Client, vault *vaultSession) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.vault != nil {
+		c.vault.stop()
+	}
+
+	c.client = client
+	c.vault = vault
+}
+
+func (c *reloadableClient) CurrentToken() (*oauth2.Token, error) {
+	return c.current().CurrentToken()
+}
+
+func (c *reloadableClient) AuthCodeURL(redirectURL, state string) string {
+	return c.current().AuthCodeURL(redirectURL, state)
+}
+
+func (c *reloadableClient) Exchange(ctx context.Context, code, state string) error {
+	return c.current().Exchange(ctx, code, state)
+}
+
+func (c *reloadableClient) InitWithToken(ctx context.Context, token *oauth2.Token) {
+	c.current().InitWithToken(ctx, token)
+}
+
+// registerReloadHandler reloads the configuration and recreates the NetAtmo client when SIGHUP is received.
+func registerReloadHandler(ctx context.Context, client *reloadableClient) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for range ch {
+			log.Info("Got SIGHUP, reloading credentials...")
+			if err := reloadClient(ctx, client, os.Args, os.Getenv); err != nil {
+				log.Errorf("Error reloading credentials, keeping previous client: %s", err)
+				continue
+			}
+
+			log.Info("Credentials reloaded.")
+		}
+	}()
+}
+
+// reloadClient parses the configuration from args and getenv and replaces the client with a new one using it. The
+// client is kept, if the configuration is invalid.
+func reloadClient(ctx context.Context, client *reloadableClient, args []string, getenv func(string) string) error {
+	cfg, err := config.Parse(args, getenv)
+	if err != nil {
+		return err
+	}
+
+	updateToken := tokenUpdated(cfg.TokenFile)
+	var session *vaultSession
+	if cfg.VaultURL != "" {
+		// The refresh token from Vault is not used, the current authentication is kept like for the token file.
+		session, err = startVault(ctx, &cfg, userAgentFor(cfg), vaultStatus, updateToken)
+		if err != nil {
+			return err
+		}
+
+		updateToken = session.updateToken
+	}
+
+	newClient := netatmo.NewClient(cfg.Netatmo, updateToken)
+	restored := false
+	if cfg.TokenFile != "" {
+		restored, err = restoreToken(ctx, newClient, cfg.TokenFile)
+		if err != nil {
+			if session != nil {
+				session.stop()
+			}
+
+			return err
+		}
+	}
+
+	if !restored {
+		// Keep the current authentication if there is no usable token in the token file.
+		if token, err := client.CurrentToken(); err == nil {
+			newClient.InitWithToken(ctx, token)
+		}
+	}
+
+	client.swap(newClient, session)
+	return nil
+}
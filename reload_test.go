@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/exzz/netatmo-api-go"
+	"golang.org/x/oauth2"
+)
+
+func testReloadableClient(t *testing.T, accessToken string) *reloadableClient {
+	t.Helper()
+
+	client := netatmo.NewClient(netatmo.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+	}, nil)
+	client.InitWithToken(context.Background(), &oauth2.Token{
+		AccessToken:  accessToken,
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(time.Hour),
+	})
+
+	return newReloadableClient(client, nil)
+}
+
+func currentAccessToken(t *testing.T, tokenFunc func() (*oauth2.Token, error)) string {
+	t.Helper()
+
+	token, err := tokenFunc()
+	if err != nil {
+		t.Fatalf("error getting token: %s", err)
+	}
+
+	return token.AccessToken
+}
+
+func TestReloadClient(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token.json")
+	if err := saveTokenFile(tokenFile, &oauth2.Token{
+		AccessToken:  "reloaded",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("error writing token file: %s", err)
+	}
+
+	tt := []struct {
+		desc            string
+		args            []string
+		wantErr         bool
+		wantSwap        bool
+		wantAccessToken string
+	}{
+		{
+			desc:            "valid config",
+			args:            []string{"netatmo-exporter", "--client-id", "new-id", "--client-secret", "new-secret", "--token-file", tokenFile},
+			wantSwap:        true,
+			wantAccessToken: "reloaded",
+		},
+		{
+			desc:            "keep authentication",
+			args:            []string{"netatmo-exporter", "--client-id", "new-id", "--client-secret", "new-secret", "--token-file", filepath.Join(dir, "missing.json")},
+			wantSwap:        true,
+			wantAccessToken: "previous",
+		},
+		{
+			desc:            "invalid config",
+			args:            []string{"netatmo-exporter", "--refresh-interval", "invalid"},
+			wantErr:         true,
+			wantAccessToken: "previous",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := testReloadableClient(t, "previous")
+			previous := client.current()
+			// The API client keeps a reference to this function, so the reads use the current client.
+			tokenFunc := client.CurrentToken
+
+			err := reloadClient(context.Background(), client, tc.args, func(string) string { return "" })
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, want error %v", err, tc.wantErr)
+			}
+
+			if swapped := client.current() != previous; swapped != tc.wantSwap {
+				t.Errorf("got client replaced %v, want %v", swapped, tc.wantSwap)
+			}
+
+			if got := currentAccessToken(t, tokenFunc); got != tc.wantAccessToken {
+				t.Errorf("got access token %q, want %q", got, tc.wantAccessToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/web"
+)
+
+// router registers the HTTP handlers below the route prefix. When a bearer token is configured, all handlers require
+// it, except the ones registered using handlePublic.
+type router struct {
+	*http.ServeMux
+	prefix      string
+	bearerToken string
+}
+
+func newRouter(prefix, bearerToken string) *router {
+	return &router{
+		ServeMux:    http.NewServeMux(),
+		prefix:      prefix,
+		bearerToken: bearerToken,
+	}
+}
+
+// handle registers a handler, which requires the bearer token.
+func (r *router) handle(pattern string, handler http.Handler) {
+	if r.bearerToken != "" {
+		handler = web.BearerAuth(r.bearerToken, handler)
+	}
+
+	r.handlePublic(pattern, handler)
+}
+
+// handlePublic registers a handler, which can be used without the bearer token.
+func (r *router) handlePublic(pattern string, handler http.Handler) {
+	r.Handle(r.prefix+pattern, handler)
+}
+
+// registerAuthHandlers registers the handlers used for authorizing the exporter. Only the redirect to NetAtmo and the
+// callback are public, because the browser does not send the bearer token while following these redirects.
+func registerAuthHandlers(ctx context.Context, r *router, client web.OAuthClient, externalURL string, scopes []string, basePath string) {
+	r.handlePublic("/auth/authorize", web.AuthorizeHandler(externalURL, scopes, client))
+	r.handlePublic("/auth/callback", web.CallbackHandler(ctx, client, basePath))
+	r.handle("/auth/settoken", web.SetTokenHandler(ctx, client, basePath))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/exzz/netatmo-api-go"
+)
+
+func TestRegisterAuthHandlersBearerToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "settoken without token",
+			method:   http.MethodPost,
+			path:     "/netatmo/auth/settoken",
+			body:     "refresh_token=refresh",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "settoken with wrong token",
+			method:   http.MethodPost,
+			path:     "/netatmo/auth/settoken",
+			body:     "refresh_token=refresh",
+			token:    "wrong",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "settoken with token",
+			method:   http.MethodPost,
+			path:     "/netatmo/auth/settoken",
+			body:     "refresh_token=refresh",
+			token:    "bearer-token",
+			wantCode: http.StatusFound,
+		},
+		{
+			name:     "authorize without token",
+			method:   http.MethodGet,
+			path:     "/netatmo/auth/authorize",
+			wantCode: http.StatusFound,
+		},
+		{
+			name:     "callback without token",
+			method:   http.MethodGet,
+			path:     "/netatmo/auth/callback?error=access_denied",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := netatmo.NewClient(netatmo.Config{
+				ClientID:     "id",
+				ClientSecret: "secret",
+			}, nil)
+
+			r := newRouter("/netatmo", "bearer-token")
+			registerAuthHandlers(context.Background(), r, client, "http://127.0.0.1:9210/netatmo", []string{"read_station"}, "/netatmo")
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("got code %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/exzz/netatmo-api-go"
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/config"
+	"github.com/xperimental/netatmo-exporter/v2/internal/transport"
+	"github.com/xperimental/netatmo-exporter/v2/internal/vault"
+)
+
+// vaultSession contains the state of the Vault integration for one NetAtmo client.
+type vaultSession struct {
+	// refreshToken is the refresh token stored in Vault at startup. It can be empty.
+	refreshToken string
+
+	// updateToken calls the next netatmo.TokenUpdateFunc and writes changed refresh tokens back to Vault.
+	updateToken netatmo.TokenUpdateFunc
+
+	// stop ends renewing the Vault token and writing refresh tokens.
+	stop context.CancelFunc
+}
+
+// startVault reads the credentials stored in Vault. The client ID and secret found in Vault replace the configured
+// values. It keeps the Vault token alive and writes changed refresh tokens back to Vault in the background, until the
+// stop function of the returned session is called or ctx is cancelled.
+func startVault(ctx context.Context, cfg *config.Config, userAgent string, status *vault.Status, next netatmo.TokenUpdateFunc) (*vaultSession, error) {
+	// Vault is reached using the same proxy and certificate authorities as the NetAtmo API.
+	vaultTransport, err := transport.New(transport.Options{
+		ProxyURL:   cfg.ProxyURL,
+		UserAgent:  userAgent,
+		CACertFile: cfg.CACertFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating transport: %w", err)
+	}
+
+	client := vault.New(log, cfg.VaultURL, cfg.VaultToken, cfg.VaultMount, cfg.VaultSecretPath, vaultTransport)
+	client.Status = status
+
+	credentials, err := client.ReadCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if credentials.ClientID != "" {
+		cfg.Netatmo.ClientID = credentials.ClientID
+	}
+
+	if credentials.ClientSecret != "" {
+		cfg.Netatmo.ClientSecret = credentials.ClientSecret
+	}
+
+	if cfg.Netatmo.ClientID == "" || cfg.Netatmo.ClientSecret == "" {
+		return nil, errors.New("client ID and secret are neither configured nor stored in Vault")
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	go client.KeepTokenAlive(ctx)
+
+	// The NetAtmo client calls the update function while holding its token lock, so the refresh token is written by a
+	// separate goroutine. Only the latest refresh token is kept when writing falls behind.
+	pending := make(chan string, 1)
+	go writeRefreshTokens(ctx, client, status, credentials.RefreshToken, pending)
+
+	updateToken := func(token *oauth2.Token) {
+		next(token)
+
+		if token.RefreshToken == "" {
+			return
+		}
+
+		select {
+		case <-pending:
+		default:
+		}
+		pending <- token.RefreshToken
+	}
+
+	return &vaultSession{
+		refreshToken: credentials.RefreshToken,
+		updateToken:  updateToken,
+		stop:         stop,
+	}, nil
+}
+
+// writeRefreshTokens writes the refresh tokens received from pending to Vault, until ctx is cancelled. Tokens equal to
+// the last saved one are skipped.
+func writeRefreshTokens(ctx context.Context, client *vault.Client, status *vault.Status, savedRefreshToken string, pending <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case refreshToken := <-pending:
+			if refreshToken == savedRefreshToken {
+				continue
+			}
+
+			if err := client.UpdateRefreshToken(ctx, refreshToken); err != nil {
+				log.Errorf("Error saving refresh token to Vault: %s", err)
+				status.WriteFailed()
+				continue
+			}
+
+			log.Debug("Saved refresh token to Vault.")
+			savedRefreshToken = refreshToken
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/xperimental/netatmo-exporter/v2/internal/config"
+	"github.com/xperimental/netatmo-exporter/v2/internal/vault"
+)
+
+func TestStartVaultWritesInBackground(t *testing.T) {
+	writing := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	written := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{"data":{"data":{"client_id":"id","client_secret":"secret","refresh_token":"initial"}}}`)
+		case http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			writing <- struct{}{}
+			<-unblock
+			written <- string(body)
+		default:
+			// Token without expiry, so it is not renewed.
+			fmt.Fprint(w, `{"auth":{"lease_duration":0,"renewable":false}}`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.Config{
+		VaultURL:        server.URL,
+		VaultToken:      "vault-token",
+		VaultMount:      vault.DefaultMount,
+		VaultSecretPath: "netatmo-exporter",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session, err := startVault(ctx, &cfg, "test", &vault.Status{}, func(*oauth2.Token) {})
+	if err != nil {
+		t.Fatalf("error starting Vault: %s", err)
+	}
+	defer session.stop()
+
+	if session.refreshToken != "initial" {
+		t.Errorf("got refresh token %q, want %q", session.refreshToken, "initial")
+	}
+
+	// Vault does not answer the first write, but updating the token must not block.
+	session.updateToken(&oauth2.Token{RefreshToken: "first"})
+	select {
+	case <-writing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresh token was not written")
+	}
+
+	updated := make(chan struct{})
+	go func() {
+		session.updateToken(&oauth2.Token{RefreshToken: "second"})
+		session.updateToken(&oauth2.Token{RefreshToken: "third"})
+		close(updated)
+	}()
+
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("updating the token blocked on Vault")
+	}
+	close(unblock)
+
+	// The write in progress finishes and only the latest pending token is written after it.
+	var bodies []string
+	for len(bodies) < 2 {
+		select {
+		case body := <-written:
+			bodies = append(bodies, body)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got writes %v, want two", bodies)
+		}
+	}
+
+	if !strings.Contains(bodies[1], `"third"`) {
+		t.Errorf("got second write %s, want latest refresh token", bodies[1])
+	}
+}